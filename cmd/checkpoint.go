@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/spf13/cobra"
+)
+
+var (
+	checkpointComment string
+	checkpointJSON    bool
+	restoreYes        bool
+)
+
+var checkpointCmd = &cobra.Command{
+	Use:   "checkpoint",
+	Short: "Create, list and restore Sprite checkpoints",
+}
+
+var checkpointCreateCmd = &cobra.Command{
+	Use:   "create <sprite-name>",
+	Short: "Checkpoint a Sprite",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+
+		comment := checkpointComment
+		if comment == "" {
+			comment = sprites.CheckpointName(sprites.CheckpointPrefixManual, time.Now())
+		}
+		if err := src.Checkpoint(cmd.Context(), args[0], comment); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Checkpointed %s as %s\n", args[0], comment)
+		return nil
+	},
+}
+
+var checkpointListCmd = &cobra.Command{
+	Use:   "list <sprite-name>",
+	Short: "List a Sprite's checkpoints, newest first",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+
+		cps, err := src.ListCheckpoints(cmd.Context(), args[0])
+		if err != nil {
+			return err
+		}
+
+		if checkpointJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(cps)
+		}
+
+		if len(cps) == 0 {
+			fmt.Printf("No checkpoints for %s.\n", args[0])
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tCREATED")
+		fmt.Fprintln(w, "──\t────\t───────")
+		for _, cp := range cps {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", cp.ID, cp.Comment, formatCheckpointTime(cp.CreatedAt))
+		}
+		return w.Flush()
+	},
+}
+
+var checkpointRestoreCmd = &cobra.Command{
+	Use:   "restore <sprite-name> <checkpoint-id>",
+	Short: "Restore a Sprite to a checkpoint",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, id := args[0], args[1]
+		if !restoreYes {
+			ok, err := promptYesNo(cmd.InOrStdin(), cmd.OutOrStdout(),
+				fmt.Sprintf("Restore %s to %s? Changes since then will be lost.", name, id))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(cmd.OutOrStdout(), "Cancelled.")
+				return nil
+			}
+		}
+
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+		if err := src.RestoreCheckpoint(cmd.Context(), name, id); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Restored %s to %s\n", name, id)
+		return nil
+	},
+}
+
+func init() {
+	checkpointCreateCmd.Flags().StringVarP(&checkpointComment, "name", "n", "", "Checkpoint name (default manual-YYYYMMDD-HHMMSS)")
+	checkpointListCmd.Flags().BoolVar(&checkpointJSON, "json", false, "Output as JSON")
+	checkpointRestoreCmd.Flags().BoolVarP(&restoreYes, "yes", "y", false, "Restore without prompting")
+
+	checkpointCmd.AddCommand(checkpointCreateCmd, checkpointListCmd, checkpointRestoreCmd)
+	rootCmd.AddCommand(checkpointCmd)
+}
+
+// formatCheckpointTime renders a checkpoint timestamp in local time.
+func formatCheckpointTime(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// promptYesNo asks question and reports whether the answer was yes.
+// Anything else, including an empty answer, means no.
+func promptYesNo(in io.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprintf(out, "%s [y/N]: ", question)
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
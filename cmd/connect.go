@@ -1,19 +1,67 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"time"
 
+	"github.com/JPM1118/slua/internal/config"
 	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/state"
 	"github.com/spf13/cobra"
 )
 
+var (
+	connectSession string
+	connectNew     bool
+)
+
 var connectCmd = &cobra.Command{
 	Use:   "connect <sprite-name>",
 	Short: "Connect to a Sprite console session",
-	Args:  cobra.ExactArgs(1),
+	Long: `Connect the terminal to a Sprite.
+
+By default this attaches to the exec session last attached to, if it is
+still running, and otherwise opens a new console. --session attaches to a
+given session and remembers it; --new opens a new console and forgets it.
+See slua sessions list for a Sprite's sessions.`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cli := &sprites.CLI{Org: org}
-		c := cli.ConsoleCmd(args[0])
+		if connectSession != "" && connectNew {
+			return errors.New("--session and --new cannot be used together")
+		}
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+		name, err := stateKey(cmd.Context(), src, args[0])
+		if err != nil {
+			return err
+		}
+
+		statePath, err := config.StatePath()
+		if err != nil {
+			return err
+		}
+		store := state.NewStore(statePath)
+
+		id := connectSession
+		if id == "" && !connectNew {
+			id = preferredSession(cmd, src, store, name)
+		}
+
+		err = store.Update(func(st *state.State) {
+			st.Record(state.Event{At: time.Now(), Sprite: name, Kind: state.EventConnect, User: state.CurrentUser(), Session: id})
+		})
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "slua: could not save state: %s\n", err)
+		}
+
+		c := src.ConsoleCmd(name)
+		if id != "" {
+			c = src.AttachCmd(name, id)
+		}
 		c.Stdin = os.Stdin
 		c.Stdout = os.Stdout
 		c.Stderr = os.Stderr
@@ -21,6 +69,31 @@ var connectCmd = &cobra.Command{
 	},
 }
 
+// preferredSession returns the session name was last attached to, or ""
+// if there is none or it has ended. When the sessions cannot be listed the
+// session is tried anyway.
+func preferredSession(cmd *cobra.Command, src sprites.SpriteSource, store *state.Store, name string) string {
+	st, err := store.Load()
+	if err != nil {
+		return ""
+	}
+	session := st.Local[name].Session
+	if session == "" {
+		return ""
+	}
+	sessions, err := src.ListSessions(cmd.Context(), name)
+	if err != nil {
+		return session
+	}
+	if _, ok := sprites.FindSession(sessions, session); !ok {
+		fmt.Fprintf(cmd.ErrOrStderr(), "Session %s on %s has ended; opening a new console.\n", session, name)
+		return ""
+	}
+	return session
+}
+
 func init() {
+	connectCmd.Flags().StringVarP(&connectSession, "session", "S", "", "Exec session ID to attach to")
+	connectCmd.Flags().BoolVar(&connectNew, "new", false, "Open a new console instead of attaching")
 	rootCmd.AddCommand(connectCmd)
 }
@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JPM1118/slua/internal/config"
+	"github.com/JPM1118/slua/internal/cost"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/spf13/cobra"
+)
+
+var (
+	costSince string
+	costBy    string
+	costCSV   bool
+	costJSON  bool
+)
+
+var costCmd = &cobra.Command{
+	Use:   "cost",
+	Short: "Estimate what Sprites cost from their time awake and asleep",
+	Long: `Estimate what Sprites cost from the time they spent awake and asleep,
+priced with the rates under cost: in the config file.
+
+Usage is recorded by the dashboard in the state file, one entry per Sprite
+and day. Time is counted from when slua first saw a Sprite; while slua is
+not running a Sprite is assumed to stay in the state it was last seen in.
+Storage is charged at cost.storage_gb per Sprite, awake or asleep, since
+disk usage is not reported.
+
+--since takes a date (2026-10-01) or a duration (7d, 36h) and defaults to
+the start of the month. Days are whole days.
+
+  slua cost --by tag
+  slua cost --since 30d --by day --csv`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{annotationLocal: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if costCSV && costJSON {
+			return errors.New("--csv and --json cannot be used together")
+		}
+		now := time.Now()
+		since, err := parseSince(costSince, now)
+		if err != nil {
+			return err
+		}
+		statePath, err := config.StatePath()
+		if err != nil {
+			return err
+		}
+		st, err := state.NewStore(statePath).Load()
+		if err != nil {
+			return err
+		}
+
+		rates := cfg.CostRates()
+		usage := cost.Since(st.UsageAt(now), since)
+		lines, err := cost.Report(usage, rates, costBy, st.Tags())
+		if err != nil {
+			return err
+		}
+		total := cost.Total(usage, rates)
+
+		out := cmd.OutOrStdout()
+		switch {
+		case costJSON:
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(struct {
+				Since    string      `json:"since"`
+				By       string      `json:"by"`
+				Currency string      `json:"currency"`
+				Lines    []cost.Line `json:"lines"`
+				Total    cost.Line   `json:"total"`
+			}{since.Format(state.DayFormat), costBy, rates.Currency, lines, total})
+		case costCSV:
+			return writeCostCSV(out, append(lines, total))
+		}
+
+		if !rates.Enabled() {
+			fmt.Fprintln(cmd.ErrOrStderr(), "slua: no rates configured; set cost.running_hour or cost.storage_gb_month in the config file")
+		}
+		if len(lines) == 0 {
+			fmt.Fprintf(out, "No usage recorded since %s.\n", since.Format(state.DayFormat))
+			return nil
+		}
+		w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		key := strings.ToUpper(costBy)
+		fmt.Fprintf(w, "%s\tAWAKE\tASLEEP\tCOST\n", key)
+		fmt.Fprintf(w, "%s\t─────\t──────\t────\n", strings.Repeat("─", len(key)))
+		for _, l := range append(lines, total) {
+			if l.Key == total.Key {
+				l.Key = "TOTAL"
+			}
+			fmt.Fprintf(w, "%s\t%.1fh\t%.1fh\t%s\n", l.Key, l.Awake, l.Asleep, rates.Format(l.Cost))
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if costBy == cost.ByTag {
+			fmt.Fprintln(out, "\nSprites with several tags count towards each; the total counts them once.")
+		}
+		return nil
+	},
+}
+
+func init() {
+	costCmd.Flags().StringVar(&costSince, "since", "", "Start date (2006-01-02) or duration (7d, 36h); default the start of the month")
+	costCmd.Flags().StringVar(&costBy, "by", cost.BySprite, "Group by sprite, tag, day or month")
+	costCmd.Flags().BoolVar(&costCSV, "csv", false, "Output as CSV")
+	costCmd.Flags().BoolVar(&costJSON, "json", false, "Output as JSON")
+	rootCmd.AddCommand(costCmd)
+}
+
+// parseSince reads --since relative to now: a date, a duration with an
+// optional d suffix for days, or empty for the start of the month.
+func parseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()), nil
+	}
+	if t, err := time.ParseInLocation(state.DayFormat, s, now.Location()); err == nil {
+		return t, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: want a date like 2006-01-02 or a duration like 7d", s)
+}
+
+func writeCostCSV(out io.Writer, lines []cost.Line) error {
+	w := csv.NewWriter(out)
+	w.Write([]string{costBy, "awake_hours", "asleep_hours", "cost"})
+	for _, l := range lines {
+		w.Write([]string{
+			l.Key,
+			strconv.FormatFloat(l.Awake, 'f', 2, 64),
+			strconv.FormatFloat(l.Asleep, 'f', 2, 64),
+			strconv.FormatFloat(l.Cost, 'f', 2, 64),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -1,9 +1,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"sync"
 
-	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/config"
+	"github.com/JPM1118/slua/internal/notify"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/templates"
 	"github.com/JPM1118/slua/internal/tui"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/spf13/cobra"
@@ -22,9 +28,51 @@ func init() {
 }
 
 func runDashboard() error {
-	cli := &sprites.CLI{Org: org}
+	src, err := newSource()
+	if err != nil {
+		return err
+	}
 
-	model := tui.NewDashboard(cli)
+	statePath, err := config.StatePath()
+	if err != nil {
+		return err
+	}
+	store := state.NewStore(statePath)
+	// Broken templates are left out and shown as warnings.
+	tmpls, tmplErr := cfg.SpriteTemplates()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pl := poller.New(src)
+	cfg.Configure(pl)
+	saved := detectByTemplate(pl, tmpls, store)
+	stateDir, err := config.StateDir()
+	if err != nil {
+		return err
+	}
+	warnings := templateWarnings(tmplErr)
+	if cfg.Notifications.Desktop && !notify.DesktopAvailable() {
+		warnings = append(warnings, "notifications.desktop: "+notify.ErrNoDesktop.Error())
+		cfg.Notifications.Desktop = false
+	}
+	n := cfg.NewNotifier(stateDir)
+	pl.Observe(func(c poller.Cycle) { n.NotifyCycle(ctx, c) })
+	go n.Flush(ctx)
+	go pl.Run(ctx)
+
+	tui.SetPalette(tui.Palette(cfg.Display.Colors))
+	model := tui.NewDashboard(src,
+		tui.WithPoller(pl),
+		tui.WithNotifier(n),
+		tui.WithState(store),
+		tui.WithOnSave(saved),
+		tui.WithAutoCheckpoint(cfg.AutoCheckpointPolicies()),
+		tui.WithColumns(tui.Columns(cfg.Display.Columns)),
+		tui.WithTemplates(tmpls),
+		tui.WithCost(cfg.CostRates()),
+		tui.WithQueue(cfg.QueueOptions()),
+		tui.WithWarnings(warnings...),
+	)
 	p := tea.NewProgram(model, tea.WithAltScreen())
 
 	finalModel, err := p.Run()
@@ -37,3 +85,25 @@ func runDashboard() error {
 	}
 	return nil
 }
+
+// detectByTemplate has pl choose detectors by template for Sprites the
+// state file says were created from one. The templates are read once; the
+// returned function takes the state each time it is saved, to keep up.
+func detectByTemplate(pl *poller.Poller, tmpls []templates.Template, store *state.Store) func(*state.State) {
+	var mu sync.Mutex
+	var madeFrom map[string]string
+	if st, err := store.Load(); err == nil {
+		madeFrom = st.Templates()
+	}
+	pl.DetectorFor = cfg.DetectorFor(tmpls, func(name string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		return madeFrom[name]
+	})
+	return func(st *state.State) {
+		m := st.Templates()
+		mu.Lock()
+		madeFrom = m
+		mu.Unlock()
+	}
+}
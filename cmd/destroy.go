@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/spf13/cobra"
+)
+
+var (
+	destroyCheckpoint bool
+	destroyYes        bool
+)
+
+var destroyCmd = &cobra.Command{
+	Use:   "destroy <sprite-name>",
+	Short: "Destroy a Sprite, optionally checkpointing it first",
+	Long: `Destroy a Sprite. Without flags, asks whether to checkpoint first.
+
+Use --checkpoint to checkpoint then destroy, or --yes to destroy without a
+checkpoint, skipping the prompt.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := args[0]
+		if destroyCheckpoint && destroyYes {
+			return fmt.Errorf("--checkpoint and --yes are mutually exclusive")
+		}
+
+		checkpoint := destroyCheckpoint
+		if !destroyCheckpoint && !destroyYes {
+			choice, err := promptDestroy(cmd.InOrStdin(), cmd.OutOrStdout(), name)
+			if err != nil {
+				return err
+			}
+			switch choice {
+			case "1":
+				checkpoint = true
+			case "2":
+			default:
+				fmt.Fprintln(cmd.OutOrStdout(), "Cancelled.")
+				return nil
+			}
+		}
+
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+
+		if checkpoint {
+			comment := sprites.CheckpointName(sprites.CheckpointPrefixManual, time.Now())
+			if err := src.Checkpoint(cmd.Context(), name, comment); err != nil {
+				return fmt.Errorf("checkpoint failed, not destroying: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Checkpointed %s as %s\n", name, comment)
+		}
+
+		if err := src.Destroy(cmd.Context(), name); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Destroyed %s\n", name)
+		return nil
+	},
+}
+
+func init() {
+	destroyCmd.Flags().BoolVar(&destroyCheckpoint, "checkpoint", false, "Checkpoint before destroying, without prompting")
+	destroyCmd.Flags().BoolVarP(&destroyYes, "yes", "y", false, "Destroy without a checkpoint, without prompting")
+	rootCmd.AddCommand(destroyCmd)
+}
+
+// promptDestroy shows the same three choices as the dashboard dialog and
+// returns the answer. Anything other than 1 or 2 means cancel.
+func promptDestroy(in io.Reader, out io.Writer, name string) (string, error) {
+	fmt.Fprintf(out, "Destroy %s? This cannot be undone.\n", name)
+	fmt.Fprintln(out, "  1) Checkpoint then destroy")
+	fmt.Fprintln(out, "  2) Destroy without checkpoint")
+	fmt.Fprintln(out, "  3) Cancel")
+	fmt.Fprint(out, "Choice [3]: ")
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JPM1118/slua/internal/fleet"
+	"github.com/spf13/cobra"
+)
+
+var (
+	execAll         bool
+	execFilter      string
+	execSprites     []string
+	execConcurrency int
+	execTimeout     time.Duration
+	execJSON        bool
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec (--all | --filter expr | -s name...) -- command [args...]",
+	Short: "Run a command on many Sprites in parallel",
+	Long: `Run a command on many Sprites in parallel and summarize the exit codes.
+
+Output is streamed as it arrives, each line prefixed with its Sprite's name.
+Sleeping Sprites are woken to run the command. slua exits non-zero if the
+command failed, timed out or could not run on any Sprite.
+
+--filter takes whitespace-separated terms that must all match: a glob
+matched against the name, or key=glob and key!=glob for name, status and
+region. With several organizations a name glob matches the name without
+its organization unless it has an org/ part, as in 'work/web-*'. Filtering
+on status detects the Claude Code state first.
+
+  slua exec --all -- git pull
+  slua exec --filter 'web-* region=ord' -- df -h /
+  slua exec -s api -s worker --json -- git status --short`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() != 0 {
+			return errors.New("put the command after --, e.g. slua exec --all -- git pull")
+		}
+		filter, err := fleet.ParseFilter(execFilter)
+		if err != nil {
+			return err
+		}
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+
+		names := execSprites
+		if len(names) == 0 {
+			list, err := src.List(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if filter.NeedsStatus() {
+				applyDetection(cmd.Context(), src, list)
+			}
+			for _, s := range list {
+				if filter.Match(s) {
+					names = append(names, s.Name)
+				}
+			}
+			if len(names) == 0 {
+				return errors.New("no Sprites match")
+			}
+		}
+
+		opts := fleet.Options{Concurrency: execConcurrency, Timeout: execTimeout}
+		var mu sync.Mutex
+		if execJSON {
+			enc := json.NewEncoder(os.Stdout)
+			opts.Done = func(r fleet.Result) {
+				mu.Lock()
+				defer mu.Unlock()
+				enc.Encode(r)
+			}
+		} else {
+			width := 0
+			for _, n := range names {
+				width = max(width, len(n))
+			}
+			opts.Output = func(name string) (io.Writer, io.Writer) {
+				prefix := fmt.Sprintf("%-*s │ ", width, name)
+				return fleet.NewPrefixWriter(os.Stdout, prefix, &mu), fleet.NewPrefixWriter(os.Stderr, prefix, &mu)
+			}
+		}
+
+		results := fleet.Run(cmd.Context(), src, names, args, opts)
+
+		failed := 0
+		for _, r := range results {
+			if !r.OK() {
+				failed++
+			}
+		}
+		if !execJSON {
+			if err := printExecSummary(os.Stdout, results); err != nil {
+				return err
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("failed on %d of %d Sprites", failed, len(results))
+		}
+		return nil
+	},
+}
+
+func init() {
+	execCmd.Flags().BoolVar(&execAll, "all", false, "Run on every Sprite")
+	execCmd.Flags().StringVar(&execFilter, "filter", "", "Run on Sprites matching expr, e.g. 'web-* status=waiting'")
+	execCmd.Flags().StringArrayVarP(&execSprites, "sprite", "s", nil, "Run on this Sprite (repeatable)")
+	execCmd.Flags().IntVar(&execConcurrency, "concurrency", fleet.DefaultConcurrency, "Sprites to run on at once")
+	execCmd.Flags().DurationVar(&execTimeout, "timeout", fleet.DefaultTimeout, "Time limit per Sprite")
+	execCmd.Flags().BoolVar(&execJSON, "json", false, "Print one JSON result per Sprite as it finishes, instead of output and a summary")
+	execCmd.MarkFlagsMutuallyExclusive("all", "filter", "sprite")
+	execCmd.MarkFlagsOneRequired("all", "filter", "sprite")
+	rootCmd.AddCommand(execCmd)
+}
+
+// printExecSummary writes a table of each Sprite's exit code.
+func printExecSummary(out io.Writer, results []fleet.Result) error {
+	fmt.Fprintln(out)
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "SPRITE\tEXIT\tTIME\tERROR")
+	fmt.Fprintln(w, "──────\t────\t────\t─────")
+	for _, r := range results {
+		exit := fmt.Sprint(r.ExitCode)
+		if r.Error != "" {
+			exit = "—"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Sprite, exit, r.Duration.Round(100*time.Millisecond), r.Error)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/JPM1118/slua/internal/fleet"
+	"github.com/JPM1118/slua/internal/hooks"
+	"github.com/spf13/cobra"
+)
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Have Claude Code report its state through hooks",
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install <sprite-name>...",
+	Short: "Install slua's Claude Code hooks on Sprites",
+	Long: `Install Claude Code hooks that log each prompt, tool call, notification
+and stop to ~/` + hooks.LogFile + ` on the Sprite. The default hooks
+detector reads that log instead of guessing from the tmux pane, and shows
+the exact permission request a WAITING Sprite is stuck on. Only the event,
+the tool and a one-line summary of its input are logged, and the log
+starts over past 1 MiB.
+
+The hooks are added to ~/` + hooks.SettingsFile + `, keeping the rest of
+it. A Claude Code session that is already running picks them up when it
+is restarted (R in the dashboard). Templates install them with
+claude.hooks: true.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+
+		var errs []error
+		for _, name := range args {
+			ctx, cancel := context.WithTimeout(cmd.Context(), fleet.DefaultTimeout)
+			err := hooks.Install(ctx, src, name)
+			cancel()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Installed hooks on %s\n", name)
+		}
+		return errors.Join(errs...)
+	},
+}
+
+func init() {
+	hooksCmd.AddCommand(hooksInstallCmd)
+	rootCmd.AddCommand(hooksCmd)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/JPM1118/slua/internal/logs"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/spf13/cobra"
+)
+
+var (
+	logsFollow   bool
+	logsService  string
+	logsLines    int
+	logsInterval time.Duration
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <sprite-name>",
+	Short: "Print a Sprite's Claude Code pane or service log",
+	Long: `Print the Claude Code tmux pane of a Sprite, or with --service the log of
+one of its services, without attaching a console.
+
+Colors are kept when writing to a terminal. With -f, new lines are printed
+as they appear until interrupted. Reading the pane wakes a sleeping Sprite.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+		target := logs.Target{Sprite: args[0]}
+		if logsService != "" {
+			target, err = logs.ResolveService(cmd.Context(), src, args[0], logsService)
+			if err != nil {
+				return err
+			}
+		}
+
+		out := cmd.OutOrStdout()
+		color := isTerminal(out)
+		emit := func(lines []string) {
+			for _, l := range lines {
+				if !color {
+					l = ansi.Strip(l)
+				}
+				fmt.Fprintln(out, l)
+			}
+		}
+
+		if !logsFollow {
+			lines, err := logs.Fetch(cmd.Context(), src, target, logsLines)
+			if err != nil {
+				return err
+			}
+			emit(lines)
+			return nil
+		}
+		return logs.Follow(cmd.Context(), src, target, logsLines, logsInterval, emit, func(err error) {
+			fmt.Fprintf(cmd.ErrOrStderr(), "slua: %s (retrying)\n", err)
+		})
+	},
+}
+
+func init() {
+	logsCmd.Flags().BoolVarP(&logsFollow, "follow", "f", false, "Keep printing new lines")
+	logsCmd.Flags().StringVarP(&logsService, "service", "S", "", "Service ID or name to read instead of the Claude Code pane")
+	logsCmd.Flags().IntVarP(&logsLines, "lines", "n", 100, "Number of lines to print first")
+	logsCmd.Flags().DurationVar(&logsInterval, "interval", logs.DefaultInterval, "Time between reads with --follow")
+	rootCmd.AddCommand(logsCmd)
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
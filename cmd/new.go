@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/config"
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newRegion   string
+	newTemplate string
+)
+
+var newCmd = &cobra.Command{
+	Use:   "new [sprite-name]",
+	Short: "Create a Sprite and bootstrap it from a template",
+	Long: `Create a Sprite, wait for it to start, then run the chosen template's
+setup steps on it.
+
+Asks for the name, region and template unless they are given as arguments
+or flags, and for the organization when several are configured and --org
+does not pick one. Templates come from "templates:" in the config file, YAML files
+in the templates directory next to it, and .slua.yml in the current
+directory. See 'slua template --help'.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		in := bufio.NewReader(cmd.InOrStdin())
+		out := cmd.OutOrStdout()
+		ts := spriteTemplates(cmd)
+
+		name := ""
+		if len(args) > 0 {
+			name = args[0]
+		} else {
+			answer, err := prompt(in, out, "Name: ")
+			if err != nil {
+				return err
+			}
+			name = answer
+		}
+		if err := sprites.ValidateName(name); err != nil {
+			return err
+		}
+
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+		dst, key := src, name
+		if m, ok := src.(*sprites.Multi); ok {
+			org, err := chooseOrg(in, out, m.Orgs())
+			if err != nil {
+				return err
+			}
+			dst, key = m.In(org), org+"/"+name
+		}
+
+		region := newRegion
+		if !cmd.Flags().Changed("region") {
+			answer, err := prompt(in, out, "Region [default]: ")
+			if err != nil {
+				return err
+			}
+			region = answer
+		}
+
+		t, err := chooseTemplate(in, out, ts, newTemplate, cmd.Flags().Changed("template"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "Creating %s from %s\n", key, t.Name)
+		err = templates.Provision(cmd.Context(), dst, name, region, t, func(p templates.Progress) {
+			fmt.Fprintf(out, "  %s\n", p)
+		})
+		var stepErr *templates.StepError
+		if err == nil || errors.As(err, &stepErr) {
+			// The Sprite exists: remember its template, as the dashboard does.
+			recordCreate(cmd, key, t.Name, err)
+		}
+		if err != nil {
+			return fmt.Errorf("create %s: %w", key, err)
+		}
+		fmt.Fprintf(out, "Created %s. Connect with: slua connect %s\n", key, key)
+		return nil
+	},
+}
+
+func init() {
+	newCmd.Flags().StringVarP(&newRegion, "region", "r", "", "Region to create the Sprite in (default chosen by Sprites)")
+	newCmd.Flags().StringVarP(&newTemplate, "template", "t", "", "Template to bootstrap with (default blank)")
+	rootCmd.AddCommand(newCmd)
+}
+
+// chooseTemplate returns the template called name if set, the only
+// template if there is one, or asks.
+func chooseTemplate(in *bufio.Reader, out io.Writer, ts []templates.Template, name string, set bool) (templates.Template, error) {
+	if set {
+		for _, t := range ts {
+			if t.Name == name {
+				return t, nil
+			}
+		}
+		return templates.Template{}, fmt.Errorf("unknown template %q", name)
+	}
+	if len(ts) == 1 {
+		return ts[0], nil
+	}
+
+	fmt.Fprintln(out, "Template:")
+	for i, t := range ts {
+		line := fmt.Sprintf("  %d) %s", i+1, t.Name)
+		if t.Description != "" {
+			line += " — " + t.Description
+		}
+		fmt.Fprintln(out, line)
+	}
+	answer, err := prompt(in, out, "Choice [1]: ")
+	if err != nil {
+		return templates.Template{}, err
+	}
+	if answer == "" {
+		return ts[0], nil
+	}
+	n, err := strconv.Atoi(answer)
+	if err != nil || n < 1 || n > len(ts) {
+		return templates.Template{}, fmt.Errorf("invalid choice %q", answer)
+	}
+	return ts[n-1], nil
+}
+
+// recordCreate records in the state file that name was created from
+// template, noting err if its setup failed.
+func recordCreate(cmd *cobra.Command, name, template string, err error) {
+	detail := template
+	if err != nil {
+		detail += " (" + err.Error() + ")"
+	}
+	statePath, err := config.StatePath()
+	if err == nil {
+		err = state.NewStore(statePath).Update(func(st *state.State) {
+			st.Record(state.Event{At: time.Now(), Sprite: name, Kind: state.EventCreate, Detail: detail, User: state.CurrentUser()})
+		})
+	}
+	if err != nil {
+		fmt.Fprintf(cmd.ErrOrStderr(), "slua: could not save state: %s\n", err)
+	}
+}
+
+// chooseOrg returns the only organization in orgs, or asks, offering the
+// first.
+func chooseOrg(in *bufio.Reader, out io.Writer, orgs []string) (string, error) {
+	if len(orgs) == 1 {
+		return orgs[0], nil
+	}
+	answer, err := prompt(in, out, fmt.Sprintf("Organization (%s) [%s]: ", strings.Join(orgs, ", "), orgs[0]))
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return orgs[0], nil
+	}
+	if !slices.Contains(orgs, answer) {
+		return "", fmt.Errorf("unknown organization %q", answer)
+	}
+	return answer, nil
+}
+
+// prompt writes question and returns the trimmed answer line.
+func prompt(in *bufio.Reader, out io.Writer, question string) (string, error) {
+	fmt.Fprint(out, question)
+	line, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/config"
+	"github.com/JPM1118/slua/internal/fleet"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/templates"
+	"github.com/spf13/cobra"
+)
+
+var promptCmd = &cobra.Command{
+	Use:   "prompt <sprite-name> [text]",
+	Short: "Give Claude Code on a Sprite a new task",
+	Long: `Send a prompt to the Claude Code session on a Sprite, as if typed into it.
+
+If Claude Code is not running it is started in its tmux session with the
+prompt. Without text, or with -, the prompt is read from stdin, so it can
+span several lines. Prompts are recorded in the state file and shown in the
+dashboard's detail pane.
+
+  slua prompt api "run the test suite and fix what fails"
+  slua prompt api < task.md`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		text, err := promptText(cmd, args[1:])
+		if err != nil {
+			return err
+		}
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(cmd.Context(), fleet.DefaultTimeout)
+		defer cancel()
+		name, err := stateKey(ctx, src, args[0])
+		if err != nil {
+			return err
+		}
+		t := spriteTemplate(cmd, name)
+		det := cfg.DetectorFor([]templates.Template{t}, func(string) string { return t.Name })(name)
+		process, target := poller.AgentOf(det)
+		res, err := src.Exec(ctx, name, t.PromptCommand(process, target, text))
+		if err != nil {
+			return err
+		}
+		if res.ExitCode != 0 {
+			if msg := strings.TrimSpace(string(res.Stderr)); msg != "" {
+				return errors.New(msg)
+			}
+			return fmt.Errorf("sending the prompt failed with exit code %d", res.ExitCode)
+		}
+
+		statePath, err := config.StatePath()
+		if err == nil {
+			err = state.NewStore(statePath).Update(func(st *state.State) {
+				st.Record(state.Event{At: time.Now(), Sprite: name, Kind: state.EventPrompt, Detail: text, User: state.CurrentUser()})
+			})
+		}
+		if err != nil {
+			fmt.Fprintf(cmd.ErrOrStderr(), "slua: could not save state: %s\n", err)
+		}
+
+		if templates.PromptStarted(res.Stdout) {
+			fmt.Fprintf(cmd.OutOrStdout(), "Started Claude Code on %s with the prompt\n", name)
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Sent the prompt to %s\n", name)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+}
+
+// promptText returns the prompt given as the only argument in args or, if
+// there is none or it is -, on stdin, without surrounding blank lines.
+func promptText(cmd *cobra.Command, args []string) (string, error) {
+	text := ""
+	if len(args) == 1 && args[0] != "-" {
+		text = args[0]
+	} else {
+		if f, ok := cmd.InOrStdin().(*os.File); ok && isTerminal(f) {
+			fmt.Fprintln(cmd.ErrOrStderr(), "Type the prompt, then press Ctrl-D.")
+		}
+		data, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return "", err
+		}
+		text = string(data)
+	}
+	text = strings.Trim(text, "\r\n")
+	if strings.TrimSpace(text) == "" {
+		return "", errors.New("the prompt is empty")
+	}
+	return text, nil
+}
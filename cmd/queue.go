@@ -0,0 +1,215 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JPM1118/slua/internal/config"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/templates"
+	"github.com/JPM1118/slua/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// queuePromptWidth is how much of each prompt queue list shows.
+const queuePromptWidth = 50
+
+var (
+	queueTemplate string
+	queueTags     []string
+	queuePriority int
+	queueAll      bool
+	queueJSON     bool
+)
+
+var queueCmd = &cobra.Command{
+	Use:   "queue",
+	Short: "Queue prompts for whichever Sprite is free",
+	Long: `Queue prompts for Claude Code to run on whichever Sprite is free.
+
+While the dashboard runs it gives each queued task, highest priority first,
+to a Sprite whose Claude Code has finished or that is asleep. The task runs
+as claude -p in the Sprite's tmux session and is done when that exits, so
+the tools it needs must be allowed in Claude Code's settings or the
+template's claude.command. A task can be restricted to
+Sprites created from a template or having tags. When no Sprite can take a
+task, one is created from the task's template (or queue.template) as long
+as the fleet is smaller than queue.max_sprites. A task that has not been
+seen running 30 minutes after it was assigned fails.`,
+}
+
+var queueAddCmd = &cobra.Command{
+	Use:   "add [text]",
+	Short: "Add a task to the queue",
+	Long: `Add a task to the queue. Without text, or with -, the prompt is read
+from stdin.
+
+  slua queue add "upgrade the Go toolchain" --tag backend
+  slua queue add --template web --priority 10 < task.md`,
+	Args:        cobra.MaximumNArgs(1),
+	Annotations: map[string]string{annotationLocal: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		text, err := promptText(cmd, args)
+		if err != nil {
+			return err
+		}
+		if queueTemplate != "" {
+			ts := spriteTemplates(cmd)
+			if !slices.ContainsFunc(ts, func(t templates.Template) bool { return t.Name == queueTemplate }) {
+				return fmt.Errorf("unknown template %q", queueTemplate)
+			}
+		}
+		store, err := queueStore()
+		if err != nil {
+			return err
+		}
+
+		var added state.Task
+		err = store.Update(func(st *state.State) {
+			added = st.AddTask(state.Task{
+				Prompt:   text,
+				Template: queueTemplate,
+				Tags:     state.ParseTags(strings.Join(queueTags, ",")),
+				Priority: queuePriority,
+				AddedAt:  time.Now(),
+				AddedBy:  state.CurrentUser(),
+			})
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Queued task #%d\n", added.ID)
+		return nil
+	},
+}
+
+var queueListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List queued and running tasks in dispatch order",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{annotationLocal: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		store, err := queueStore()
+		if err != nil {
+			return err
+		}
+		st, err := store.Load()
+		if err != nil {
+			return err
+		}
+
+		// Running tasks first, then the queue in the order it is taken.
+		var tasks []state.Task
+		for _, t := range st.Tasks {
+			if t.Active() {
+				tasks = append(tasks, t)
+			}
+		}
+		tasks = append(tasks, st.Queued()...)
+		if queueAll {
+			for _, t := range slices.Backward(st.Tasks) {
+				if t.Ended() {
+					tasks = append(tasks, t)
+				}
+			}
+		}
+
+		out := cmd.OutOrStdout()
+		if queueJSON {
+			enc := json.NewEncoder(out)
+			enc.SetIndent("", "  ")
+			return enc.Encode(tasks)
+		}
+		if len(tasks) == 0 {
+			fmt.Fprintln(out, "The queue is empty.")
+			return nil
+		}
+		return printTasks(out, tasks)
+	},
+}
+
+var queueCancelCmd = &cobra.Command{
+	Use:   "cancel <task-id>...",
+	Short: "Cancel tasks",
+	Long: `Cancel tasks. A queued task is taken out of the queue; a running one is
+no longer followed, but Claude Code is left to carry on.`,
+	Args:        cobra.MinimumNArgs(1),
+	Annotations: map[string]string{annotationLocal: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ids := make([]int, len(args))
+		for i, arg := range args {
+			id, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+			if err != nil {
+				return fmt.Errorf("invalid task ID %q", arg)
+			}
+			ids[i] = id
+		}
+		store, err := queueStore()
+		if err != nil {
+			return err
+		}
+
+		var canceled []int
+		var errs []error
+		err = store.Update(func(st *state.State) {
+			canceled, errs = nil, nil
+			for _, id := range ids {
+				if err := st.CancelTask(id, time.Now()); err != nil {
+					errs = append(errs, err)
+				} else {
+					canceled = append(canceled, id)
+				}
+			}
+		})
+		if err != nil {
+			return err
+		}
+		for _, id := range canceled {
+			fmt.Fprintf(cmd.OutOrStdout(), "Canceled task #%d\n", id)
+		}
+		return errors.Join(errs...)
+	},
+}
+
+func init() {
+	queueAddCmd.Flags().StringVar(&queueTemplate, "template", "", "Only run on Sprites created from this template")
+	queueAddCmd.Flags().StringSliceVarP(&queueTags, "tag", "t", nil, "Only run on Sprites with this tag (repeatable)")
+	queueAddCmd.Flags().IntVarP(&queuePriority, "priority", "p", 0, "Higher runs first")
+	queueListCmd.Flags().BoolVar(&queueAll, "all", false, "Include done, failed and canceled tasks")
+	queueListCmd.Flags().BoolVar(&queueJSON, "json", false, "Output as JSON")
+	queueCmd.AddCommand(queueAddCmd, queueListCmd, queueCancelCmd)
+	rootCmd.AddCommand(queueCmd)
+}
+
+func queueStore() (*state.Store, error) {
+	statePath, err := config.StatePath()
+	if err != nil {
+		return nil, err
+	}
+	return state.NewStore(statePath), nil
+}
+
+// printTasks writes tasks as a table, each prompt cut to its first line.
+func printTasks(out io.Writer, tasks []state.Task) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPRI\tSTATUS\tSPRITE\tADDED\tPROMPT")
+	fmt.Fprintln(w, "──\t───\t──────\t──────\t─────\t──────")
+	for _, t := range tasks {
+		text, _, _ := strings.Cut(strings.TrimSpace(t.Prompt), "\n")
+		if r := []rune(text); len(r) > queuePromptWidth {
+			text = string(r[:queuePromptWidth-1]) + "…"
+		}
+		if t.Detail != "" {
+			text += " — " + t.Detail
+		}
+		fmt.Fprintf(w, "#%d\t%d\t%s\t%s\t%s\t%s\n", t.ID, t.Priority, t.Status, tui.Dash(t.Sprite), formatCheckpointTime(t.AddedAt), text)
+	}
+	return w.Flush()
+}
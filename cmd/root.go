@@ -1,14 +1,41 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/JPM1118/slua/internal/config"
 	"github.com/JPM1118/slua/internal/sprites"
 	"github.com/spf13/cobra"
 )
 
-var org string
+var (
+	orgs         []string
+	backend      string
+	configPath   string
+	pollInterval time.Duration
+
+	// cfg is the config file merged with flag overrides. It is loaded
+	// before any command runs.
+	cfg config.Config
+	// fileOrgs are the organizations the config file lists, before --org.
+	fileOrgs []string
+)
+
+// Supported values for --backend.
+const (
+	backendCLI = config.BackendCLI
+	backendAPI = config.BackendAPI
+)
+
+// annotationLocal marks commands that only read local files and do not
+// need the sprite CLI.
+const annotationLocal = "local"
 
 var rootCmd = &cobra.Command{
 	Use:   "slua",
@@ -20,7 +47,13 @@ Run without arguments to launch the dashboard.`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		return sprites.CheckSpriteCLI()
+		if err := loadConfig(cmd); err != nil {
+			return err
+		}
+		if cfg.Backend == backendCLI && cmd.Annotations[annotationLocal] == "" {
+			return sprites.CheckSpriteCLI()
+		}
+		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runDashboard()
@@ -28,7 +61,111 @@ Run without arguments to launch the dashboard.`,
 }
 
 func init() {
-	rootCmd.PersistentFlags().StringVarP(&org, "org", "o", "", "Fly.io organization to use")
+	rootCmd.PersistentFlags().StringSliceVarP(&orgs, "org", "o", nil, "Fly.io organization to use; repeat to show several")
+	rootCmd.PersistentFlags().StringVar(&backend, "backend", backendCLI, "Sprite backend: cli (sprite binary) or api (REST)")
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "", "Config file (default $XDG_CONFIG_HOME/slua/config.yml)")
+	rootCmd.PersistentFlags().DurationVar(&pollInterval, "poll-interval", 0, "Time between state detection polls (default from config, 15s)")
+}
+
+// loadConfig reads the config file into cfg and applies any flags the user
+// set explicitly on top of it.
+func loadConfig(cmd *cobra.Command) error {
+	path := configPath
+	if path == "" {
+		p, err := config.Path()
+		if err != nil {
+			return err
+		}
+		path = p
+	}
+
+	c, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+
+	fileOrgs = c.OrgList()
+	flags := cmd.Flags()
+	if flags.Changed("org") {
+		list, err := orgFlag(orgs)
+		if err != nil {
+			return err
+		}
+		c.Org, c.Orgs = list[0], nil
+		if len(list) > 1 {
+			c.Orgs = list
+		}
+	}
+	if flags.Changed("backend") {
+		c.Backend = backend
+	}
+	if flags.Changed("poll-interval") {
+		c.Detection.PollInterval = pollInterval
+	}
+	if err := c.Validate(); err != nil {
+		return fmt.Errorf("invalid flags: %w", err)
+	}
+
+	cfg = c
+	return nil
+}
+
+// orgFlag returns the organizations given with --org, in order and
+// without blanks or repeats.
+func orgFlag(values []string) ([]string, error) {
+	var list []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" && !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	if len(list) == 0 {
+		return nil, errors.New("--org needs a value")
+	}
+	return list, nil
+}
+
+// newSource returns the SpriteSource selected by --backend or the config.
+// Several organizations are combined into a sprites.Multi. So is one
+// picked with --org from several in the config file, so that Sprites keep
+// the org/name the state file knows them by.
+func newSource() (sprites.SpriteSource, error) {
+	list := cfg.OrgList()
+	if len(list) == 1 && len(fileOrgs) < 2 {
+		return newOrgSource(list[0])
+	}
+	sources := make(map[string]sprites.SpriteSource, len(list))
+	for _, o := range list {
+		src, err := newOrgSource(o)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", o, err)
+		}
+		sources[o] = src
+	}
+	return sprites.NewMulti(list, sources), nil
+}
+
+// stateKey returns the name the state file and dashboard know the Sprite
+// name by: org/name when src spans organizations, otherwise name itself.
+func stateKey(ctx context.Context, src sprites.SpriteSource, name string) (string, error) {
+	if m, ok := src.(*sprites.Multi); ok {
+		return m.Qualify(ctx, name)
+	}
+	return name, nil
+}
+
+// newOrgSource returns the SpriteSource for one organization.
+func newOrgSource(org string) (sprites.SpriteSource, error) {
+	if cfg.Backend == backendAPI {
+		token, err := sprites.LoadToken(org)
+		if err != nil {
+			return nil, err
+		}
+		api := sprites.NewAPI(token, org)
+		api.ListTimeout = cfg.ListTimeout
+		return api, nil
+	}
+	return &sprites.CLI{Org: org, ListTimeout: cfg.ListTimeout}, nil
 }
 
 func Execute() error {
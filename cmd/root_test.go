@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestLoadConfig_OrgFlag(t *testing.T) {
+	configPath = filepath.Join(t.TempDir(), "missing.yml")
+	defer func() { configPath, orgs = "", nil }()
+
+	tests := []struct {
+		args []string
+		want []string // OrgList, nil for an error
+	}{
+		{[]string{"--org="}, nil},
+		{[]string{"--org", " , "}, nil},
+		{[]string{"--org=work"}, []string{"work"}},
+		{[]string{"-o", "work", "-o", "personal,work"}, []string{"work", "personal"}},
+	}
+	for _, tt := range tests {
+		orgs = nil
+		cmd := &cobra.Command{Use: "status"}
+		cmd.Flags().StringSliceVarP(&orgs, "org", "o", nil, "")
+		if err := cmd.ParseFlags(tt.args); err != nil {
+			t.Fatalf("%q: %v", tt.args, err)
+		}
+		err := loadConfig(cmd)
+		switch {
+		case tt.want == nil && err == nil:
+			t.Errorf("%q: want an error, got orgs %v", tt.args, cfg.OrgList())
+		case tt.want != nil && err != nil:
+			t.Errorf("%q: %v", tt.args, err)
+		case tt.want != nil && !slices.Equal(cfg.OrgList(), tt.want):
+			t.Errorf("%q: orgs = %v, want %v", tt.args, cfg.OrgList(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/JPM1118/slua/internal/config"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	sessionsJSON bool
+	killYes      bool
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List and kill a Sprite's exec sessions",
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list <sprite-name>",
+	Short: "List a Sprite's exec sessions, oldest first",
+	Long: `List a Sprite's exec sessions, oldest first. The session slua connect
+attaches to is marked with *.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+
+		name, err := stateKey(cmd.Context(), src, args[0])
+		if err != nil {
+			return err
+		}
+		sessions, err := src.ListSessions(cmd.Context(), name)
+		if err != nil {
+			return err
+		}
+
+		if sessionsJSON {
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(sessions)
+		}
+
+		if len(sessions) == 0 {
+			fmt.Printf("No sessions running on %s.\n", args[0])
+			return nil
+		}
+
+		var preferred string
+		if statePath, err := config.StatePath(); err == nil {
+			if st, err := state.NewStore(statePath).Load(); err == nil {
+				preferred = st.Local[name].Session
+			}
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCOMMAND\tTTY\tSTARTED")
+		fmt.Fprintln(w, "──\t───────\t───\t───────")
+		for _, s := range sessions {
+			id := s.ID
+			if id == preferred {
+				id += " *"
+			}
+			tty := "no"
+			if s.TTY {
+				tty = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", id, tui.Dash(s.Command), tty, formatCheckpointTime(s.CreatedAt))
+		}
+		return w.Flush()
+	},
+}
+
+var sessionsKillCmd = &cobra.Command{
+	Use:   "kill <sprite-name> <session-id>...",
+	Short: "End exec sessions on a Sprite",
+	Args:  cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, ids := args[0], args[1:]
+		if !killYes {
+			ok, err := promptYesNo(cmd.InOrStdin(), cmd.OutOrStdout(),
+				fmt.Sprintf("Kill %d session(s) on %s?", len(ids), name))
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Fprintln(cmd.OutOrStdout(), "Cancelled.")
+				return nil
+			}
+		}
+
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+		name, err = stateKey(cmd.Context(), src, name)
+		if err != nil {
+			return err
+		}
+		statePath, err := config.StatePath()
+		if err != nil {
+			return err
+		}
+		store := state.NewStore(statePath)
+		for _, id := range ids {
+			if err := src.KillSession(cmd.Context(), name, id); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Killed session %s on %s\n", id, name)
+			err := store.Update(func(st *state.State) {
+				st.Record(state.Event{At: time.Now(), Sprite: name, Kind: state.EventKill, User: state.CurrentUser(), Session: id})
+			})
+			if err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "slua: could not save state: %s\n", err)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	sessionsListCmd.Flags().BoolVar(&sessionsJSON, "json", false, "Output as JSON")
+	sessionsKillCmd.Flags().BoolVarP(&killYes, "yes", "y", false, "Kill without prompting")
+
+	sessionsCmd.AddCommand(sessionsListCmd, sessionsKillCmd)
+	rootCmd.AddCommand(sessionsCmd)
+}
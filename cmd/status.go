@@ -1,43 +1,85 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 	"text/tabwriter"
 
+	"github.com/JPM1118/slua/internal/config"
+	"github.com/JPM1118/slua/internal/poller"
 	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/state"
 	"github.com/spf13/cobra"
 )
 
-var jsonOutput bool
+var (
+	jsonOutput bool
+	detect     bool
+	statusTags []string
+)
+
+// statusRow is a Sprite as printed by status --json.
+type statusRow struct {
+	sprites.Sprite
+	Tags []string `json:"tags,omitempty"`
+}
 
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Print Sprite status (non-interactive)",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		cli := &sprites.CLI{Org: org}
-		spriteList, err := cli.List(cmd.Context())
+		src, err := newSource()
 		if err != nil {
 			return err
 		}
+		spriteList, err := src.List(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		tags, err := loadTags()
+		if err != nil && len(statusTags) > 0 {
+			return err
+		}
+		if len(statusTags) > 0 {
+			want := state.ParseTags(strings.Join(statusTags, ","))
+			spriteList = slices.DeleteFunc(spriteList, func(s sprites.Sprite) bool {
+				return !state.HasTags(tags[s.Name], want)
+			})
+		}
+
+		if detect {
+			applyDetection(cmd.Context(), src, spriteList)
+		}
 
 		if jsonOutput {
 			enc := json.NewEncoder(os.Stdout)
 			enc.SetIndent("", "  ")
-			return enc.Encode(spriteList)
+			rows := make([]statusRow, len(spriteList))
+			for i, s := range spriteList {
+				rows[i] = statusRow{Sprite: s, Tags: tags[s.Name]}
+			}
+			return enc.Encode(rows)
 		}
 
 		if len(spriteList) == 0 {
+			if len(statusTags) > 0 {
+				fmt.Printf("No Sprites tagged %s.\n", strings.Join(statusTags, ", "))
+				return nil
+			}
 			fmt.Println("No Sprites running.")
 			return nil
 		}
 
 		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
-		fmt.Fprintln(w, "NAME\tSTATUS\tUPTIME\tREGION")
-		fmt.Fprintln(w, "────\t──────\t──────\t──────")
+		fmt.Fprintln(w, "NAME\tSTATUS\tUPTIME\tREGION\tTAGS")
+		fmt.Fprintln(w, "────\t──────\t──────\t──────\t────")
 		for _, s := range spriteList {
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.Status, s.FormatUptime(), s.Region)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, s.Status, s.FormatUptime(), s.Region, strings.Join(tags[s.Name], ","))
 		}
 		return w.Flush()
 	},
@@ -45,5 +87,44 @@ var statusCmd = &cobra.Command{
 
 func init() {
 	statusCmd.Flags().BoolVar(&jsonOutput, "json", false, "Output as JSON")
+	statusCmd.Flags().BoolVar(&detect, "detect", false, "Run Claude Code state detection on awake Sprites")
+	statusCmd.Flags().StringSliceVarP(&statusTags, "tag", "t", nil, "Only show Sprites with this tag (repeatable)")
 	rootCmd.AddCommand(statusCmd)
 }
+
+// loadTags returns the tags saved in the state file for each Sprite.
+func loadTags() (map[string][]string, error) {
+	statePath, err := config.StatePath()
+	if err != nil {
+		return nil, err
+	}
+	st, err := state.NewStore(statePath).Load()
+	if err != nil {
+		return nil, err
+	}
+	return st.Tags(), nil
+}
+
+// applyDetection replaces the API status of each awake Sprite with the
+// detected Claude Code state.
+func applyDetection(ctx context.Context, src poller.Source, spriteList []sprites.Sprite) {
+	var names []string
+	for _, s := range spriteList {
+		if poller.Pollable(s) {
+			names = append(names, s.Name)
+		}
+	}
+	pl := poller.New(src)
+	cfg.Configure(pl)
+	if statePath, err := config.StatePath(); err == nil {
+		// Templates that fail to load just fall back to the default detector.
+		tmpls, _ := cfg.SpriteTemplates()
+		detectByTemplate(pl, tmpls, state.NewStore(statePath))
+	}
+	results := pl.PollAll(ctx, names)
+	for i, s := range spriteList {
+		if r, ok := results[s.Name]; ok {
+			spriteList[i].Status = r.Status
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/JPM1118/slua/internal/config"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/templates"
+	"github.com/JPM1118/slua/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+var templateDryRun bool
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "List, check and apply environment templates",
+	Long: `Templates describe a Sprite environment: repos to clone, packages to
+install, env vars, files, extra setup steps and the Claude Code session to
+start. They are read from "templates:" in the config file, from one YAML
+file per template in the templates directory next to it, and from
+.slua.yml in the current directory, later sources replacing earlier ones.
+
+Example .slua.yml:
+
+  extends: node
+  repos:
+    - url: git@github.com:acme/webapp.git
+  packages:
+    apt: [postgresql-client]
+  env:
+    NODE_ENV: development
+  files:
+    - path: .npmrc
+      source: ./sprite.npmrc
+      mode: "0600"
+  claude:
+    prompt: Run the test suite and fix any failures`,
+}
+
+var templateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ts := spriteTemplates(cmd)
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tEXTENDS\tDESCRIPTION")
+		fmt.Fprintln(w, "────\t───────\t───────────")
+		for _, t := range ts {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", t.Name, tui.Dash(t.Extends), t.Description)
+		}
+		return w.Flush()
+	},
+}
+
+var templateValidateCmd = &cobra.Command{
+	Use:   "validate [file...]",
+	Short: "Check template files, or every configured template",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ts, err := cfg.SpriteTemplates()
+		if len(args) == 0 {
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%d templates OK\n", len(ts))
+			return nil
+		}
+
+		// Files may extend any configured template that is itself valid.
+		set := templates.Set{}
+		for _, t := range ts {
+			set[t.Name] = t
+		}
+		var errs []error
+		for _, path := range args {
+			t, err := templates.LoadFile(path)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			set[t.Name] = t
+			if t, err = set.Resolve(t.Name); err == nil {
+				_, err = t.Plan()
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", path, err))
+				continue
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: OK (%s)\n", path, t.Name)
+		}
+		return errors.Join(errs...)
+	},
+}
+
+var templateApplyCmd = &cobra.Command{
+	Use:   "apply <template> <sprite-name>...",
+	Short: "Apply a template to existing Sprites",
+	Long: `Apply a template to existing Sprites, one after another. Steps whose work
+is already done are skipped, so applying a template again only does what
+changed.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		out := cmd.OutOrStdout()
+		ts := spriteTemplates(cmd)
+		var t templates.Template
+		found := false
+		for _, c := range ts {
+			if c.Name == args[0] {
+				t, found = c, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown template %q", args[0])
+		}
+
+		if templateDryRun {
+			steps, err := t.Plan()
+			if err != nil {
+				return err
+			}
+			for i, s := range steps {
+				fmt.Fprintf(out, "step %d/%d: %s\n", i+1, len(steps), s.Label())
+				if s.Unless != "" {
+					fmt.Fprintf(out, "  unless: %s\n", s.Unless)
+				}
+				fmt.Fprintf(out, "  run: %s\n", s.Run)
+			}
+			return nil
+		}
+
+		src, err := newSource()
+		if err != nil {
+			return err
+		}
+		var errs []error
+		for _, name := range args[1:] {
+			fmt.Fprintf(out, "Applying %s to %s\n", t.Name, name)
+			err := templates.Apply(cmd.Context(), src, name, t, func(p templates.Progress) {
+				fmt.Fprintf(out, "  %s\n", p)
+			})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("apply to %s: %w", name, err))
+			}
+		}
+		return errors.Join(errs...)
+	},
+}
+
+func init() {
+	templateApplyCmd.Flags().BoolVar(&templateDryRun, "dry-run", false, "Print the commands without running them")
+
+	templateCmd.AddCommand(templateListCmd, templateValidateCmd, templateApplyCmd)
+	rootCmd.AddCommand(templateCmd)
+}
+
+// spriteTemplates returns the templates that load and prints what is wrong
+// with the others as warnings. Only 'slua template validate' fails on them.
+func spriteTemplates(cmd *cobra.Command) []templates.Template {
+	ts, err := cfg.SpriteTemplates()
+	for _, w := range templateWarnings(err) {
+		fmt.Fprintf(cmd.ErrOrStderr(), "slua: warning: %s\n", w)
+	}
+	return ts
+}
+
+// spriteTemplate returns the template the state file says name was created
+// from, or the blank template if there is none or it no longer loads.
+func spriteTemplate(cmd *cobra.Command, name string) templates.Template {
+	blank := templates.Template{Name: templates.Blank}
+	statePath, err := config.StatePath()
+	if err != nil {
+		return blank
+	}
+	st, err := state.NewStore(statePath).Load()
+	if err != nil || st.Local[name].Template == "" {
+		return blank
+	}
+	for _, t := range spriteTemplates(cmd) {
+		if t.Name == st.Local[name].Template {
+			return t
+		}
+	}
+	return blank
+}
+
+// templateWarnings splits the error from SpriteTemplates into one warning
+// per broken template.
+func templateWarnings(err error) []string {
+	if err == nil {
+		return nil
+	}
+	return strings.Split(err.Error(), "\n")
+}
@@ -0,0 +1,522 @@
+// Package config loads slua's YAML configuration file.
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/JPM1118/slua/internal/cost"
+	"github.com/JPM1118/slua/internal/hooks"
+	"github.com/JPM1118/slua/internal/notify"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/queue"
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/templates"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported values for Config.Backend.
+const (
+	BackendCLI = "cli"
+	BackendAPI = "api"
+)
+
+// Config is the contents of config.yml merged over Default.
+type Config struct {
+	// Org is the default Fly.io organization. Empty uses the sprite CLI's.
+	Org string `yaml:"org"`
+	// Orgs lists several organizations to show together, grouped. Org, if
+	// set, must be one of them and is where new Sprites are created.
+	Orgs []string `yaml:"orgs"`
+	// Backend selects how slua talks to Sprites: BackendCLI or BackendAPI.
+	Backend string `yaml:"backend"`
+	// ListTimeout bounds each Sprite and checkpoint list call.
+	ListTimeout time.Duration `yaml:"list_timeout"`
+
+	Detection     Detection     `yaml:"detection"`
+	Checkpoints   Checkpoints   `yaml:"checkpoints"`
+	Notifications Notifications `yaml:"notifications"`
+	Display       Display       `yaml:"display"`
+	Cost          Cost          `yaml:"cost"`
+	Queue         Queue         `yaml:"queue"`
+
+	// Templates are the bootstrap recipes offered by `slua new`, by name.
+	Templates map[string]templates.Template `yaml:"templates"`
+}
+
+// Detection configures the Claude Code state poller.
+type Detection struct {
+	PollInterval     time.Duration `yaml:"poll_interval"`
+	ExecTimeout      time.Duration `yaml:"exec_timeout"`
+	Workers          int           `yaml:"workers"`
+	FailureThreshold int           `yaml:"failure_threshold"`
+	// PromptPatterns are POSIX extended regexes, as for grep -E, that mark
+	// a Sprite as WAITING when they match the end of its tmux pane.
+	PromptPatterns []string `yaml:"prompt_patterns"`
+	// Detector names the detector for Sprites given none below or by their
+	// template: a built-in (hooks, tmux, process or marker) or one of
+	// Detectors. The default, hooks, reads the log kept by the hooks
+	// `slua hooks install` sets up and otherwise works like tmux.
+	Detector string `yaml:"detector"`
+	// Detectors defines detectors by name, for agents other than Claude
+	// Code, other multiplexers or state written by hooks.
+	Detectors map[string]Detector `yaml:"detectors"`
+	// Sprites names the detector for individual Sprites.
+	Sprites map[string]string `yaml:"sprites"`
+}
+
+// Detector configures a named state detector.
+type Detector struct {
+	// Type is hooks, tmux, process, marker or script.
+	Type string `yaml:"type"`
+	// Process is the agent's process name. Empty means claude for tmux and
+	// process, and no process check for marker.
+	Process string `yaml:"process"`
+	// Target is the pane a tmux detector, or the tmux fallback of a hooks
+	// detector, reads, and where answers and prompts are typed. Empty means
+	// the claude session templates start, if there is one, else the
+	// current pane.
+	Target string `yaml:"target"`
+	// PromptPatterns replace detection.prompt_patterns for tmux and hooks.
+	PromptPatterns []string `yaml:"prompt_patterns"`
+	// File is the marker file, relative to the home directory. Empty means
+	// .slua/status.
+	File string `yaml:"file"`
+	// Script is the shell script a script detector runs. It prints the
+	// state as JSON: {"status": ..., "detail": ..., "exit_code": ...}.
+	Script string `yaml:"script"`
+}
+
+// Checkpoints configures automatic checkpoints when Claude Code finishes.
+type Checkpoints struct {
+	AutoOnCompletion bool          `yaml:"auto_on_completion"`
+	Keep             int           `yaml:"keep"`
+	DedupWindow      time.Duration `yaml:"dedup_window"`
+	// Sprites overrides the settings above for individual Sprites.
+	Sprites map[string]SpriteCheckpoints `yaml:"sprites"`
+}
+
+// SpriteCheckpoints overrides Checkpoints for one Sprite. Unset fields
+// inherit the global value.
+type SpriteCheckpoints struct {
+	AutoOnCompletion *bool          `yaml:"auto_on_completion"`
+	Keep             *int           `yaml:"keep"`
+	DedupWindow      *time.Duration `yaml:"dedup_window"`
+}
+
+// Notifications configures alerts for status changes.
+type Notifications struct {
+	// OnStates lists the statuses that notify when a Sprite enters them.
+	OnStates     []string      `yaml:"on_states"`
+	DedupWindow  time.Duration `yaml:"dedup_window"`
+	MaxPerMinute int           `yaml:"max_per_minute"`
+
+	TerminalBell bool `yaml:"terminal_bell"`
+	Desktop      bool `yaml:"desktop"`
+	// Command is a shell hook run for every notification.
+	Command string `yaml:"command"`
+
+	// Ntfy and Webhooks push to remote services. Undelivered alerts are
+	// kept in an outbox under StateDir and retried at the next start.
+	Ntfy     Ntfy      `yaml:"ntfy"`
+	Webhooks []Webhook `yaml:"webhooks"`
+	Retry    Retry     `yaml:"retry"`
+}
+
+// Ntfy configures push notifications to an ntfy topic. Empty URL disables it.
+type Ntfy struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token"`
+}
+
+// Webhook configures one generic webhook. Without a template the payload
+// is Slack-compatible.
+type Webhook struct {
+	URL      string            `yaml:"url"`
+	Template string            `yaml:"template"`
+	Headers  map[string]string `yaml:"headers"`
+}
+
+// Retry configures delivery retries for push sinks.
+type Retry struct {
+	Attempts int           `yaml:"attempts"`
+	Backoff  time.Duration `yaml:"backoff"`
+}
+
+// Cost prices Sprite usage for `slua cost` and the dashboard's cost column.
+// With every rate zero, costs are not shown.
+type Cost struct {
+	RunningHour    float64 `yaml:"running_hour"`
+	StorageGBMonth float64 `yaml:"storage_gb_month"`
+	// StorageGB is the disk assumed per Sprite.
+	StorageGB float64 `yaml:"storage_gb"`
+	Currency  string  `yaml:"currency"`
+}
+
+// Queue configures how the dashboard dispatches tasks from `slua queue`.
+type Queue struct {
+	// MaxSprites is the fleet size up to which Sprites are created for
+	// queued tasks no idle Sprite can take. Zero never creates Sprites.
+	MaxSprites int `yaml:"max_sprites"`
+	// Template bootstraps Sprites created for tasks that name none.
+	Template string `yaml:"template"`
+}
+
+// Display configures the dashboard's appearance. Zero values keep the
+// dashboard defaults.
+type Display struct {
+	Columns Columns `yaml:"columns"`
+	Colors  Colors  `yaml:"colors"`
+}
+
+// Columns holds Sprite list column widths in cells.
+type Columns struct {
+	Name   int `yaml:"name"`
+	Status int `yaml:"status"`
+	Uptime int `yaml:"uptime"`
+}
+
+// Colors holds dashboard colors as ANSI indexes ("3") or hex ("#ffaa00").
+type Colors struct {
+	Working     string `yaml:"working"`
+	Finished    string `yaml:"finished"`
+	Waiting     string `yaml:"waiting"`
+	Error       string `yaml:"error"`
+	Sleeping    string `yaml:"sleeping"`
+	Unreachable string `yaml:"unreachable"`
+	Header      string `yaml:"header"`
+	Muted       string `yaml:"muted"`
+	Cursor      string `yaml:"cursor"`
+}
+
+// Default returns the configuration used when no file exists.
+func Default() Config {
+	return Config{
+		Backend:     BackendCLI,
+		ListTimeout: sprites.ListTimeout,
+		Detection: Detection{
+			PollInterval:     poller.DefaultInterval,
+			ExecTimeout:      poller.DefaultExecTimeout,
+			Workers:          poller.DefaultWorkers,
+			FailureThreshold: poller.DefaultFailureThreshold,
+			PromptPatterns:   append([]string(nil), poller.DefaultPromptPatterns...),
+			Detector:         hooks.DetectorHooks,
+		},
+		Checkpoints: Checkpoints{
+			AutoOnCompletion: true,
+			Keep:             sprites.DefaultAutoCheckpointKeep,
+			DedupWindow:      sprites.DefaultAutoCheckpointDedup,
+		},
+		Cost: Cost{Currency: "$"},
+		Notifications: Notifications{
+			OnStates:     append([]string(nil), notify.DefaultStates...),
+			DedupWindow:  notify.DefaultDedupWindow,
+			MaxPerMinute: notify.DefaultMaxPerMinute,
+			TerminalBell: true,
+			Retry: Retry{
+				Attempts: notify.DefaultAttempts,
+				Backoff:  notify.DefaultBackoff,
+			},
+		},
+	}
+}
+
+// Dir returns slua's config directory: $XDG_CONFIG_HOME/slua, or
+// ~/.config/slua when XDG_CONFIG_HOME is unset.
+func Dir() (string, error) {
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		return filepath.Join(xdg, "slua"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locating config directory: %w", err)
+	}
+	return filepath.Join(home, ".config", "slua"), nil
+}
+
+// Path returns the default config file location.
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "config.yml"), nil
+}
+
+// StatePath returns where the dashboard remembers Sprites between runs.
+func StatePath() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "state.json"), nil
+}
+
+// StateDir returns where slua keeps runtime state: $XDG_STATE_HOME/slua,
+// or ~/.local/state/slua when XDG_STATE_HOME is unset.
+func StateDir() (string, error) {
+	if xdg := os.Getenv("XDG_STATE_HOME"); xdg != "" {
+		return filepath.Join(xdg, "slua"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locating state directory: %w", err)
+	}
+	return filepath.Join(home, ".local", "state", "slua"), nil
+}
+
+// Load reads the config file at path over Default. A missing file is not
+// an error.
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("reading config: %w", err)
+	}
+	return Parse(path, data)
+}
+
+// Parse decodes data over Default and validates the result. name is used
+// as the file name in errors, which are of type Errors.
+func Parse(name string, data []byte) (Config, error) {
+	cfg := Default()
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && err != io.EOF {
+		return Config{}, yamlErrors(name, err)
+	}
+
+	if probs := cfg.problems(); len(probs) > 0 {
+		var root yaml.Node
+		_ = yaml.Unmarshal(data, &root)
+		errs := make(Errors, len(probs))
+		for i, p := range probs {
+			errs[i] = &Error{File: name, Line: lineOf(&root, p.path), Field: p.field(), Msg: p.msg}
+		}
+		return Config{}, errs
+	}
+	return cfg, nil
+}
+
+// SpriteTemplates gathers templates from the config file, one-per-file
+// templates in Dir()/templates and templates.ProjectFile in the current
+// directory, later sources replacing earlier ones of the same name. They
+// are returned resolved, sorted by name with the built-in blank template
+// first. Templates that fail to load or resolve are left out and reported
+// in the error, so one broken file does not hide the rest.
+func (c Config) SpriteTemplates() ([]templates.Template, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	set := templates.Set{}
+	set.Add(dir, c.Templates)
+
+	var errs []error
+	fromDir, err := templates.LoadDir(filepath.Join(dir, "templates"))
+	errs = append(errs, err)
+	set.Add("", fromDir)
+
+	project, err := templates.LoadFile(templates.ProjectFile)
+	switch {
+	case err == nil:
+		set[project.Name] = project
+	case !errors.Is(err, os.ErrNotExist):
+		errs = append(errs, err)
+	}
+
+	list, err := set.List()
+	return list, errors.Join(append(errs, err)...)
+}
+
+// OrgList returns the organizations to list, the default first. It holds
+// just Org when Orgs is empty.
+func (c Config) OrgList() []string {
+	if len(c.Orgs) == 0 {
+		return []string{c.Org}
+	}
+	list := []string{}
+	if c.Org != "" {
+		list = append(list, c.Org)
+	}
+	for _, o := range c.Orgs {
+		if o != c.Org {
+			list = append(list, o)
+		}
+	}
+	return list
+}
+
+// AutoCheckpointPolicies converts the checkpoint settings for the dashboard.
+func (c Config) AutoCheckpointPolicies() sprites.AutoCheckpointPolicies {
+	def := sprites.AutoCheckpointPolicy{
+		Enabled:     c.Checkpoints.AutoOnCompletion,
+		Keep:        c.Checkpoints.Keep,
+		DedupWindow: c.Checkpoints.DedupWindow,
+	}
+	policies := sprites.AutoCheckpointPolicies{Default: def}
+	if len(c.Checkpoints.Sprites) == 0 {
+		return policies
+	}
+
+	policies.PerSprite = make(map[string]sprites.AutoCheckpointPolicy, len(c.Checkpoints.Sprites))
+	for name, o := range c.Checkpoints.Sprites {
+		p := def
+		if o.AutoOnCompletion != nil {
+			p.Enabled = *o.AutoOnCompletion
+		}
+		if o.Keep != nil {
+			p.Keep = *o.Keep
+		}
+		if o.DedupWindow != nil {
+			p.DedupWindow = *o.DedupWindow
+		}
+		policies.PerSprite[name] = p
+	}
+	return policies
+}
+
+// CostRates converts the cost settings.
+func (c Config) CostRates() cost.Rates {
+	return cost.Rates{
+		RunningHour:    c.Cost.RunningHour,
+		StorageGBMonth: c.Cost.StorageGBMonth,
+		StorageGB:      c.Cost.StorageGB,
+		Currency:       c.Cost.Currency,
+	}
+}
+
+// QueueOptions returns the task dispatcher settings.
+func (c Config) QueueOptions() queue.Options {
+	return queue.Options{MaxSprites: c.Queue.MaxSprites, Template: c.Queue.Template}
+}
+
+// Configure applies the detection settings to p.
+func (c Config) Configure(p *poller.Poller) {
+	p.Interval = c.Detection.PollInterval
+	p.ExecTimeout = c.Detection.ExecTimeout
+	p.Workers = c.Detection.Workers
+	p.FailureThreshold = c.Detection.FailureThreshold
+	p.DetectorFor = c.DetectorFor(nil, nil)
+}
+
+// DetectorFor returns how the poller picks each Sprite's detector: by
+// detection.sprites, then by the detector of the template in tmpls the
+// Sprite was created from, then detection.detector. templateOf names a
+// Sprite's template and is only called when some template has a detector.
+func (c Config) DetectorFor(tmpls []templates.Template, templateOf func(sprite string) string) func(sprite string) poller.Detector {
+	dets := c.detectors()
+	perSprite := make(map[string]poller.Detector, len(c.Detection.Sprites))
+	for name, det := range c.Detection.Sprites {
+		perSprite[name] = dets[det]
+	}
+	byTemplate := make(map[string]poller.Detector)
+	for _, t := range tmpls {
+		if d, ok := dets[t.Detector]; ok {
+			byTemplate[t.Name] = d
+		}
+	}
+	def := dets[c.Detection.Detector]
+
+	return func(sprite string) poller.Detector {
+		if d, ok := perSprite[sprite]; ok {
+			return d
+		}
+		if templateOf != nil && len(byTemplate) > 0 {
+			if d, ok := byTemplate[templateOf(sprite)]; ok {
+				return d
+			}
+		}
+		return def
+	}
+}
+
+// detectors returns the built-in detectors and those defined in the
+// config, by name. Each call makes new hooks detectors, which follow their
+// own place in every Sprite's log.
+func (c Config) detectors() map[string]poller.Detector {
+	dets := map[string]poller.Detector{
+		hooks.DetectorHooks:    hooks.NewDetector(poller.Tmux{Patterns: c.Detection.PromptPatterns}),
+		poller.DetectorTmux:    poller.Tmux{Patterns: c.Detection.PromptPatterns},
+		poller.DetectorProcess: poller.Process{},
+		poller.DetectorMarker:  poller.Marker{},
+	}
+	for name, d := range c.Detection.Detectors {
+		dets[name] = d.build(c.Detection.PromptPatterns)
+	}
+	return dets
+}
+
+// build makes the poller detector d describes, with patterns as the
+// prompt patterns unless d has its own.
+func (d Detector) build(patterns []string) poller.Detector {
+	switch d.Type {
+	case poller.DetectorProcess:
+		return poller.Process{Name: d.Process}
+	case poller.DetectorMarker:
+		return poller.Marker{Path: d.File, Process: d.Process}
+	case poller.DetectorScript:
+		return poller.Script{Script: d.Script}
+	}
+	if len(d.PromptPatterns) > 0 {
+		patterns = d.PromptPatterns
+	}
+	tmux := poller.Tmux{Process: d.Process, Target: d.Target, Patterns: patterns}
+	if d.Type == hooks.DetectorHooks {
+		return hooks.NewDetector(tmux)
+	}
+	return tmux
+}
+
+// NewNotifier builds a Notifier with the configured sinks. Push sinks get
+// an outbox under stateDir.
+func (c Config) NewNotifier(stateDir string) *notify.Notifier {
+	nc := c.Notifications
+	var sinks []notify.Sink
+	if nc.TerminalBell {
+		sinks = append(sinks, notify.Bell{})
+	}
+	if nc.Desktop {
+		sinks = append(sinks, notify.Desktop{})
+	}
+	if nc.Command != "" {
+		sinks = append(sinks, notify.Command{Line: nc.Command})
+	}
+
+	durable := func(name string, sink notify.Sink) notify.Sink {
+		o := notify.NewOutbox(filepath.Join(stateDir, "outbox", name), sink)
+		o.Attempts = nc.Retry.Attempts
+		o.Backoff = nc.Retry.Backoff
+		return o
+	}
+	if nc.Ntfy.URL != "" {
+		sinks = append(sinks, durable("ntfy", notify.Ntfy{URL: nc.Ntfy.URL, Token: nc.Ntfy.Token}))
+	}
+	for _, w := range nc.Webhooks {
+		sink := notify.Webhook{URL: w.URL, Template: w.Template, Headers: w.Headers}
+		sinks = append(sinks, durable("webhook-"+shortHash(w.URL), sink))
+	}
+
+	n := notify.New(sinks...)
+	n.States = nc.OnStates
+	n.DedupWindow = nc.DedupWindow
+	n.MaxPerMinute = nc.MaxPerMinute
+	return n
+}
+
+// shortHash names a per-URL outbox so reordering webhooks in the config
+// does not send pending alerts to the wrong endpoint.
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:4])
+}
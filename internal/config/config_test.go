@@ -0,0 +1,398 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JPM1118/slua/internal/hooks"
+	"github.com/JPM1118/slua/internal/notify"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/templates"
+)
+
+func TestParse_MergesOverDefaults(t *testing.T) {
+	data := []byte(`
+org: personal
+detection:
+  poll_interval: 30s
+  prompt_patterns:
+    - 'Continue\?'
+checkpoints:
+  keep: 2
+  sprites:
+    scratch:
+      auto_on_completion: false
+display:
+  columns:
+    name: 30
+  colors:
+    working: "#ffaa00"
+`)
+	cfg, err := Parse("config.yml", data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	def := Default()
+	if cfg.Org != "personal" {
+		t.Errorf("Org = %q", cfg.Org)
+	}
+	if cfg.Detection.PollInterval != 30*time.Second {
+		t.Errorf("PollInterval = %v", cfg.Detection.PollInterval)
+	}
+	if cfg.Detection.ExecTimeout != def.Detection.ExecTimeout {
+		t.Errorf("ExecTimeout = %v, want default %v", cfg.Detection.ExecTimeout, def.Detection.ExecTimeout)
+	}
+	if !reflect.DeepEqual(cfg.Detection.PromptPatterns, []string{`Continue\?`}) {
+		t.Errorf("PromptPatterns = %q", cfg.Detection.PromptPatterns)
+	}
+	if cfg.Display.Columns.Name != 30 || cfg.Display.Columns.Status != 0 {
+		t.Errorf("Columns = %+v", cfg.Display.Columns)
+	}
+	if cfg.Display.Colors.Working != "#ffaa00" {
+		t.Errorf("Colors.Working = %q", cfg.Display.Colors.Working)
+	}
+
+	policies := cfg.AutoCheckpointPolicies()
+	if got := policies.For("other"); !got.Enabled || got.Keep != 2 {
+		t.Errorf("default policy = %+v", got)
+	}
+	if got := policies.For("scratch"); got.Enabled || got.Keep != 2 || got.DedupWindow != sprites.DefaultAutoCheckpointDedup {
+		t.Errorf("scratch policy = %+v", got)
+	}
+}
+
+func TestParse_EmptyFile(t *testing.T) {
+	cfg, err := Parse("config.yml", nil)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("empty file should yield defaults, got %+v", cfg)
+	}
+}
+
+func TestParse_ValidationErrorsHaveLines(t *testing.T) {
+	data := []byte(`detection:
+  poll_interval: 10ms
+  prompt_patterns:
+    - 'ok'
+    - '(unclosed'
+notifications:
+  on_states: [WAITING, BUSY]
+cost:
+  running_hour: -1
+queue:
+  max_sprites: -2
+`)
+	_, err := Parse("config.yml", data)
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+	want := []struct {
+		line  int
+		field string
+	}{
+		{2, "detection.poll_interval"},
+		{5, "detection.prompt_patterns.1"},
+		{7, "notifications.on_states.1"},
+		{9, "cost.running_hour"},
+		{11, "queue.max_sprites"},
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("got %d errors, want %d:\n%v", len(errs), len(want), err)
+	}
+	for i, w := range want {
+		if errs[i].Line != w.line || errs[i].Field != w.field {
+			t.Errorf("error %d = %q, want line %d field %s", i, errs[i], w.line, w.field)
+		}
+	}
+	if !strings.HasPrefix(errs[0].Error(), "config.yml:2: detection.poll_interval: ") {
+		t.Errorf("unexpected message format: %q", errs[0])
+	}
+}
+
+func TestParse_PromptPatternsArePOSIX(t *testing.T) {
+	_, err := Parse("config.yml", []byte(`detection:
+  prompt_patterns: ['Allow \d+ files', '(?i)proceed']
+`))
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("expected two errors for Perl syntax grep -E does not take, got %v", err)
+	}
+	if _, err := Parse("config.yml", []byte("detection:\n  prompt_patterns: ['[[:digit:]]+ files\\?']\n")); err != nil {
+		t.Errorf("POSIX classes should validate: %v", err)
+	}
+}
+
+func TestParse_DecodeErrorsHaveLines(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+	}{
+		{"bad duration", "detection:\n  exec_timeout: soon\n", 2},
+		{"unknown key", "org: x\ndisplay:\n  colour: red\n", 3},
+		{"syntax", "org: x\n  bad: [\n", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse("config.yml", []byte(tt.data))
+			var errs Errors
+			if !errors.As(err, &errs) || len(errs) == 0 {
+				t.Fatalf("expected Errors, got %v", err)
+			}
+			if errs[0].Line != tt.line {
+				t.Errorf("line = %d, want %d (%v)", errs[0].Line, tt.line, err)
+			}
+		})
+	}
+}
+
+func TestLoad_MissingFileUsesDefaults(t *testing.T) {
+	cfg, err := Load(filepath.Join(t.TempDir(), "config.yml"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if !reflect.DeepEqual(cfg, Default()) {
+		t.Errorf("expected defaults, got %+v", cfg)
+	}
+}
+
+func TestLoad_ReadsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte("backend: api\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if cfg.Backend != BackendAPI {
+		t.Errorf("Backend = %q", cfg.Backend)
+	}
+}
+
+func TestPath_RespectsXDG(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", "/tmp/xdg")
+	path, err := Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join("/tmp/xdg", "slua", "config.yml") {
+		t.Errorf("Path = %q", path)
+	}
+}
+
+func TestOrgList(t *testing.T) {
+	cfg, err := Parse("config.yml", []byte("org: work\norgs: [personal, work]\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := cfg.OrgList(); !reflect.DeepEqual(got, []string{"work", "personal"}) {
+		t.Errorf("OrgList() = %q, want the default first", got)
+	}
+	if got := Default().OrgList(); !reflect.DeepEqual(got, []string{""}) {
+		t.Errorf("default OrgList() = %q, want the sprite CLI's org", got)
+	}
+
+	_, err = Parse("config.yml", []byte("org: other\norgs: [personal, personal]\n"))
+	for _, want := range []string{"orgs.1: personal is listed twice", "org: must be one of orgs"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse error %v, want %q", err, want)
+		}
+	}
+}
+
+func TestValidate_FlagOverrides(t *testing.T) {
+	cfg := Default()
+	cfg.Backend = "grpc"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `backend: unknown backend "grpc"`) {
+		t.Errorf("Validate = %v", err)
+	}
+}
+
+func TestNewNotifier(t *testing.T) {
+	cfg, err := Parse("config.yml", []byte(`notifications:
+  on_states: [FINISHED]
+  terminal_bell: false
+  command: "true"
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	n := cfg.NewNotifier(t.TempDir())
+	if len(n.Sinks) != 1 {
+		t.Errorf("sinks = %d, want only the command hook", len(n.Sinks))
+	}
+	if !n.Wants(sprites.StatusFinished) || n.Wants(sprites.StatusWaiting) {
+		t.Errorf("notifier states = %v", n.States)
+	}
+}
+
+func TestNewNotifier_PushSinksUseOutbox(t *testing.T) {
+	cfg, err := Parse("config.yml", []byte(`notifications:
+  terminal_bell: false
+  ntfy:
+    url: https://ntfy.sh/sprites
+  webhooks:
+    - url: https://hooks.example.com/a
+      template: '{"text": {{json .Title}}}'
+  retry:
+    attempts: 5
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	state := t.TempDir()
+	n := cfg.NewNotifier(state)
+	if len(n.Sinks) != 2 {
+		t.Fatalf("sinks = %d, want ntfy and webhook", len(n.Sinks))
+	}
+	for _, s := range n.Sinks {
+		o, ok := s.(*notify.Outbox)
+		if !ok {
+			t.Fatalf("push sink %T is not wrapped in an outbox", s)
+		}
+		if o.Attempts != 5 || !strings.HasPrefix(o.Dir, filepath.Join(state, "outbox")) {
+			t.Errorf("outbox = %+v", o)
+		}
+	}
+}
+
+func TestParse_BadWebhook(t *testing.T) {
+	_, err := Parse("config.yml", []byte(`notifications:
+  webhooks:
+    - url: not-a-url
+      template: '{{.Title'
+`))
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("expected two errors, got %v", err)
+	}
+	if errs[0].Line != 3 || errs[1].Line != 4 {
+		t.Errorf("lines = %d, %d; want 3, 4", errs[0].Line, errs[1].Line)
+	}
+}
+
+func TestParse_Templates(t *testing.T) {
+	cfg, err := Parse("config.yml", []byte(`
+templates:
+  node:
+    description: Node.js app
+    steps:
+      - name: Clone
+        run: git clone https://example.com/app.git
+      - run: npm ci
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Chdir(t.TempDir())
+	ts, err := cfg.SpriteTemplates()
+	if err != nil {
+		t.Fatalf("SpriteTemplates: %v", err)
+	}
+	if len(ts) != 2 || ts[0].Name != "blank" || ts[1].Name != "node" {
+		t.Fatalf("templates = %+v, want blank then node", ts)
+	}
+	if got := ts[1].Steps[1].Label(); got != "npm ci" {
+		t.Errorf("unnamed step label = %q", got)
+	}
+
+	_, err = Parse("config.yml", []byte(`
+templates:
+  broken:
+    steps:
+      - name: Nothing
+`))
+	if err == nil || !strings.Contains(err.Error(), "config.yml:5: templates.broken.steps.0.run: must not be empty") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestParse_Detectors(t *testing.T) {
+	cfg, err := Parse("config.yml", []byte(`
+detection:
+  detector: process
+  detectors:
+    aider:
+      type: tmux
+      process: aider
+      prompt_patterns: ['\(Y\)es/\(N\)o']
+    hooks:
+      type: marker
+      process: claude
+  sprites:
+    api: tmux
+templates:
+  web:
+    detector: hooks
+`))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	ts := []templates.Template{{Name: "web", Detector: "hooks"}, {Name: "cli", Detector: "aider"}}
+	of := map[string]string{"api": "web", "site": "web", "tool": "cli"}
+	pick := cfg.DetectorFor(ts, func(sprite string) string { return of[sprite] })
+
+	tests := []struct {
+		sprite string
+		want   poller.Detector
+	}{
+		{"api", poller.Tmux{Patterns: poller.DefaultPromptPatterns}},
+		{"site", poller.Marker{Process: "claude"}},
+		{"tool", poller.Tmux{Process: "aider", Patterns: []string{`\(Y\)es/\(N\)o`}}},
+		{"other", poller.Process{}},
+	}
+	for _, tt := range tests {
+		if got := pick(tt.sprite); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("detector for %s = %#v, want %#v", tt.sprite, got, tt.want)
+		}
+	}
+	if _, ok := Default().DetectorFor(nil, nil)("api").(*hooks.Detector); !ok {
+		t.Error("the default detector should read the hook log")
+	}
+
+	_, err = Parse("config.yml", []byte(`detection:
+  detector: codex
+  detectors:
+    bad:
+      type: screen
+    empty:
+      type: script
+  sprites:
+    api: missing
+templates:
+  web:
+    detector: nope
+`))
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+	var fields []string
+	for _, e := range errs {
+		fields = append(fields, e.Field)
+	}
+	want := []string{
+		"detection.detector",
+		"detection.detectors.bad.type",
+		"detection.detectors.empty.script",
+		"detection.sprites.api",
+		"templates.web.detector",
+	}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("error fields = %q, want %q", fields, want)
+	}
+}
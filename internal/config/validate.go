@@ -0,0 +1,383 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/hooks"
+	"github.com/JPM1118/slua/internal/notify"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/sprites"
+	"gopkg.in/yaml.v3"
+)
+
+// Error is a problem with one config value.
+type Error struct {
+	File  string
+	Line  int // zero if unknown
+	Field string
+	Msg   string
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File)
+		if e.Line > 0 {
+			fmt.Fprintf(&b, ":%d", e.Line)
+		}
+		b.WriteString(": ")
+	}
+	if e.Field != "" {
+		b.WriteString(e.Field + ": ")
+	}
+	b.WriteString(e.Msg)
+	return b.String()
+}
+
+// Errors is every problem found in a config, in file order.
+type Errors []*Error
+
+func (es Errors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Validate checks c without a source file, e.g. after applying flag
+// overrides. It returns Errors or nil.
+func (c Config) Validate() error {
+	probs := c.problems()
+	if len(probs) == 0 {
+		return nil
+	}
+	errs := make(Errors, len(probs))
+	for i, p := range probs {
+		errs[i] = &Error{Field: p.field(), Msg: p.msg}
+	}
+	return errs
+}
+
+// problem is a validation failure at a YAML path.
+type problem struct {
+	path []string
+	msg  string
+}
+
+func (p problem) field() string {
+	return strings.Join(p.path, ".")
+}
+
+// knownStates are the statuses notifications can be configured for.
+var knownStates = []string{
+	sprites.StatusWorking,
+	sprites.StatusWaiting,
+	sprites.StatusFinished,
+	sprites.StatusError,
+	sprites.StatusSleeping,
+	sprites.StatusUnreachable,
+}
+
+// detectorTypes are the kinds of detector detection.detectors can define.
+var detectorTypes = []string{
+	hooks.DetectorHooks,
+	poller.DetectorTmux,
+	poller.DetectorProcess,
+	poller.DetectorMarker,
+	poller.DetectorScript,
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+func (c Config) problems() []problem {
+	var probs []problem
+	add := func(msg string, path ...string) {
+		probs = append(probs, problem{path: path, msg: msg})
+	}
+	positive := func(d time.Duration, path ...string) {
+		if d <= 0 {
+			add("must be a positive duration", path...)
+		}
+	}
+	nonNegative := func(d time.Duration, path ...string) {
+		if d < 0 {
+			add("must not be negative", path...)
+		}
+	}
+
+	if c.Backend != BackendCLI && c.Backend != BackendAPI {
+		add(fmt.Sprintf("unknown backend %q (want %s or %s)", c.Backend, BackendCLI, BackendAPI), "backend")
+	}
+	positive(c.ListTimeout, "list_timeout")
+	seenOrgs := make(map[string]bool)
+	for i, o := range c.Orgs {
+		idx := strconv.Itoa(i)
+		switch {
+		case o == "":
+			add("must not be empty", "orgs", idx)
+		case strings.Contains(o, "/"):
+			add("must not contain /", "orgs", idx)
+		case seenOrgs[o]:
+			add(fmt.Sprintf("%s is listed twice", o), "orgs", idx)
+		}
+		seenOrgs[o] = true
+	}
+	if c.Org != "" && len(c.Orgs) > 0 && !seenOrgs[c.Org] {
+		add("must be one of orgs", "org")
+	}
+
+	d := c.Detection
+	if d.PollInterval < time.Second {
+		add("must be at least 1s", "detection", "poll_interval")
+	}
+	positive(d.ExecTimeout, "detection", "exec_timeout")
+	if d.Workers < 1 {
+		add("must be at least 1", "detection", "workers")
+	}
+	if d.FailureThreshold < 1 {
+		add("must be at least 1", "detection", "failure_threshold")
+	}
+	patterns := func(pats []string, path ...string) {
+		for i, pat := range pats {
+			p := append(append([]string(nil), path...), strconv.Itoa(i))
+			if pat == "" {
+				add("must not be empty", p...)
+				continue
+			}
+			// The patterns run under grep -E on the Sprite.
+			if _, err := regexp.CompilePOSIX(pat); err != nil {
+				add(fmt.Sprintf("invalid POSIX extended regex: %v", err), p...)
+			}
+		}
+	}
+	patterns(d.PromptPatterns, "detection", "prompt_patterns")
+	dets := c.detectors()
+	knownDetector := func(name string, path ...string) {
+		if _, ok := dets[name]; !ok {
+			add(fmt.Sprintf("unknown detector %q (want %s, %s, %s, %s or one of detection.detectors)",
+				name, hooks.DetectorHooks, poller.DetectorTmux, poller.DetectorProcess, poller.DetectorMarker), path...)
+		}
+	}
+	knownDetector(d.Detector, "detection", "detector")
+	for _, name := range sortedKeys(d.Detectors) {
+		det := d.Detectors[name]
+		switch det.Type {
+		case hooks.DetectorHooks, poller.DetectorTmux, poller.DetectorProcess, poller.DetectorMarker:
+		case poller.DetectorScript:
+			if strings.TrimSpace(det.Script) == "" {
+				add("is required for a script detector", "detection", "detectors", name, "script")
+			}
+		default:
+			add(fmt.Sprintf("unknown detector type %q (want one of %s)", det.Type, strings.Join(detectorTypes, ", ")),
+				"detection", "detectors", name, "type")
+		}
+		patterns(det.PromptPatterns, "detection", "detectors", name, "prompt_patterns")
+	}
+	for _, name := range sortedKeys(d.Sprites) {
+		knownDetector(d.Sprites[name], "detection", "sprites", name)
+	}
+
+	cp := c.Checkpoints
+	if cp.Keep < 0 {
+		add("must not be negative", "checkpoints", "keep")
+	}
+	nonNegative(cp.DedupWindow, "checkpoints", "dedup_window")
+	names := make([]string, 0, len(cp.Sprites))
+	for name := range cp.Sprites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		o := cp.Sprites[name]
+		if o.Keep != nil && *o.Keep < 0 {
+			add("must not be negative", "checkpoints", "sprites", name, "keep")
+		}
+		if o.DedupWindow != nil {
+			nonNegative(*o.DedupWindow, "checkpoints", "sprites", name, "dedup_window")
+		}
+	}
+
+	n := c.Notifications
+	for i, st := range n.OnStates {
+		if !contains(knownStates, st) {
+			add(fmt.Sprintf("unknown status %q (want one of %s)", st, strings.Join(knownStates, ", ")),
+				"notifications", "on_states", strconv.Itoa(i))
+		}
+	}
+	nonNegative(n.DedupWindow, "notifications", "dedup_window")
+	if n.MaxPerMinute < 0 {
+		add("must not be negative", "notifications", "max_per_minute")
+	}
+	if n.Ntfy.URL != "" && !validURL(n.Ntfy.URL) {
+		add("must be an http or https URL", "notifications", "ntfy", "url")
+	}
+	for i, w := range n.Webhooks {
+		idx := strconv.Itoa(i)
+		if !validURL(w.URL) {
+			add("must be an http or https URL", "notifications", "webhooks", idx, "url")
+		}
+		if w.Template != "" {
+			if _, err := notify.ParseWebhookTemplate(w.Template); err != nil {
+				add(err.Error(), "notifications", "webhooks", idx, "template")
+			}
+		}
+	}
+	if n.Retry.Attempts < 1 {
+		add("must be at least 1", "notifications", "retry", "attempts")
+	}
+	nonNegative(n.Retry.Backoff, "notifications", "retry", "backoff")
+
+	cols := c.Display.Columns
+	for _, col := range []struct {
+		key   string
+		width int
+	}{{"name", cols.Name}, {"status", cols.Status}, {"uptime", cols.Uptime}} {
+		if col.width < 0 {
+			add("must not be negative", "display", "columns", col.key)
+		}
+	}
+
+	colors := c.Display.Colors
+	for _, col := range []struct {
+		key   string
+		value string
+	}{
+		{"working", colors.Working}, {"finished", colors.Finished}, {"waiting", colors.Waiting},
+		{"error", colors.Error}, {"sleeping", colors.Sleeping}, {"unreachable", colors.Unreachable},
+		{"header", colors.Header}, {"muted", colors.Muted}, {"cursor", colors.Cursor},
+	} {
+		if col.value != "" && !validColor(col.value) {
+			add(fmt.Sprintf("invalid color %q (want an ANSI index 0-255 or #rrggbb)", col.value),
+				"display", "colors", col.key)
+		}
+	}
+
+	for _, rate := range []struct {
+		key   string
+		value float64
+	}{{"running_hour", c.Cost.RunningHour}, {"storage_gb_month", c.Cost.StorageGBMonth}, {"storage_gb", c.Cost.StorageGB}} {
+		if rate.value < 0 {
+			add("must not be negative", "cost", rate.key)
+		}
+	}
+
+	if c.Queue.MaxSprites < 0 {
+		add("must not be negative", "queue", "max_sprites")
+	}
+
+	tnames := make([]string, 0, len(c.Templates))
+	for name := range c.Templates {
+		tnames = append(tnames, name)
+	}
+	sort.Strings(tnames)
+	for _, name := range tnames {
+		for _, p := range c.Templates[name].Problems() {
+			add(p.Msg, append([]string{"templates", name}, p.Path...)...)
+		}
+		if det := c.Templates[name].Detector; det != "" {
+			knownDetector(det, "templates", name, "detector")
+		}
+	}
+
+	return probs
+}
+
+func validURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validColor(s string) bool {
+	if hexColor.MatchString(s) {
+		return true
+	}
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0 && n <= 255
+}
+
+// sortedKeys returns m's keys in order, so problems are reported in a
+// stable order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// lineOf returns the line of the value at path in the parsed document, or
+// of the deepest ancestor present when the value came from the defaults.
+func lineOf(root *yaml.Node, path []string) int {
+	n := root
+	if n.Kind == yaml.DocumentNode {
+		if len(n.Content) == 0 {
+			return 0
+		}
+		n = n.Content[0]
+	}
+	for _, key := range path {
+		next := child(n, key)
+		if next == nil {
+			break
+		}
+		n = next
+	}
+	return n.Line
+}
+
+func child(n *yaml.Node, key string) *yaml.Node {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == key {
+				return n.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && i < len(n.Content) {
+			return n.Content[i]
+		}
+	}
+	return nil
+}
+
+// yamlLine matches the "line N: " prefix yaml.v3 puts on its messages.
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlErrors converts a yaml.v3 decode error into Errors.
+func yamlErrors(name string, err error) Errors {
+	var msgs []string
+	if te, ok := err.(*yaml.TypeError); ok {
+		msgs = te.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+
+	errs := make(Errors, 0, len(msgs))
+	for _, msg := range msgs {
+		e := &Error{File: name, Msg: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Msg = m[2]
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
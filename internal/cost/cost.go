@@ -0,0 +1,133 @@
+// Package cost estimates what Sprites cost from the time they spent awake
+// and asleep, as recorded in the state file's usage log.
+package cost
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/JPM1118/slua/internal/state"
+)
+
+// HoursPerMonth converts monthly storage rates to hourly ones.
+const HoursPerMonth = 730
+
+// Ways to group a Report.
+const (
+	BySprite = "sprite"
+	ByTag    = "tag"
+	ByDay    = "day"
+	ByMonth  = "month"
+)
+
+// Untagged is the Report key of Sprites without tags when grouping by tag.
+const Untagged = "(untagged)"
+
+// Rates prices Sprite usage. Zero rates are free.
+type Rates struct {
+	// RunningHour is charged for every hour a Sprite is awake.
+	RunningHour float64
+	// StorageGBMonth is charged per GB stored per month, awake or asleep.
+	StorageGBMonth float64
+	// StorageGB is the disk each Sprite is assumed to use, since the
+	// backends do not report it.
+	StorageGB float64
+	// Currency is the symbol amounts are shown with.
+	Currency string
+}
+
+// Enabled reports whether any usage costs something.
+func (r Rates) Enabled() bool {
+	return r.RunningHour > 0 || r.StorageGBMonth > 0 && r.StorageGB > 0
+}
+
+// Of returns the cost of one Sprite awake and asleep for the given hours.
+func (r Rates) Of(awake, asleep float64) float64 {
+	storage := r.StorageGB * r.StorageGBMonth / HoursPerMonth
+	return awake*r.RunningHour + (awake+asleep)*storage
+}
+
+// Format renders amount with the currency, such as "$12.34".
+func (r Rates) Format(amount float64) string {
+	return fmt.Sprintf("%s%.2f", r.Currency, amount)
+}
+
+// Line is one row of a Report.
+type Line struct {
+	Key    string  `json:"key"`
+	Awake  float64 `json:"awake_hours"`
+	Asleep float64 `json:"asleep_hours"`
+	Cost   float64 `json:"cost"`
+}
+
+// Since returns the usage of days on or after since.
+func Since(usage []state.Usage, since time.Time) []state.Usage {
+	day := since.Format(state.DayFormat)
+	var out []state.Usage
+	for _, u := range usage {
+		if u.Day >= day {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// Report totals usage by Sprite, tag, day or month. Days and months are in
+// order; Sprites and tags are the most expensive first. A Sprite with
+// several tags counts towards each of them.
+func Report(usage []state.Usage, rates Rates, by string, tags map[string][]string) ([]Line, error) {
+	var keys func(u state.Usage) []string
+	switch by {
+	case BySprite, "":
+		keys = func(u state.Usage) []string { return []string{u.Sprite} }
+	case ByTag:
+		keys = func(u state.Usage) []string {
+			if t := tags[u.Sprite]; len(t) > 0 {
+				return t
+			}
+			return []string{Untagged}
+		}
+	case ByDay:
+		keys = func(u state.Usage) []string { return []string{u.Day} }
+	case ByMonth:
+		keys = func(u state.Usage) []string { return []string{u.Day[:7]} }
+	default:
+		return nil, fmt.Errorf("unknown grouping %q (want %s, %s, %s or %s)", by, BySprite, ByTag, ByDay, ByMonth)
+	}
+
+	index := make(map[string]int)
+	var lines []Line
+	for _, u := range usage {
+		for _, k := range keys(u) {
+			i, ok := index[k]
+			if !ok {
+				i = len(lines)
+				index[k] = i
+				lines = append(lines, Line{Key: k})
+			}
+			lines[i].Awake += u.Awake
+			lines[i].Asleep += u.Asleep
+			lines[i].Cost += rates.Of(u.Awake, u.Asleep)
+		}
+	}
+	slices.SortFunc(lines, func(a, b Line) int {
+		if by == ByDay || by == ByMonth {
+			return cmp.Compare(a.Key, b.Key)
+		}
+		return cmp.Or(cmp.Compare(b.Cost, a.Cost), cmp.Compare(a.Key, b.Key))
+	})
+	return lines, nil
+}
+
+// Total sums usage into a single line.
+func Total(usage []state.Usage, rates Rates) Line {
+	t := Line{Key: "total"}
+	for _, u := range usage {
+		t.Awake += u.Awake
+		t.Asleep += u.Asleep
+		t.Cost += rates.Of(u.Awake, u.Asleep)
+	}
+	return t
+}
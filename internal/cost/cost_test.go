@@ -0,0 +1,66 @@
+package cost
+
+import (
+	"testing"
+	"time"
+
+	"github.com/JPM1118/slua/internal/state"
+)
+
+func TestReport(t *testing.T) {
+	rates := Rates{RunningHour: 0.5, StorageGBMonth: 0.73, StorageGB: 10, Currency: "$"}
+	usage := []state.Usage{
+		{Day: "2026-04-30", Sprite: "web", Awake: 10},
+		{Day: "2026-05-01", Sprite: "web", Awake: 2, Asleep: 8},
+		{Day: "2026-05-01", Sprite: "api", Awake: 4},
+		{Day: "2026-05-02", Sprite: "docs", Asleep: 24},
+	}
+	// Storage is 10 GB at $0.001 per GB-hour: $0.01 an hour, awake or not.
+	if got := rates.Of(2, 8); !near(got, 1.1) {
+		t.Errorf("Of(2, 8) = %v, want 1.10", got)
+	}
+	if got := rates.Format(rates.Of(2, 8)); got != "$1.10" {
+		t.Errorf("Format = %q", got)
+	}
+
+	recent := Since(usage, time.Date(2026, 5, 1, 18, 0, 0, 0, time.UTC))
+	if len(recent) != 3 {
+		t.Fatalf("Since = %+v, want whole days from May 1", recent)
+	}
+
+	lines, err := Report(recent, rates, BySprite, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 3 || lines[0].Key != "api" || !near(lines[0].Cost, 2.04) || lines[1].Key != "web" {
+		t.Errorf("by sprite = %+v, want api then web then docs", lines)
+	}
+
+	tags := map[string][]string{"web": {"frontend", "prod"}, "api": {"prod"}}
+	lines, _ = Report(recent, rates, ByTag, tags)
+	want := []string{"prod", "frontend", Untagged}
+	for i, l := range lines {
+		if i >= len(want) || l.Key != want[i] {
+			t.Fatalf("by tag = %+v, want keys %v", lines, want)
+		}
+	}
+	if !near(lines[0].Cost, 3.14) {
+		t.Errorf("prod = %v, want web and api", lines[0].Cost)
+	}
+
+	lines, _ = Report(usage, rates, ByMonth, nil)
+	if len(lines) != 2 || lines[0].Key != "2026-04" || lines[1].Key != "2026-05" || lines[1].Awake != 6 {
+		t.Errorf("by month = %+v", lines)
+	}
+
+	if _, err := Report(usage, rates, "region", nil); err == nil {
+		t.Errorf("unknown grouping should fail")
+	}
+	if total := Total(recent, rates); total.Awake != 6 || total.Asleep != 32 || !near(total.Cost, 3.38) {
+		t.Errorf("Total = %+v", total)
+	}
+}
+
+func near(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
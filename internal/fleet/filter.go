@@ -0,0 +1,85 @@
+package fleet
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Filter selects Sprites with an expression of whitespace-separated terms,
+// all of which must match. A term is key=pattern or key!=pattern, where
+// key is name, status or region, or a bare pattern matched against the
+// name. Patterns are shell globs compared case-insensitively, so
+// "web-* status!=sleeping" selects every awake Sprite named web-something.
+// A name listed with its organization, as "org/name", is matched without
+// it unless the pattern has an org/ part too.
+type Filter struct {
+	terms []term
+}
+
+type term struct {
+	key     string
+	pattern string
+	negate  bool
+}
+
+// ParseFilter parses expr. An empty expression matches every Sprite.
+func ParseFilter(expr string) (Filter, error) {
+	var f Filter
+	for _, field := range strings.Fields(expr) {
+		t := term{key: "name", pattern: field}
+		if k, v, ok := strings.Cut(field, "="); ok {
+			t.key, t.pattern = k, v
+			if strings.HasSuffix(k, "!") {
+				t.key, t.negate = strings.TrimSuffix(k, "!"), true
+			}
+		}
+		switch t.key {
+		case "name", "status", "region":
+		default:
+			return Filter{}, fmt.Errorf("filter %q: unknown key %q (want name, status or region)", field, t.key)
+		}
+		t.pattern = strings.ToLower(t.pattern)
+		if _, err := path.Match(t.pattern, ""); err != nil {
+			return Filter{}, fmt.Errorf("filter %q: %w", field, err)
+		}
+		f.terms = append(f.terms, t)
+	}
+	return f, nil
+}
+
+// NeedsStatus reports whether f matches on status, which callers may want
+// to detect before filtering.
+func (f Filter) NeedsStatus() bool {
+	for _, t := range f.terms {
+		if t.key == "status" {
+			return true
+		}
+	}
+	return false
+}
+
+// Match reports whether s passes every term of f.
+func (f Filter) Match(s sprites.Sprite) bool {
+	for _, t := range f.terms {
+		var value string
+		switch t.key {
+		case "name":
+			value = s.Name
+			if !strings.Contains(t.pattern, "/") {
+				value = path.Base(value)
+			}
+		case "status":
+			value = s.Status
+		case "region":
+			value = s.Region
+		}
+		ok, _ := path.Match(t.pattern, strings.ToLower(value))
+		if ok == t.negate {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,161 @@
+// Package fleet runs one command on many Sprites at once.
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Defaults for Options.
+const (
+	DefaultConcurrency = 8
+	DefaultTimeout     = 5 * time.Minute
+)
+
+// Options bounds a fleet run.
+type Options struct {
+	// Concurrency is how many Sprites run the command at once. Zero means
+	// DefaultConcurrency.
+	Concurrency int
+	// Timeout bounds the command on each Sprite. Zero means DefaultTimeout.
+	Timeout time.Duration
+	// Output returns where a Sprite's output is copied while it runs. It
+	// may be nil, or return nil writers, to only collect output.
+	Output func(name string) (stdout, stderr io.Writer)
+	// Done is called once per Sprite as it finishes, from its goroutine.
+	Done func(Result)
+}
+
+// Result is the outcome of the command on one Sprite.
+type Result struct {
+	Sprite   string        `json:"sprite"`
+	ExitCode int           `json:"exit_code"`
+	Stdout   string        `json:"stdout"`
+	Stderr   string        `json:"stderr"`
+	Duration time.Duration `json:"-"`
+	// Error is set when the command could not be run or timed out, in
+	// which case ExitCode is meaningless.
+	Error string `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes Duration in milliseconds.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type result Result
+	return json.Marshal(struct {
+		result
+		DurationMS int64 `json:"duration_ms"`
+	}{result(r), r.Duration.Milliseconds()})
+}
+
+// OK reports whether the command ran and exited zero.
+func (r Result) OK() bool {
+	return r.Error == "" && r.ExitCode == 0
+}
+
+// Run executes command on every Sprite in names and returns the results in
+// the same order. Sources that implement sprites.StreamExecer have their
+// output copied to opts.Output as it arrives; others once they finish.
+func Run(ctx context.Context, src sprites.SpriteSource, names []string, command []string, opts Options) []Result {
+	limit := opts.Concurrency
+	if limit <= 0 {
+		limit = DefaultConcurrency
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	results := make([]Result, len(names))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				results[i] = Result{Sprite: name, Error: ctx.Err().Error()}
+				done(opts, results[i])
+				return
+			}
+			defer func() { <-sem }()
+
+			results[i] = runOne(ctx, src, name, command, timeout, opts)
+			done(opts, results[i])
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func done(opts Options, r Result) {
+	if opts.Done != nil {
+		opts.Done(r)
+	}
+}
+
+func runOne(ctx context.Context, src sprites.SpriteSource, name string, command []string, timeout time.Duration, opts Options) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var outW, errW io.Writer
+	if opts.Output != nil {
+		outW, errW = opts.Output(name)
+	}
+
+	start := time.Now()
+	var res sprites.ExecResult
+	var err error
+	if s, ok := src.(sprites.StreamExecer); ok {
+		var stdout, stderr bytes.Buffer
+		res.ExitCode, err = s.ExecStream(ctx, name, command, tee(&stdout, outW), tee(&stderr, errW))
+		res.Stdout, res.Stderr = stdout.Bytes(), stderr.Bytes()
+	} else {
+		res, err = src.Exec(ctx, name, command)
+		copyTo(outW, res.Stdout)
+		copyTo(errW, res.Stderr)
+	}
+
+	r := Result{
+		Sprite:   name,
+		ExitCode: res.ExitCode,
+		Stdout:   string(res.Stdout),
+		Stderr:   string(res.Stderr),
+		Duration: time.Since(start),
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil:
+		r.Error = fmt.Sprintf("timed out after %s", timeout)
+	case err != nil:
+		r.Error = err.Error()
+	}
+	if f, ok := outW.(flusher); ok {
+		f.Flush()
+	}
+	if f, ok := errW.(flusher); ok {
+		f.Flush()
+	}
+	return r
+}
+
+func tee(buf *bytes.Buffer, w io.Writer) io.Writer {
+	if w == nil {
+		return buf
+	}
+	return io.MultiWriter(buf, w)
+}
+
+func copyTo(w io.Writer, data []byte) {
+	if w != nil && len(data) > 0 {
+		w.Write(data)
+	}
+}
@@ -0,0 +1,158 @@
+package fleet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// fakeSource runs commands in memory. A Sprite named "slow" blocks until
+// its context is done and "bad" exits 1. Other SpriteSource methods are
+// not used.
+type fakeSource struct {
+	sprites.SpriteSource
+	running, peak atomic.Int32
+}
+
+func (f *fakeSource) Exec(ctx context.Context, name string, command []string) (sprites.ExecResult, error) {
+	n := f.running.Add(1)
+	defer f.running.Add(-1)
+	for {
+		p := f.peak.Load()
+		if n <= p || f.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+
+	switch name {
+	case "slow":
+		<-ctx.Done()
+		return sprites.ExecResult{}, ctx.Err()
+	case "bad":
+		return sprites.ExecResult{Stderr: []byte("no\n"), ExitCode: 1}, nil
+	}
+	time.Sleep(5 * time.Millisecond)
+	return sprites.ExecResult{Stdout: []byte(name + ": " + strings.Join(command, " ") + "\n")}, nil
+}
+
+func TestRun(t *testing.T) {
+	src := &fakeSource{}
+	names := []string{"a", "b", "c", "d", "bad", "slow"}
+
+	var out bytes.Buffer
+	var mu sync.Mutex
+	finished := 0
+	results := Run(context.Background(), src, names, []string{"git", "pull"}, Options{
+		Concurrency: 2,
+		Timeout:     50 * time.Millisecond,
+		Output: func(name string) (io.Writer, io.Writer) {
+			w := NewPrefixWriter(&out, name+" | ", &mu)
+			return w, w
+		},
+		Done: func(Result) {
+			mu.Lock()
+			finished++
+			mu.Unlock()
+		},
+	})
+
+	if len(results) != len(names) || finished != len(names) {
+		t.Fatalf("%d results, %d done calls; want %d", len(results), finished, len(names))
+	}
+	for i, r := range results {
+		if r.Sprite != names[i] {
+			t.Errorf("results[%d] = %s, want input order", i, r.Sprite)
+		}
+	}
+	if !results[0].OK() || results[0].Stdout != "a: git pull\n" {
+		t.Errorf("a = %+v", results[0])
+	}
+	if r := results[4]; r.OK() || r.ExitCode != 1 || r.Error != "" {
+		t.Errorf("bad = %+v", r)
+	}
+	if r := results[5]; r.OK() || r.Error != "timed out after 50ms" {
+		t.Errorf("slow = %+v", r)
+	}
+	if p := src.peak.Load(); p > 2 {
+		t.Errorf("%d ran at once, limit 2", p)
+	}
+	if !strings.Contains(out.String(), "c | c: git pull\n") || !strings.Contains(out.String(), "bad | no\n") {
+		t.Errorf("output = %q", out.String())
+	}
+}
+
+func TestResult_JSON(t *testing.T) {
+	data, err := json.Marshal(Result{Sprite: "a", ExitCode: 2, Duration: 1500 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"sprite":"a","exit_code":2,"stdout":"","stderr":"","duration_ms":1500}`
+	if string(data) != want {
+		t.Errorf("json = %s, want %s", data, want)
+	}
+}
+
+func TestPrefixWriter(t *testing.T) {
+	var out bytes.Buffer
+	w := NewPrefixWriter(&out, "web │ ", &sync.Mutex{})
+	w.Write([]byte("one\ntw"))
+	w.Write([]byte("o\nthr"))
+	if out.String() != "web │ one\nweb │ two\n" {
+		t.Errorf("before flush = %q", out.String())
+	}
+	w.Flush()
+	if !strings.HasSuffix(out.String(), "web │ thr\n") {
+		t.Errorf("after flush = %q", out.String())
+	}
+}
+
+func TestFilter(t *testing.T) {
+	list := []sprites.Sprite{
+		{Name: "web-1", Status: sprites.StatusWaiting, Region: "ord"},
+		{Name: "web-2", Status: sprites.StatusSleeping, Region: "iad"},
+		{Name: "api", Status: sprites.StatusWorking, Region: "ord"},
+		{Name: "work/web-3", Status: sprites.StatusWorking, Region: "ord"},
+	}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"", "web-1,web-2,api,work/web-3"},
+		{"web-*", "web-1,web-2,work/web-3"},
+		{"region=ORD", "web-1,api,work/web-3"},
+		{"web-* status!=sleeping", "web-1,work/web-3"},
+		{"name=api status=work*", "api"},
+		{"work/*", "work/web-3"},
+		{"name!=*/web-*", "web-1,web-2,api"},
+	}
+	for _, tt := range tests {
+		f, err := ParseFilter(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseFilter(%q): %v", tt.expr, err)
+		}
+		var got []string
+		for _, s := range list {
+			if f.Match(s) {
+				got = append(got, s.Name)
+			}
+		}
+		if strings.Join(got, ",") != tt.want {
+			t.Errorf("%q matched %v, want %s", tt.expr, got, tt.want)
+		}
+	}
+
+	if _, err := ParseFilter("owner=me"); err == nil {
+		t.Error("unknown key should fail")
+	}
+	if f, _ := ParseFilter("web status=waiting"); !f.NeedsStatus() {
+		t.Error("NeedsStatus = false for a status term")
+	}
+}
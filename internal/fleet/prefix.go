@@ -0,0 +1,64 @@
+package fleet
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+type flusher interface {
+	Flush() error
+}
+
+// PrefixWriter copies complete lines to an underlying writer, each
+// preceded by a prefix. PrefixWriters that share a mutex never interleave
+// within a line, so many Sprites can stream to one terminal.
+type PrefixWriter struct {
+	w      io.Writer
+	prefix string
+	mu     *sync.Mutex
+	buf    []byte
+}
+
+// NewPrefixWriter returns a PrefixWriter for w. mu guards w and is shared
+// by every PrefixWriter writing to it.
+func NewPrefixWriter(w io.Writer, prefix string, mu *sync.Mutex) *PrefixWriter {
+	return &PrefixWriter{w: w, prefix: prefix, mu: mu}
+}
+
+// Write buffers b and writes out any lines it completes.
+func (p *PrefixWriter) Write(b []byte) (int, error) {
+	p.buf = append(p.buf, b...)
+	i := bytes.LastIndexByte(p.buf, '\n')
+	if i < 0 {
+		return len(b), nil
+	}
+	lines := p.buf[:i+1]
+	err := p.emit(lines)
+	p.buf = append(p.buf[:0], p.buf[i+1:]...)
+	return len(b), err
+}
+
+// Flush writes a trailing line that has no newline yet.
+func (p *PrefixWriter) Flush() error {
+	if len(p.buf) == 0 {
+		return nil
+	}
+	err := p.emit(append(p.buf, '\n'))
+	p.buf = p.buf[:0]
+	return err
+}
+
+func (p *PrefixWriter) emit(lines []byte) error {
+	var out bytes.Buffer
+	for len(lines) > 0 {
+		i := bytes.IndexByte(lines, '\n')
+		out.WriteString(p.prefix)
+		out.Write(lines[:i+1])
+		lines = lines[i+1:]
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, err := p.w.Write(out.Bytes())
+	return err
+}
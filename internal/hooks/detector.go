@@ -0,0 +1,236 @@
+package hooks
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// DetectorHooks names the Detector in the config file.
+const DetectorHooks = "hooks"
+
+// MaxCatchUp bounds how much of an existing log is read the first time a
+// Sprite is checked. Only the latest events decide the state.
+const MaxCatchUp = 64 << 10
+
+// logScript prints a header line "slua-log <start> <end>", then bytes
+// start to end of the log, then what the fallback command prints. Without
+// a log the header is "slua-log none". $1 is where the last read stopped,
+// or -1; when the log has since shrunk it was replaced and is read again.
+// $2 is the fallback command.
+const logScript = `F="$HOME/` + LogFile + `"
+if [ -f "$F" ]; then
+  END=$(($(wc -c < "$F")))
+  START=$1
+  if [ "$START" -lt 0 ] || [ "$START" -gt "$END" ]; then
+    START=$((END > %[1]d ? END - %[1]d : 0))
+  fi
+  echo "slua-log $START $END"
+  tail -c +$((START + 1)) "$F" | head -c $((END - START))
+else
+  echo "slua-log none"
+fi
+eval "$2"
+`
+
+// Detector reads the state from the event log the hooks write, following
+// it from where the previous check stopped. It falls back to another
+// detector, normally the tmux heuristic, for Sprites whose log has no
+// events yet, and defers to it whenever it finds the agent is not
+// running, since the log cannot tell that Claude Code exited. A Detector
+// remembers each Sprite's place in its log, so the same one must be used
+// for every check of a Sprite.
+type Detector struct {
+	fallback poller.Detector
+
+	mu      sync.Mutex
+	sprites map[string]*follow
+}
+
+// follow is how far a Sprite's log has been read and what it said.
+type follow struct {
+	offset int64 // -1 until the log has been read
+	seen   bool  // some event has been read
+	state  poller.Detection
+	tool   string // the tool last about to run, for permission prompts
+}
+
+// NewDetector returns a Detector that falls back to fallback, or to the
+// default Tmux detector if it is nil.
+func NewDetector(fallback poller.Detector) *Detector {
+	if fallback == nil {
+		fallback = poller.Tmux{}
+	}
+	return &Detector{fallback: fallback, sprites: make(map[string]*follow)}
+}
+
+// Command implements poller.Detector.
+func (d *Detector) Command(sprite string) []string {
+	offset := int64(-1)
+	d.mu.Lock()
+	if f, ok := d.sprites[sprite]; ok {
+		offset = f.offset
+	}
+	d.mu.Unlock()
+	return []string{"sh", "-c", fmt.Sprintf(logScript, MaxCatchUp), "sh",
+		strconv.FormatInt(offset, 10), quoteAll(d.fallback.Command(sprite))}
+}
+
+// Agent implements poller.Agent with the fallback's process and pane.
+func (d *Detector) Agent() (process, target string) {
+	return poller.AgentOf(d.fallback)
+}
+
+// Parse implements poller.Detector.
+func (d *Detector) Parse(sprite string, out []byte) (poller.Detection, error) {
+	header, rest, _ := bytes.Cut(out, []byte("\n"))
+	fields := strings.Fields(string(header))
+	if len(fields) < 2 || fields[0] != "slua-log" {
+		return poller.Detection{}, fmt.Errorf("unexpected hook log output %q", header)
+	}
+	var chunk []byte
+	start, end := int64(-1), int64(-1)
+	if fields[1] != "none" {
+		var err error
+		if len(fields) == 3 {
+			start, err = strconv.ParseInt(fields[1], 10, 64)
+			if err == nil {
+				end, err = strconv.ParseInt(fields[2], 10, 64)
+			}
+		}
+		if err != nil || len(fields) != 3 || start < 0 || end < start || int64(len(rest)) < end-start {
+			return poller.Detection{}, fmt.Errorf("unexpected hook log output %q", header)
+		}
+		chunk, rest = rest[:end-start], rest[end-start:]
+	}
+
+	fb, err := d.fallback.Parse(sprite, rest)
+	if err != nil {
+		return poller.Detection{}, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f := d.sprites[sprite]
+	if f == nil || start < 0 || f.offset > end {
+		// First read, no log, or the log was replaced: start over.
+		f = &follow{offset: -1}
+		d.sprites[sprite] = f
+	}
+	if start >= 0 {
+		f.read(start, chunk)
+	}
+
+	if !f.seen {
+		return fb, nil
+	}
+	if fb.Status != sprites.StatusWorking && fb.Status != sprites.StatusWaiting {
+		// The agent is not running whatever the log last said.
+		f.state = poller.Detection{Status: sprites.StatusFinished}
+		return fb, nil
+	}
+	return f.state, nil
+}
+
+// read applies the complete lines in chunk, which starts at byte start of
+// the log. Lines before f.offset were applied already; a chunk read from
+// the middle of the log starts with part of a line, which is skipped.
+func (f *follow) read(start int64, chunk []byte) {
+	switch {
+	case f.offset >= start:
+		chunk = chunk[min(f.offset-start, int64(len(chunk))):]
+		start = f.offset
+	case start > 0:
+		i := bytes.IndexByte(chunk, '\n')
+		if i < 0 {
+			f.offset = start
+			return
+		}
+		chunk, start = chunk[i+1:], start+int64(i+1)
+	}
+	end := bytes.LastIndexByte(chunk, '\n') + 1
+	for _, line := range bytes.Split(chunk[:end], []byte("\n")) {
+		f.apply(line)
+	}
+	f.offset = start + int64(end)
+}
+
+// entry is one line of the log.
+type entry struct {
+	Hook struct {
+		Event            string          `json:"hook_event_name"`
+		Message          string          `json:"message"`
+		NotificationType string          `json:"notification_type"`
+		ToolName         string          `json:"tool_name"`
+		Input            string          `json:"input"`      // one-line summary of the tool input
+		ToolInput        json.RawMessage `json:"tool_input"` // logged whole by older hooks
+	} `json:"hook"`
+}
+
+// apply updates the state for one log line. Lines that do not parse are
+// ignored.
+func (f *follow) apply(line []byte) {
+	var e entry
+	if len(bytes.TrimSpace(line)) == 0 || json.Unmarshal(line, &e) != nil {
+		return
+	}
+	h := e.Hook
+	switch h.Event {
+	case "UserPromptSubmit", "PostToolUse":
+		f.state = poller.Detection{Status: sprites.StatusWorking}
+	case "PreToolUse":
+		f.state = poller.Detection{Status: sprites.StatusWorking}
+		f.tool = h.ToolName + " " + h.Input
+		if h.Input == "" {
+			f.tool = toolSummary(h.ToolName, h.ToolInput)
+		}
+	case "Notification":
+		if h.NotificationType == "idle_prompt" || strings.HasPrefix(h.Message, "Claude is waiting for your input") {
+			// Sent a while after Stop; nothing has changed.
+			return
+		}
+		detail := h.Message
+		if f.tool != "" && (h.NotificationType == "permission_prompt" || strings.Contains(h.Message, "permission")) {
+			detail += ": " + f.tool
+		}
+		f.state = poller.Detection{Status: sprites.StatusWaiting, Detail: detail}
+	case "Stop":
+		f.state = poller.Detection{Status: sprites.StatusFinished}
+		f.tool = ""
+	default:
+		return
+	}
+	f.seen = true
+}
+
+// toolSummary describes a tool call on one line: the tool and its command,
+// file, URL or pattern.
+func toolSummary(name string, input json.RawMessage) string {
+	var in map[string]any
+	_ = json.Unmarshal(input, &in)
+	for _, key := range []string{"command", "file_path", "url", "pattern", "path"} {
+		if v, ok := in[key].(string); ok && v != "" {
+			line, _, more := strings.Cut(strings.TrimSpace(v), "\n")
+			if more {
+				line += " …"
+			}
+			return name + " " + line
+		}
+	}
+	return name
+}
+
+// quoteAll joins argv into a shell command line.
+func quoteAll(argv []string) string {
+	quoted := make([]string, len(argv))
+	for i, a := range argv {
+		quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
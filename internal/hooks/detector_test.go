@@ -0,0 +1,124 @@
+package hooks
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// fallback reports a fixed status and checks that it got its own output.
+type fallback struct {
+	t      *testing.T
+	status string
+}
+
+func (f *fallback) Command(string) []string {
+	return []string{"echo", "it's me"}
+}
+
+func (f *fallback) Parse(_ string, out []byte) (poller.Detection, error) {
+	if string(out) != "it's me\n" {
+		f.t.Errorf("fallback got %q", out)
+	}
+	return poller.Detection{Status: f.status}, nil
+}
+
+func TestDetector(t *testing.T) {
+	home := t.TempDir()
+	src := localExec{home: home}
+	if err := Install(context.Background(), src, "api"); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	fb := &fallback{t: t, status: sprites.StatusWorking}
+	d := NewDetector(fb)
+
+	check := func(want, detail string) {
+		t.Helper()
+		res, err := src.Exec(context.Background(), "api", d.Command("api"))
+		if err != nil || res.ExitCode != 0 {
+			t.Fatalf("detection failed: %v %s", err, res.Stderr)
+		}
+		got, err := d.Parse("api", res.Stdout)
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		if got.Status != want || got.Detail != detail {
+			t.Errorf("got %s %q, want %s %q", got.Status, got.Detail, want, detail)
+		}
+	}
+	appendLog := func(text string) {
+		t.Helper()
+		f, err := os.OpenFile(filepath.Join(home, LogFile), os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		if _, err := f.WriteString(text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Without hooks the fallback decides.
+	check(sprites.StatusWorking, "")
+
+	hook(t, home, `{"hook_event_name": "UserPromptSubmit"}`)
+	hook(t, home, `{"hook_event_name": "Stop"}`)
+	check(sprites.StatusFinished, "")
+
+	hook(t, home, `{"hook_event_name": "UserPromptSubmit"}`)
+	hook(t, home, `{"hook_event_name": "PreToolUse", "tool_name": "Bash", "tool_input": {"command": "npm test"}}`)
+	hook(t, home, `{"hook_event_name": "Notification", "notification_type": "permission_prompt", "message": "Claude needs your permission to use Bash"}`)
+	check(sprites.StatusWaiting, "Claude needs your permission to use Bash: Bash npm test")
+	check(sprites.StatusWaiting, "Claude needs your permission to use Bash: Bash npm test")
+
+	// A line still being written waits for the next check.
+	appendLog(`{"at": 1, "hook": {"hook_event_name": "Post`)
+	check(sprites.StatusWaiting, "Claude needs your permission to use Bash: Bash npm test")
+	appendLog(`ToolUse"}}` + "\n")
+	check(sprites.StatusWorking, "")
+
+	hook(t, home, `{"hook_event_name": "Stop"}`)
+	hook(t, home, `{"hook_event_name": "Notification", "notification_type": "idle_prompt", "message": "Claude is waiting for your input"}`)
+	check(sprites.StatusFinished, "")
+
+	// Once the agent is gone the fallback decides, and a new session starts
+	// out idle.
+	fb.status = sprites.StatusError
+	check(sprites.StatusError, "")
+	fb.status = sprites.StatusWaiting
+	check(sprites.StatusFinished, "")
+
+	// A replaced log is read from the start.
+	if err := os.Remove(filepath.Join(home, LogFile)); err != nil {
+		t.Fatal(err)
+	}
+	hook(t, home, `{"hook_event_name": "UserPromptSubmit"}`)
+	check(sprites.StatusWorking, "")
+}
+
+func TestDetector_CatchesUpFromTheEnd(t *testing.T) {
+	d := NewDetector(&fallback{t: t, status: sprites.StatusWorking})
+	chunk := `_name": "Stop"}}` + "\n" +
+		`{"hook": {"hook_event_name": "Notification", "message": "Pick an option"}}` + "\n"
+	out := []byte("slua-log 100 " + strconv.Itoa(100+len(chunk)) + "\n" + chunk + "it's me\n")
+
+	got, err := d.Parse("api", out)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got.Status != sprites.StatusWaiting || got.Detail != "Pick an option" {
+		t.Errorf("got %+v, want WAITING from the last whole line", got)
+	}
+	if f := d.sprites["api"]; f.offset != int64(100+len(chunk)) {
+		t.Errorf("offset = %d, want the end of the log", f.offset)
+	}
+
+	if _, err := d.Parse("api", []byte("garbage\n")); err == nil {
+		t.Error("Parse should reject output without the log header")
+	}
+}
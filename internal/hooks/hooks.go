@@ -0,0 +1,186 @@
+// Package hooks has Claude Code report its state through hooks instead of
+// leaving it to be read off the tmux pane. Install sets up hooks on a
+// Sprite that append every relevant event to a log, and Detector reads
+// that log a little further at each poll.
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Files on the Sprite, relative to the home directory.
+const (
+	// LogFile is where the hook appends events, one JSON object a line.
+	LogFile = ".slua/events.jsonl"
+	// ScriptFile is the hook command itself.
+	ScriptFile = ".slua/claude-hook"
+	// SettingsFile is the Claude Code user settings the hook is added to.
+	SettingsFile = ".claude/settings.json"
+)
+
+// MaxLog is the size past which the hook starts a new log, keeping the
+// previous one as LogFile.1. The detector reads a log that shrank from
+// the start.
+const MaxLog = 1 << 20
+
+// Events are the hooks installed. Stop, Notification and PreToolUse mark
+// finishing, asking and working; UserPromptSubmit and PostToolUse mark
+// work resuming after a new prompt or an approved tool, which would
+// otherwise go unnoticed until the next tool call.
+var Events = []string{"UserPromptSubmit", "PreToolUse", "PostToolUse", "Notification", "Stop"}
+
+// script logs the hook input, which Claude Code passes on stdin, with the
+// time. Only the fields the detector reads are kept, and of the tool input
+// only the first line of its command, file, URL or pattern, so each line
+// is short enough to be appended in a single write and events from hooks
+// running in parallel do not interleave. Values are copied still escaped;
+// cutting one short drops any escape left incomplete. %[1]d is MaxLog.
+const script = `#!/bin/sh
+# Installed by slua: logs Claude Code hook events for its state detection.
+IN=$(tr -d '\n')
+# field KEY MAX prints the string value of KEY, still JSON-escaped, cut to
+# MAX bytes.
+field() {
+  printf '%%s' "$IN" | sed -nE 's/.*"'"$1"'"[[:space:]]*:[[:space:]]*"(([^"\\]|\\.)*)".*/\1/p' |
+    head -c "$2" | sed -E 's/\\u[0-9a-fA-F]{0,3}$//; s/(^|[^\\])((\\\\)*)\\$/\1\2/'
+}
+EVENT=$(field hook_event_name 40)
+LINE="\"hook_event_name\":\"$EVENT\""
+add() {
+  [ -n "$2" ] && LINE="$LINE,\"$1\":\"$2\""
+}
+case "$EVENT" in
+Notification)
+  add notification_type "$(field notification_type 40)"
+  add message "$(field message 300)"
+  ;;
+PreToolUse|PostToolUse)
+  add tool_name "$(field tool_name 80)"
+  for KEY in command file_path url pattern path; do
+    INPUT=$(field "$KEY" 4096 | sed -E 's/^(([^\\]|\\[^n])*)\\n.*/\1 …/' | head -c 200)
+    INPUT=$(printf '%%s' "$INPUT" | sed -E 's/\\u[0-9a-fA-F]{0,3}$//; s/(^|[^\\])((\\\\)*)\\$/\1\2/')
+    if [ -n "$INPUT" ]; then
+      add input "$INPUT"
+      break
+    fi
+  done
+  ;;
+esac
+F="$HOME/` + LogFile + `"
+mkdir -p "$HOME/.slua"
+if [ -f "$F" ] && [ "$(($(wc -c < "$F")))" -gt %[1]d ]; then
+  mv -f "$F" "$F.1"
+fi
+printf '{"at":%%s,"hook":{%%s}}\n' "$(date +%%s)" "$LINE" >> "$F"
+exit 0
+`
+
+// command runs the hook script; it also identifies slua's entries in the
+// settings so installing again replaces them.
+const command = `sh "$HOME/` + ScriptFile + `"`
+
+// writeScript writes $1 to the hook script and $2 to the settings, the
+// settings through a temporary file so Claude Code never reads half.
+const writeScript = `mkdir -p "$HOME/.slua" "$HOME/.claude" &&
+printf '%s' "$1" > "$HOME/` + ScriptFile + `" &&
+printf '%s' "$2" > "$HOME/` + SettingsFile + `.slua-tmp" &&
+mv "$HOME/` + SettingsFile + `.slua-tmp" "$HOME/` + SettingsFile + `"`
+
+// Execer runs commands on Sprites.
+type Execer interface {
+	Exec(ctx context.Context, name string, command []string) (sprites.ExecResult, error)
+}
+
+// Install sets up the hooks on name, keeping whatever else its Claude Code
+// settings hold. Installing again is harmless. A Claude Code session that
+// is already running only picks the hooks up when restarted.
+func Install(ctx context.Context, src Execer, name string) error {
+	res, err := src.Exec(ctx, name, []string{"sh", "-c", `cat "$HOME/` + SettingsFile + `" 2>/dev/null || true`})
+	if err := execErr(res, err); err != nil {
+		return fmt.Errorf("reading Claude Code settings: %w", err)
+	}
+	settings, err := Merge(res.Stdout)
+	if err != nil {
+		return fmt.Errorf("~/%s: %w", SettingsFile, err)
+	}
+	res, err = src.Exec(ctx, name, []string{"sh", "-c", writeScript, "sh", fmt.Sprintf(script, MaxLog), string(settings)})
+	if err := execErr(res, err); err != nil {
+		return fmt.Errorf("writing Claude Code settings: %w", err)
+	}
+	return nil
+}
+
+// Merge adds slua's hooks to Claude Code settings, replacing any it added
+// before and leaving everything else as it was. Empty settings are
+// treated as {}.
+func Merge(settings []byte) ([]byte, error) {
+	doc := map[string]any{}
+	if len(strings.TrimSpace(string(settings))) > 0 {
+		if err := json.Unmarshal(settings, &doc); err != nil {
+			return nil, fmt.Errorf("not a JSON object: %w", err)
+		}
+	}
+	all, ok := doc["hooks"].(map[string]any)
+	if doc["hooks"] != nil && !ok {
+		return nil, errors.New("hooks is not an object")
+	}
+	if all == nil {
+		all = map[string]any{}
+	}
+
+	for _, event := range Events {
+		groups, _ := all[event].([]any)
+		groups = slices.DeleteFunc(slices.Clone(groups), isOurs)
+		group := map[string]any{
+			"hooks": []any{map[string]any{"type": "command", "command": command}},
+		}
+		if event == "PreToolUse" || event == "PostToolUse" {
+			group["matcher"] = "*"
+		}
+		all[event] = append(groups, group)
+	}
+	doc["hooks"] = all
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// isOurs reports whether a settings hook group runs only slua's hook.
+func isOurs(group any) bool {
+	g, _ := group.(map[string]any)
+	list, _ := g["hooks"].([]any)
+	if len(list) == 0 {
+		return false
+	}
+	for _, h := range list {
+		h, _ := h.(map[string]any)
+		if h["command"] != command {
+			return false
+		}
+	}
+	return true
+}
+
+// execErr turns a failed exec or non-zero exit into an error.
+func execErr(res sprites.ExecResult, err error) error {
+	if err != nil {
+		return err
+	}
+	if res.ExitCode != 0 {
+		if msg := strings.TrimSpace(string(res.Stderr)); msg != "" {
+			return errors.New(msg)
+		}
+		return fmt.Errorf("exit code %d", res.ExitCode)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// localExec runs commands on this machine with home as $HOME, standing in
+// for a Sprite.
+type localExec struct {
+	home string
+}
+
+func (l localExec) Exec(ctx context.Context, _ string, argv []string) (sprites.ExecResult, error) {
+	cmd := exec.CommandContext(ctx, argv[0], argv[1:]...)
+	cmd.Env = append(os.Environ(), "HOME="+l.home)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	res := sprites.ExecResult{Stdout: stdout.Bytes(), Stderr: stderr.Bytes()}
+	var exit *exec.ExitError
+	if errors.As(err, &exit) {
+		res.ExitCode, err = exit.ExitCode(), nil
+	}
+	return res, err
+}
+
+// hook runs the installed hook script in home with input on stdin, as
+// Claude Code would.
+func hook(t *testing.T, home, input string) {
+	t.Helper()
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Env = append(os.Environ(), "HOME="+home)
+	cmd.Stdin = strings.NewReader(input)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("hook failed: %v\n%s", err, out)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	existing := `{
+  "model": "opus",
+  "hooks": {
+    "Stop": [{"hooks": [{"type": "command", "command": "notify-send done"}]}]
+  }
+}`
+	once, err := Merge([]byte(existing))
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	twice, err := Merge(once)
+	if err != nil {
+		t.Fatalf("Merge again: %v", err)
+	}
+	if !bytes.Equal(once, twice) {
+		t.Errorf("merging again changed the settings:\n%s\n---\n%s", once, twice)
+	}
+
+	var doc struct {
+		Model string                      `json:"model"`
+		Hooks map[string][]map[string]any `json:"hooks"`
+	}
+	if err := json.Unmarshal(twice, &doc); err != nil {
+		t.Fatalf("merged settings: %v", err)
+	}
+	if doc.Model != "opus" {
+		t.Errorf("model = %q, want it kept", doc.Model)
+	}
+	for _, event := range Events {
+		if n := len(doc.Hooks[event]); (event == "Stop" && n != 2) || (event != "Stop" && n != 1) {
+			t.Errorf("%s has %d hook groups", event, n)
+		}
+	}
+	if doc.Hooks["PreToolUse"][0]["matcher"] != "*" {
+		t.Errorf("PreToolUse should match every tool: %v", doc.Hooks["PreToolUse"])
+	}
+
+	if _, err := Merge(nil); err != nil {
+		t.Errorf("Merge(nil): %v", err)
+	}
+	for _, bad := range []string{"[]", `{"hooks": []}`} {
+		if _, err := Merge([]byte(bad)); err == nil {
+			t.Errorf("Merge(%s) should fail", bad)
+		}
+	}
+}
+
+func TestInstall(t *testing.T) {
+	home := t.TempDir()
+	src := localExec{home: home}
+	settings := filepath.Join(home, SettingsFile)
+	if err := os.MkdirAll(filepath.Dir(settings), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(settings, []byte(`{"model": "opus"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Install(context.Background(), src, "api"); err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	data, _ := os.ReadFile(settings)
+	if !strings.Contains(string(data), `"model": "opus"`) || !strings.Contains(string(data), ScriptFile) {
+		t.Errorf("settings = %s", data)
+	}
+
+	hook(t, home, "{\n  \"hook_event_name\": \"Stop\"\n}\n")
+	log, err := os.ReadFile(filepath.Join(home, LogFile))
+	if err != nil {
+		t.Fatalf("reading log: %v", err)
+	}
+	var e entry
+	if err := json.Unmarshal(bytes.TrimSuffix(log, []byte("\n")), &e); err != nil || e.Hook.Event != "Stop" {
+		t.Errorf("log = %q (%v), want one Stop event", log, err)
+	}
+
+	// Only a short summary of the tool input is kept.
+	content := strings.Repeat("x", 100_000)
+	hook(t, home, `{"hook_event_name": "PreToolUse", "tool_name": "Bash", "tool_input": {"command": "npm test\n\"again\"", "content": "`+content+`"}}`)
+	log, _ = os.ReadFile(filepath.Join(home, LogFile))
+	_, line, _ := bytes.Cut(bytes.TrimSuffix(log, []byte("\n")), []byte("\n"))
+	e = entry{}
+	if err := json.Unmarshal(line, &e); err != nil || e.Hook.ToolName != "Bash" || e.Hook.Input != "npm test …" || len(line) > 200 {
+		t.Errorf("logged %q (%v), want Bash with the first line of its command", line, err)
+	}
+
+	// A log past MaxLog is set aside and a new one started.
+	if err := os.WriteFile(filepath.Join(home, LogFile), bytes.Repeat([]byte("{}\n"), MaxLog/3+1), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hook(t, home, `{"hook_event_name": "Stop"}`)
+	log, _ = os.ReadFile(filepath.Join(home, LogFile))
+	if bytes.Count(log, []byte("\n")) != 1 {
+		t.Errorf("log has %d bytes, want only the new event", len(log))
+	}
+	if _, err := os.Stat(filepath.Join(home, LogFile+".1")); err != nil {
+		t.Errorf("the old log should be kept: %v", err)
+	}
+
+	if err := os.WriteFile(settings, []byte("{oops"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Install(context.Background(), src, "api"); err == nil {
+		t.Error("Install should refuse settings it cannot parse")
+	}
+}
@@ -0,0 +1,159 @@
+// Package logs reads what a Sprite is printing — Claude Code's tmux pane or
+// a service's log — as lines that keep their ANSI colors. It is a light
+// alternative to attaching a console when all you want is to watch.
+package logs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Defaults for reading and following logs.
+const (
+	DefaultLines    = 1000
+	DefaultInterval = 2 * time.Second
+	// ExecTimeout bounds reading the tmux pane.
+	ExecTimeout = 10 * time.Second
+)
+
+// Target is what to read on a Sprite: a service's log, or Claude Code's
+// pane when Service is empty.
+type Target struct {
+	Sprite string
+	// Service is the service ID.
+	Service string
+	// Label names the target for display. Empty means the service ID, or
+	// "claude" for the pane.
+	Label string
+}
+
+func (t Target) String() string {
+	switch {
+	case t.Label != "":
+		return t.Label
+	case t.Service != "":
+		return t.Service
+	default:
+		return "claude"
+	}
+}
+
+// paneScript prints the tmux pane with its scrollback and colors, joining
+// wrapped lines. %d is the number of scrollback lines.
+const paneScript = "tmux capture-pane -p -e -J -S -%d"
+
+// Fetch returns the last n lines of t. Trailing blank lines, which tmux
+// pads the pane with, are dropped.
+func Fetch(ctx context.Context, src sprites.SpriteSource, t Target, n int) ([]string, error) {
+	if n <= 0 {
+		n = DefaultLines
+	}
+
+	var out []byte
+	if t.Service != "" {
+		data, err := src.ServiceLogs(ctx, t.Sprite, t.Service)
+		if err != nil {
+			return nil, err
+		}
+		out = data
+	} else {
+		ctx, cancel := context.WithTimeout(ctx, ExecTimeout)
+		defer cancel()
+		res, err := src.Exec(ctx, t.Sprite, []string{"sh", "-c", fmt.Sprintf(paneScript, n)})
+		if err != nil {
+			return nil, err
+		}
+		if res.ExitCode != 0 {
+			msg := strings.TrimSpace(string(res.Stderr))
+			if msg == "" {
+				msg = fmt.Sprintf("exit code %d", res.ExitCode)
+			}
+			return nil, fmt.Errorf("reading Claude Code pane: %s", msg)
+		}
+		out = res.Stdout
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(out), "\r\n", "\n"), "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, nil
+}
+
+// NewLines returns the lines of next that were not in prev, for printing
+// only what changed between two reads of a scrolling log. It finds where
+// next starts inside prev and returns what follows the overlap; a line
+// redrawn in place counts as new.
+func NewLines(prev, next []string) []string {
+	best := 0
+	for i := range prev {
+		m := 0
+		for i+m < len(prev) && m < len(next) && prev[i+m] == next[m] {
+			m++
+		}
+		best = max(best, m)
+		if m > 0 && i+m == len(prev) {
+			// next continues prev from here; later starts overlap less.
+			break
+		}
+	}
+	return next[best:]
+}
+
+// ResolveService returns the target for the service on sprite whose ID or
+// name is ref.
+func ResolveService(ctx context.Context, src sprites.SpriteSource, sprite, ref string) (Target, error) {
+	services, err := src.ListServices(ctx, sprite)
+	if err != nil {
+		return Target{}, err
+	}
+	for _, s := range services {
+		if s.ID == ref || s.Name == ref {
+			return Target{Sprite: sprite, Service: s.ID, Label: s.Label()}, nil
+		}
+	}
+	return Target{}, fmt.Errorf("no service %q on %s", ref, sprite)
+}
+
+// Follow emits the last n lines of t, then every interval the lines added
+// since, until ctx is done. Errors after the first read are passed to
+// onErr and following continues.
+func Follow(ctx context.Context, src sprites.SpriteSource, t Target, n int, interval time.Duration, emit func([]string), onErr func(error)) error {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	prev, err := Fetch(ctx, src, t, n)
+	if err != nil {
+		return err
+	}
+	emit(prev)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		next, err := Fetch(ctx, src, t, n)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				onErr(err)
+			}
+			continue
+		}
+		if added := NewLines(prev, next); len(added) > 0 {
+			emit(added)
+		}
+		prev = next
+	}
+}
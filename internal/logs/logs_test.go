@@ -0,0 +1,83 @@
+package logs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// fakeSource serves a fixed pane and service log. Other SpriteSource
+// methods are not used.
+type fakeSource struct {
+	sprites.SpriteSource
+	pane    string
+	logs    string
+	command string
+}
+
+func (f *fakeSource) Exec(_ context.Context, _ string, command []string) (sprites.ExecResult, error) {
+	f.command = command[len(command)-1]
+	return sprites.ExecResult{Stdout: []byte(f.pane)}, nil
+}
+
+func (f *fakeSource) ServiceLogs(context.Context, string, string) ([]byte, error) {
+	return []byte(f.logs), nil
+}
+
+func (f *fakeSource) ListServices(context.Context, string) ([]sprites.Service, error) {
+	return []sprites.Service{{ID: "svc-1", Name: "web"}}, nil
+}
+
+func TestFetch(t *testing.T) {
+	src := &fakeSource{
+		pane: "\x1b[32m✓\x1b[0m tests pass\r\none\ntwo\n\n   \n",
+		logs: "listening on :3000\n",
+	}
+	lines, err := Fetch(context.Background(), src, Target{Sprite: "w"}, 2)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if strings.Join(lines, "|") != "one|two" {
+		t.Errorf("pane lines = %q, want the last 2 without padding", lines)
+	}
+	if !strings.Contains(src.command, "capture-pane -p -e -J -S -2") {
+		t.Errorf("command = %q, want colors kept", src.command)
+	}
+
+	lines, _ = Fetch(context.Background(), src, Target{Sprite: "w"}, 10)
+	if lines[0] != "\x1b[32m✓\x1b[0m tests pass" {
+		t.Errorf("first line = %q, want ANSI kept and CR dropped", lines[0])
+	}
+
+	target, err := ResolveService(context.Background(), src, "w", "web")
+	if err != nil || target.Service != "svc-1" || target.String() != "web" {
+		t.Fatalf("ResolveService = %+v, %v", target, err)
+	}
+	lines, _ = Fetch(context.Background(), src, target, 0)
+	if strings.Join(lines, "|") != "listening on :3000" {
+		t.Errorf("service lines = %q", lines)
+	}
+}
+
+func TestNewLines(t *testing.T) {
+	tests := []struct {
+		name       string
+		prev, next string
+		want       string
+	}{
+		{"appended", "a b c", "a b c d e", "d e"},
+		{"scrolled", "a b c", "b c d", "d"},
+		{"unchanged", "a b c", "a b c", ""},
+		{"redrawn last line", "a b c", "a b x", "x"},
+		{"no overlap", "a b", "x y", "x y"},
+		{"first read", "", "a b", "a b"},
+	}
+	for _, tt := range tests {
+		got := NewLines(strings.Fields(tt.prev), strings.Fields(tt.next))
+		if strings.Join(got, " ") != tt.want {
+			t.Errorf("%s: NewLines = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package notify
+
+// barCapacity is how many events a Bar remembers.
+const barCapacity = 10
+
+// Bar is the FIFO of recent events shown in the dashboard's notification
+// bar. The zero value is ready to use.
+type Bar struct {
+	events []Event // oldest first
+}
+
+// Push appends events, skipping any already present, and drops the oldest
+// beyond capacity.
+func (b *Bar) Push(events ...Event) {
+	for _, e := range events {
+		if b.contains(e) {
+			continue
+		}
+		b.events = append(b.events, e)
+	}
+	if over := len(b.events) - barCapacity; over > 0 {
+		b.events = append([]Event(nil), b.events[over:]...)
+	}
+}
+
+// Recent returns up to n events, newest first.
+func (b *Bar) Recent(n int) []Event {
+	out := make([]Event, 0, n)
+	for i := len(b.events) - 1; i >= 0 && len(out) < n; i-- {
+		out = append(out, b.events[i])
+	}
+	return out
+}
+
+// Dismiss removes every event for the named Sprite, e.g. once the user
+// has connected to it.
+func (b *Bar) Dismiss(sprite string) {
+	var kept []Event
+	for _, e := range b.events {
+		if e.Sprite != sprite {
+			kept = append(kept, e)
+		}
+	}
+	b.events = kept
+}
+
+// Len returns the number of events held.
+func (b *Bar) Len() int {
+	return len(b.events)
+}
+
+func (b *Bar) contains(e Event) bool {
+	for _, have := range b.events {
+		if have.Sprite == e.Sprite && have.To == e.To && have.At.Equal(e.At) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Command runs a shell command for every event. The event is passed in
+// the environment:
+//
+//	SLUA_SPRITE    Sprite name
+//	SLUA_STATUS    new status
+//	SLUA_PREVIOUS  previous status
+//	SLUA_DETAIL    prompt or failure reason, may be empty
+//	SLUA_TITLE     one-line summary
+//	SLUA_MESSAGE   detail line
+//	SLUA_TIME      RFC 3339 timestamp
+type Command struct {
+	// Line is run with sh -c.
+	Line string
+}
+
+// Notify runs the hook and waits for it to exit.
+func (c Command) Notify(ctx context.Context, e Event) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Line)
+	cmd.Env = append(os.Environ(), commandEnv(e)...)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("notify command: %s", msg)
+	}
+	return nil
+}
+
+func commandEnv(e Event) []string {
+	return []string{
+		"SLUA_SPRITE=" + e.Sprite,
+		"SLUA_STATUS=" + e.To,
+		"SLUA_PREVIOUS=" + e.From,
+		"SLUA_DETAIL=" + e.Detail,
+		"SLUA_TITLE=" + e.Title(),
+		"SLUA_MESSAGE=" + e.Body(),
+		"SLUA_TIME=" + e.At.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// ErrNoDesktop is returned when neither notify-send nor gdbus is installed.
+var ErrNoDesktop = errors.New("no desktop notifier found (install notify-send or gdbus)")
+
+// Desktop shows a freedesktop notification, using notify-send when it is
+// installed and calling the D-Bus service through gdbus otherwise.
+type Desktop struct {
+	// AppName is shown as the notification's source. Empty means "slua".
+	AppName string
+}
+
+// Notify shows e as a desktop notification.
+func (d Desktop) Notify(ctx context.Context, e Event) error {
+	name, args, err := desktopCommand(d.appName(), e, exec.LookPath)
+	if err != nil {
+		return err
+	}
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return fmt.Errorf("%s: %s", name, msg)
+	}
+	return nil
+}
+
+func (d Desktop) appName() string {
+	if d.AppName == "" {
+		return "slua"
+	}
+	return d.AppName
+}
+
+// DesktopAvailable reports whether Desktop has a tool to deliver with.
+func DesktopAvailable() bool {
+	_, _, err := desktopCommand("slua", Event{}, exec.LookPath)
+	return err == nil
+}
+
+// desktopCommand returns the command that shows e, preferring notify-send.
+func desktopCommand(app string, e Event, lookPath func(string) (string, error)) (string, []string, error) {
+	urgency := "normal"
+	if e.To == sprites.StatusError {
+		urgency = "critical"
+	}
+
+	if _, err := lookPath("notify-send"); err == nil {
+		return "notify-send", []string{"-a", app, "-u", urgency, e.Title(), e.Body()}, nil
+	}
+	if _, err := lookPath("gdbus"); err == nil {
+		return "gdbus", []string{
+			"call", "--session",
+			"--dest", "org.freedesktop.Notifications",
+			"--object-path", "/org/freedesktop/Notifications",
+			"--method", "org.freedesktop.Notifications.Notify",
+			gvariantString(app), "0", "''",
+			gvariantString(e.Title()), gvariantString(e.Body()),
+			"[]", "{}", "-1",
+		}, nil
+	}
+	return "", nil, ErrNoDesktop
+}
+
+// gvariantString quotes s as a GVariant text-format string for gdbus.
+func gvariantString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
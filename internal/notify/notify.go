@@ -0,0 +1,251 @@
+// Package notify delivers alerts when a Sprite's Claude Code state changes.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Defaults for Notifier fields.
+const (
+	DefaultDedupWindow  = 30 * time.Second
+	DefaultMaxPerMinute = 6
+	// SinkTimeout bounds a single delivery to one sink.
+	SinkTimeout = 10 * time.Second
+)
+
+// DefaultStates are the statuses that trigger a notification.
+var DefaultStates = []string{sprites.StatusWaiting, sprites.StatusError}
+
+// Event is a Sprite status change worth telling the user about.
+type Event struct {
+	Sprite string `json:"sprite"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	// Detail is the WAITING prompt or the failure reason, if known.
+	Detail string    `json:"detail,omitempty"`
+	At     time.Time `json:"at"`
+}
+
+// FromTransition builds an Event from a poller transition and the result
+// that produced it.
+func FromTransition(t poller.Transition, r poller.Result) Event {
+	return Event{Sprite: t.Name, From: t.From, To: t.To, Detail: r.Detail, At: t.At}
+}
+
+// Title is a one-line summary like "web-app needs attention".
+func (e Event) Title() string {
+	switch e.To {
+	case sprites.StatusWaiting:
+		return e.Sprite + " needs attention"
+	case sprites.StatusError:
+		return e.Sprite + " errored"
+	case sprites.StatusFinished:
+		return e.Sprite + " finished"
+	case sprites.StatusUnreachable:
+		return e.Sprite + " is unreachable"
+	default:
+		return fmt.Sprintf("%s is %s", e.Sprite, e.To)
+	}
+}
+
+// Body is the detail line shown under the title.
+func (e Event) Body() string {
+	if e.Detail != "" {
+		return e.Detail
+	}
+	return fmt.Sprintf("%s → %s", e.From, e.To)
+}
+
+// Sink delivers an Event somewhere: the terminal, the desktop, a hook.
+type Sink interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// Notifier filters, deduplicates and rate-limits events before fanning
+// them out to its sinks. It is safe for concurrent use.
+type Notifier struct {
+	Sinks []Sink
+	// States lists the statuses that notify when entered.
+	States []string
+	// DedupWindow drops an event if the same Sprite entered the same
+	// status this recently.
+	DedupWindow time.Duration
+	// MaxPerMinute caps deliveries across all Sprites. Zero means no limit.
+	// Events over the limit are dropped, not delayed.
+	MaxPerMinute int
+
+	mu        sync.Mutex
+	now       func() time.Time
+	suspended bool
+	queued    []Event
+	seen      map[string]time.Time // last event per Sprite and status
+	sent      []time.Time          // deliveries in the last minute
+	err       error                // most recent delivery failure
+}
+
+// New creates a Notifier with default settings.
+func New(sinks ...Sink) *Notifier {
+	return &Notifier{
+		Sinks:        sinks,
+		States:       DefaultStates,
+		DedupWindow:  DefaultDedupWindow,
+		MaxPerMinute: DefaultMaxPerMinute,
+		now:          time.Now,
+		seen:         make(map[string]time.Time),
+	}
+}
+
+// Wants reports whether entering status triggers a notification.
+func (n *Notifier) Wants(status string) bool {
+	for _, s := range n.States {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Notify delivers e to every sink unless it is filtered, a duplicate or
+// over the rate limit. While suspended, accepted events are queued for
+// Resume instead of going to the bell and the desktop; other sinks still
+// get them. Delivery errors are returned and also kept for Err.
+func (n *Notifier) Notify(ctx context.Context, e Event) error {
+	if !n.Wants(e.To) {
+		return nil
+	}
+	send, suspended := n.admit(e)
+	if !send {
+		return nil
+	}
+
+	// Sinks run in parallel so a slow webhook does not delay the bell.
+	errs := make([]error, len(n.Sinks))
+	var wg sync.WaitGroup
+	for i, s := range n.Sinks {
+		if suspended && local(s) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sctx, cancel := context.WithTimeout(ctx, SinkTimeout)
+			defer cancel()
+			errs[i] = s.Notify(sctx, e)
+		}()
+	}
+	wg.Wait()
+	return n.record(errors.Join(errs...))
+}
+
+// Flush retries deliveries left over from earlier runs by sinks that keep
+// an Outbox. Errors are returned and also kept for Err.
+func (n *Notifier) Flush(ctx context.Context) error {
+	var errs []error
+	for _, s := range n.Sinks {
+		if o, ok := s.(*Outbox); ok {
+			errs = append(errs, o.Flush(ctx))
+		}
+	}
+	return n.record(errors.Join(errs...))
+}
+
+// record keeps err for Err and returns it.
+func (n *Notifier) record(err error) error {
+	if err != nil {
+		n.mu.Lock()
+		n.err = err
+		n.mu.Unlock()
+	}
+	return err
+}
+
+// NotifyCycle sends an event for every transition in c without blocking
+// the caller. It is meant to be registered with poller.Observe.
+func (n *Notifier) NotifyCycle(ctx context.Context, c poller.Cycle) {
+	for _, t := range c.Transitions() {
+		if n.Wants(t.To) {
+			go n.Notify(ctx, FromTransition(t, c.Results[t.Name]))
+		}
+	}
+}
+
+// local reports whether s alerts on this machine's terminal or desktop,
+// which a console session has the use of.
+func local(s Sink) bool {
+	switch s.(type) {
+	case Bell, *Bell, Desktop, *Desktop:
+		return true
+	}
+	return false
+}
+
+// admit applies deduplication, suspension and the rate limit. It reports
+// whether e should go to the sinks now, and whether deliveries are
+// suspended, in which case e was queued for Resume.
+func (n *Notifier) admit(e Event) (send, suspended bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := n.now()
+
+	key := e.Sprite + "\x00" + e.To
+	if last, ok := n.seen[key]; ok && now.Sub(last) < n.DedupWindow {
+		return false, false
+	}
+	n.seen[key] = now
+
+	if n.suspended {
+		n.queued = append(n.queued, e)
+	}
+
+	if n.MaxPerMinute > 0 {
+		recent := n.sent[:0]
+		for _, t := range n.sent {
+			if now.Sub(t) < time.Minute {
+				recent = append(recent, t)
+			}
+		}
+		n.sent = recent
+		if len(n.sent) >= n.MaxPerMinute {
+			return false, n.suspended
+		}
+		n.sent = append(n.sent, now)
+	}
+	return true, n.suspended
+}
+
+// Suspend holds back the bell and desktop notifications, for example
+// while a console has the terminal. Events are queued until Resume, and
+// other sinks such as ntfy and webhooks get them meanwhile.
+func (n *Notifier) Suspend() {
+	n.mu.Lock()
+	n.suspended = true
+	n.mu.Unlock()
+}
+
+// Resume re-enables deliveries and returns the events queued while
+// suspended, oldest first. Queued events are not sent to the bell or the
+// desktop.
+func (n *Notifier) Resume() []Event {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.suspended = false
+	q := n.queued
+	n.queued = nil
+	return q
+}
+
+// Err returns the most recent delivery failure and clears it.
+func (n *Notifier) Err() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	err := n.err
+	n.err = nil
+	return err
+}
@@ -0,0 +1,241 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// recordingSink remembers every event it is given.
+type recordingSink struct {
+	mu     sync.Mutex
+	events []Event
+	err    error
+}
+
+func (r *recordingSink) Notify(_ context.Context, e Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, e)
+	return r.err
+}
+
+func (r *recordingSink) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+// testNotifier returns a Notifier with a controllable clock.
+func testNotifier(sinks ...Sink) (*Notifier, *time.Time) {
+	n := New(sinks...)
+	now := time.Date(2026, 2, 5, 12, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return now }
+	return n, &now
+}
+
+func waiting(name string) Event {
+	return Event{Sprite: name, From: sprites.StatusWorking, To: sprites.StatusWaiting}
+}
+
+func TestNotify_FiltersStates(t *testing.T) {
+	sink := &recordingSink{}
+	n, _ := testNotifier(sink)
+	ctx := context.Background()
+
+	n.Notify(ctx, Event{Sprite: "a", From: sprites.StatusWaiting, To: sprites.StatusWorking})
+	n.Notify(ctx, waiting("a"))
+	n.Notify(ctx, Event{Sprite: "b", To: sprites.StatusError, Detail: "exit code 1"})
+
+	if sink.count() != 2 {
+		t.Errorf("delivered %d events, want 2 (WAITING and ERROR only)", sink.count())
+	}
+}
+
+func TestNotify_Dedup(t *testing.T) {
+	sink := &recordingSink{}
+	n, now := testNotifier(sink)
+	ctx := context.Background()
+
+	n.Notify(ctx, waiting("a"))
+	*now = now.Add(10 * time.Second)
+	n.Notify(ctx, waiting("a"))
+	n.Notify(ctx, waiting("b"))
+	if sink.count() != 2 {
+		t.Fatalf("delivered %d, want repeat for a suppressed", sink.count())
+	}
+
+	*now = now.Add(DefaultDedupWindow)
+	n.Notify(ctx, waiting("a"))
+	if sink.count() != 3 {
+		t.Errorf("delivered %d, want a again after the dedup window", sink.count())
+	}
+}
+
+func TestNotify_RateLimit(t *testing.T) {
+	sink := &recordingSink{}
+	n, now := testNotifier(sink)
+	n.MaxPerMinute = 2
+	ctx := context.Background()
+
+	for _, name := range []string{"a", "b", "c"} {
+		n.Notify(ctx, waiting(name))
+	}
+	if sink.count() != 2 {
+		t.Fatalf("delivered %d, want 2 within a minute", sink.count())
+	}
+
+	*now = now.Add(time.Minute)
+	n.Notify(ctx, waiting("d"))
+	if sink.count() != 3 {
+		t.Errorf("delivered %d, want limit to reset after a minute", sink.count())
+	}
+}
+
+func TestNotify_SuspendQueues(t *testing.T) {
+	sink := &recordingSink{}
+	var bell bytes.Buffer
+	n, _ := testNotifier(sink, Bell{W: &bell})
+	ctx := context.Background()
+
+	n.Suspend()
+	n.Notify(ctx, waiting("a"))
+	n.Notify(ctx, Event{Sprite: "b", To: sprites.StatusError})
+	if bell.Len() != 0 {
+		t.Fatalf("rang the bell %d times while suspended", bell.Len())
+	}
+	// Pushes leave the machine, so they go out regardless.
+	if sink.count() != 2 {
+		t.Fatalf("delivered %d to the push sink while suspended, want 2", sink.count())
+	}
+
+	queued := n.Resume()
+	if len(queued) != 2 || queued[0].Sprite != "a" || queued[1].Sprite != "b" {
+		t.Errorf("queued = %+v", queued)
+	}
+	if len(n.Resume()) != 0 {
+		t.Errorf("queue should be drained by Resume")
+	}
+
+	n.Notify(ctx, waiting("c"))
+	if bell.Len() != 1 || sink.count() != 3 {
+		t.Errorf("delivery should resume, got %d bells and %d pushes", bell.Len(), sink.count())
+	}
+}
+
+func TestNotify_ErrorsAreKept(t *testing.T) {
+	failing := &recordingSink{err: errors.New("boom")}
+	ok := &recordingSink{}
+	n, _ := testNotifier(failing, ok)
+
+	err := n.Notify(context.Background(), waiting("a"))
+	if err == nil || ok.count() != 1 {
+		t.Fatalf("err=%v delivered=%d; a failing sink must not block the others", err, ok.count())
+	}
+	if got := n.Err(); got == nil || !strings.Contains(got.Error(), "boom") {
+		t.Errorf("Err() = %v", got)
+	}
+	if n.Err() != nil {
+		t.Errorf("Err should clear after reading")
+	}
+}
+
+func TestBell(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (Bell{W: &buf}).Notify(context.Background(), waiting("a")); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "\a" {
+		t.Errorf("wrote %q, want BEL", buf.String())
+	}
+}
+
+func TestCommand_PassesEventInEnv(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	c := Command{Line: `printf '%s|%s|%s' "$SLUA_SPRITE" "$SLUA_STATUS" "$SLUA_TITLE" > ` + out}
+
+	if err := c.Notify(context.Background(), waiting("web-app")); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(data); got != "web-app|WAITING|web-app needs attention" {
+		t.Errorf("hook saw %q", got)
+	}
+}
+
+func TestCommand_Failure(t *testing.T) {
+	err := Command{Line: "echo nope >&2; exit 3"}.Notify(context.Background(), waiting("a"))
+	if err == nil || !strings.Contains(err.Error(), "nope") {
+		t.Errorf("err = %v, want stderr in message", err)
+	}
+}
+
+func TestDesktopCommand(t *testing.T) {
+	only := func(names ...string) func(string) (string, error) {
+		return func(file string) (string, error) {
+			for _, n := range names {
+				if n == file {
+					return "/usr/bin/" + file, nil
+				}
+			}
+			return "", errors.New("not found")
+		}
+	}
+	e := Event{Sprite: "api", To: sprites.StatusError, Detail: "it's broken"}
+
+	name, args, err := desktopCommand("slua", e, only("notify-send", "gdbus"))
+	if err != nil || name != "notify-send" {
+		t.Fatalf("got %s %v, want notify-send", name, err)
+	}
+	if strings.Join(args, " ") != "-a slua -u critical api errored it's broken" {
+		t.Errorf("notify-send args = %q", args)
+	}
+
+	name, args, err = desktopCommand("slua", e, only("gdbus"))
+	if err != nil || name != "gdbus" {
+		t.Fatalf("got %s %v, want gdbus fallback", name, err)
+	}
+	if !strings.Contains(strings.Join(args, " "), `'api errored' 'it\'s broken'`) {
+		t.Errorf("gdbus args not GVariant-quoted: %q", args)
+	}
+
+	if _, _, err := desktopCommand("slua", e, only()); !errors.Is(err, ErrNoDesktop) {
+		t.Errorf("err = %v, want ErrNoDesktop", err)
+	}
+}
+
+func TestBar(t *testing.T) {
+	var b Bar
+	at := time.Now()
+	b.Push(Event{Sprite: "a", To: sprites.StatusWaiting, At: at})
+	b.Push(Event{Sprite: "a", To: sprites.StatusWaiting, At: at}) // duplicate
+	b.Push(Event{Sprite: "b", To: sprites.StatusError, At: at.Add(time.Second)})
+
+	recent := b.Recent(2)
+	if len(recent) != 2 || recent[0].Sprite != "b" || recent[1].Sprite != "a" {
+		t.Errorf("Recent = %+v, want newest first without duplicates", recent)
+	}
+
+	b.Dismiss("a")
+	if b.Len() != 1 {
+		t.Errorf("Len after Dismiss = %d", b.Len())
+	}
+
+	for i := 0; i < barCapacity+5; i++ {
+		b.Push(Event{Sprite: "c", At: at.Add(time.Duration(i) * time.Minute)})
+	}
+	if b.Len() != barCapacity {
+		t.Errorf("Len = %d, want capped at %d", b.Len(), barCapacity)
+	}
+}
@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Ntfy publishes events to an ntfy topic, e.g. https://ntfy.sh/my-sprites,
+// for push notifications on a phone.
+type Ntfy struct {
+	// URL is the full topic URL.
+	URL string
+	// Token is sent as a bearer token for protected topics. Optional.
+	Token string
+	// HTTPClient is used for requests. Nil means a client with SinkTimeout.
+	HTTPClient *http.Client
+}
+
+// Notify publishes e with its title, priority and tags set from the status.
+func (n Ntfy) Notify(ctx context.Context, e Event) error {
+	h := http.Header{}
+	h.Set("Title", e.Title())
+	h.Set("Content-Type", "text/plain; charset=utf-8")
+	priority, tags := ntfyStyle(e.To)
+	h.Set("Priority", priority)
+	h.Set("Tags", strings.Join(tags, ","))
+	if n.Token != "" {
+		h.Set("Authorization", "Bearer "+n.Token)
+	}
+	return post(ctx, n.HTTPClient, n.URL, h, []byte(e.Body()))
+}
+
+// ntfyStyle maps a status to an ntfy priority and emoji tags.
+func ntfyStyle(status string) (string, []string) {
+	switch status {
+	case sprites.StatusWaiting:
+		return "high", []string{"raised_hand"}
+	case sprites.StatusError:
+		return "high", []string{"x"}
+	case sprites.StatusFinished:
+		return "default", []string{"white_check_mark"}
+	case sprites.StatusUnreachable:
+		return "default", []string{"warning"}
+	default:
+		return "default", nil
+	}
+}
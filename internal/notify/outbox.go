@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for Outbox retries.
+const (
+	DefaultAttempts = 3
+	DefaultBackoff  = time.Second
+)
+
+// Outbox makes a sink durable. Each event is written to Dir before
+// delivery and removed once the sink accepts or permanently rejects it,
+// so alerts still pending when slua exits are sent by Flush on the next
+// start. Events are delivered in order.
+type Outbox struct {
+	Sink Sink
+	// Dir holds one JSON file per pending event.
+	Dir string
+	// Attempts is how many times each delivery is tried before it is left
+	// for the next Flush.
+	Attempts int
+	// Backoff is the wait before the first retry. It doubles each time.
+	Backoff time.Duration
+
+	mu  sync.Mutex // one flush at a time, to keep order
+	seq int
+}
+
+// NewOutbox wraps sink with an outbox in dir using default retries.
+func NewOutbox(dir string, sink Sink) *Outbox {
+	return &Outbox{Sink: sink, Dir: dir, Attempts: DefaultAttempts, Backoff: DefaultBackoff}
+}
+
+// Notify stores e and delivers it along with anything still pending.
+func (o *Outbox) Notify(ctx context.Context, e Event) error {
+	if err := o.save(e); err != nil {
+		// Better to try once without durability than to drop the alert.
+		return errors.Join(err, o.Sink.Notify(ctx, e))
+	}
+	return o.Flush(ctx)
+}
+
+// Flush delivers pending events oldest first. It stops at the first event
+// that still fails after retries so later events are not sent out of
+// order; rejected events are discarded and reported.
+func (o *Outbox) Flush(ctx context.Context) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	paths, err := o.pending()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, path := range paths {
+		e, err := loadEvent(path)
+		if err != nil {
+			// Unreadable entries would block the queue forever.
+			os.Remove(path)
+			errs = append(errs, err)
+			continue
+		}
+
+		err = retry(ctx, o.Attempts, o.Backoff, func() error {
+			return o.Sink.Notify(ctx, e)
+		})
+		if ctx.Err() != nil {
+			// Stopped before finishing: keep the event for next time.
+			return errors.Join(append(errs, err, ctx.Err())...)
+		}
+		if err != nil && retryable(err) {
+			return errors.Join(append(errs, err)...)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		os.Remove(path)
+	}
+	return errors.Join(errs...)
+}
+
+// Pending returns the number of undelivered events.
+func (o *Outbox) Pending() int {
+	paths, _ := o.pending()
+	return len(paths)
+}
+
+func (o *Outbox) pending() ([]string, error) {
+	entries, err := os.ReadDir(o.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading outbox: %w", err)
+	}
+
+	var paths []string
+	for _, ent := range entries {
+		if !ent.IsDir() && strings.HasSuffix(ent.Name(), ".json") {
+			paths = append(paths, filepath.Join(o.Dir, ent.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// save writes e to a new file whose name sorts by arrival.
+func (o *Outbox) save(e Event) error {
+	if err := os.MkdirAll(o.Dir, 0o700); err != nil {
+		return fmt.Errorf("creating outbox: %w", err)
+	}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+
+	o.mu.Lock()
+	o.seq++
+	name := fmt.Sprintf("%020d-%06d.json", time.Now().UnixNano(), o.seq)
+	o.mu.Unlock()
+
+	tmp := filepath.Join(o.Dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing outbox: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(o.Dir, name)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("writing outbox: %w", err)
+	}
+	return nil
+}
+
+func loadEvent(path string) (Event, error) {
+	var e Event
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return e, fmt.Errorf("reading outbox entry: %w", err)
+	}
+	if err := json.Unmarshal(data, &e); err != nil {
+		return e, fmt.Errorf("decoding outbox entry %s: %w", filepath.Base(path), err)
+	}
+	return e, nil
+}
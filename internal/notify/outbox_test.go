@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+func TestOutbox_SurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	var up atomic.Bool
+	srv, got := newCaptureServer(t, func(int32) int {
+		if up.Load() {
+			return http.StatusOK
+		}
+		return http.StatusBadGateway
+	})
+	sink := Webhook{URL: srv.URL, HTTPClient: srv.Client()}
+
+	first := NewOutbox(dir, sink)
+	first.Backoff = time.Millisecond
+	ctx := context.Background()
+	if err := first.Notify(ctx, Event{Sprite: "a", To: sprites.StatusWaiting}); err == nil {
+		t.Fatal("expected delivery to fail while the endpoint is down")
+	}
+	first.Notify(ctx, Event{Sprite: "b", To: sprites.StatusWaiting})
+	if first.Pending() != 2 {
+		t.Fatalf("pending = %d, want both events kept", first.Pending())
+	}
+
+	// A new process picks up where the old one left off.
+	up.Store(true)
+	got.calls.Store(0)
+	second := NewOutbox(dir, sink)
+	if err := second.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if second.Pending() != 0 {
+		t.Errorf("pending = %d after flush", second.Pending())
+	}
+	if got.calls.Load() != 2 {
+		t.Errorf("delivered %d events, want 2", got.calls.Load())
+	}
+	if !strings.Contains(got.body, "b needs attention") {
+		t.Errorf("last delivered = %q, want b (order preserved)", got.body)
+	}
+}
+
+func TestOutbox_DropsRejectedEvents(t *testing.T) {
+	dir := t.TempDir()
+	srv, _ := newCaptureServer(t, func(int32) int { return http.StatusNotFound })
+	o := NewOutbox(dir, Webhook{URL: srv.URL, HTTPClient: srv.Client()})
+
+	if err := o.Notify(context.Background(), Event{Sprite: "a"}); err == nil {
+		t.Fatal("expected the 404 to be reported")
+	}
+	if o.Pending() != 0 {
+		t.Errorf("a permanently rejected event should not stay queued")
+	}
+}
+
+func TestOutbox_KeepsEventsWhenCancelled(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Shut down while the alert is in flight.
+		cancel()
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+	o := NewOutbox(dir, Webhook{URL: srv.URL, HTTPClient: srv.Client()})
+
+	if err := o.Notify(ctx, Event{Sprite: "a", To: sprites.StatusWaiting}); err == nil {
+		t.Fatal("expected the cancelled delivery to be reported")
+	}
+	if o.Pending() != 1 {
+		t.Errorf("pending = %d, want the event kept for the next run", o.Pending())
+	}
+}
+
+func TestOutbox_DropsEventsThatCannotBeSent(t *testing.T) {
+	sink := &recordingSink{err: errors.New("webhook template: bad field")}
+	o := NewOutbox(t.TempDir(), sink)
+	o.Backoff = time.Millisecond
+
+	if err := o.Notify(context.Background(), Event{Sprite: "a"}); err == nil {
+		t.Fatal("expected the failure to be reported")
+	}
+	if sink.count() != 1 || o.Pending() != 0 {
+		t.Errorf("attempts=%d pending=%d; a payload that cannot be built should not be retried or block the queue", sink.count(), o.Pending())
+	}
+}
+
+func TestOutbox_SkipsCorruptEntries(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "0001.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sink := &recordingSink{}
+	o := NewOutbox(dir, sink)
+
+	o.Notify(context.Background(), Event{Sprite: "a"})
+	if sink.count() != 1 || o.Pending() != 0 {
+		t.Errorf("delivered=%d pending=%d; corrupt entry should not block the queue", sink.count(), o.Pending())
+	}
+}
+
+func TestNotifier_FlushesOutboxes(t *testing.T) {
+	dir := t.TempDir()
+	sink := &recordingSink{}
+	o := NewOutbox(dir, sink)
+	if err := o.save(Event{Sprite: "left-over", To: sprites.StatusError}); err != nil {
+		t.Fatal(err)
+	}
+
+	n := New(Bell{W: io.Discard}, o)
+	if err := n.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if sink.count() != 1 || sink.events[0].Sprite != "left-over" {
+		t.Errorf("events = %+v", sink.events)
+	}
+}
@@ -0,0 +1,27 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Bell rings the terminal bell.
+type Bell struct {
+	// W receives the BEL character. Nil means os.Stderr, which reaches the
+	// terminal without interfering with the dashboard's stdout rendering.
+	W io.Writer
+}
+
+// Notify writes a BEL character.
+func (b Bell) Notify(_ context.Context, _ Event) error {
+	w := b.W
+	if w == nil {
+		w = os.Stderr
+	}
+	if _, err := io.WriteString(w, "\a"); err != nil {
+		return fmt.Errorf("terminal bell: %w", err)
+	}
+	return nil
+}
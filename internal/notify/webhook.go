@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"text/template"
+)
+
+// Webhook POSTs events to an arbitrary URL. Without a template the body is
+// a Slack-compatible {"text": "..."} payload.
+type Webhook struct {
+	URL string
+	// Template is a text/template for the request body, executed with the
+	// Event. It can use .Sprite, .From, .To, .Detail, .At, .Title, .Body
+	// and a json function that quotes a value, e.g.
+	//
+	//	{"content": {{json .Title}}}
+	Template string
+	// Headers are added to every request.
+	Headers map[string]string
+	// HTTPClient is used for requests. Nil means a client with SinkTimeout.
+	HTTPClient *http.Client
+}
+
+// Notify renders the payload and sends it.
+func (w Webhook) Notify(ctx context.Context, e Event) error {
+	body, err := w.payload(e)
+	if err != nil {
+		return err
+	}
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		h.Set(k, v)
+	}
+	return post(ctx, w.HTTPClient, w.URL, h, body)
+}
+
+func (w Webhook) payload(e Event) ([]byte, error) {
+	if w.Template == "" {
+		return json.Marshal(map[string]string{
+			"text": fmt.Sprintf("*%s*\n%s", e.Title(), e.Body()),
+		})
+	}
+	tmpl, err := ParseWebhookTemplate(w.Template)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, e); err != nil {
+		return nil, fmt.Errorf("webhook template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// ParseWebhookTemplate parses a Webhook.Template.
+func ParseWebhookTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("webhook").Funcs(template.FuncMap{
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("webhook template: %w", err)
+	}
+	return tmpl, nil
+}
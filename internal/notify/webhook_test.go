@@ -0,0 +1,140 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// capture records the last request an httptest server received.
+type capture struct {
+	header http.Header
+	body   string
+	calls  atomic.Int32
+}
+
+func newCaptureServer(t *testing.T, status func(call int32) int) (*httptest.Server, *capture) {
+	t.Helper()
+	c := &capture{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := c.calls.Add(1)
+		data, _ := io.ReadAll(r.Body)
+		c.header = r.Header.Clone()
+		c.body = string(data)
+		if status != nil {
+			w.WriteHeader(status(n))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, c
+}
+
+func TestNtfy(t *testing.T) {
+	srv, got := newCaptureServer(t, nil)
+	sink := Ntfy{URL: srv.URL + "/sprites", Token: "tk", HTTPClient: srv.Client()}
+
+	e := Event{Sprite: "web", To: sprites.StatusWaiting, Detail: "Allow edit? (y/n)"}
+	if err := sink.Notify(context.Background(), e); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if got.body != "Allow edit? (y/n)" {
+		t.Errorf("body = %q", got.body)
+	}
+	for k, want := range map[string]string{
+		"Title":         "web needs attention",
+		"Priority":      "high",
+		"Tags":          "raised_hand",
+		"Authorization": "Bearer tk",
+	} {
+		if v := got.header.Get(k); v != want {
+			t.Errorf("%s = %q, want %q", k, v, want)
+		}
+	}
+}
+
+func TestWebhook_SlackPayload(t *testing.T) {
+	srv, got := newCaptureServer(t, nil)
+	sink := Webhook{URL: srv.URL, Headers: map[string]string{"X-Team": "infra"}, HTTPClient: srv.Client()}
+
+	e := Event{Sprite: "api", From: sprites.StatusWorking, To: sprites.StatusError, Detail: "exit code 2"}
+	if err := sink.Notify(context.Background(), e); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(got.body), &payload); err != nil {
+		t.Fatalf("payload is not JSON: %q", got.body)
+	}
+	if payload["text"] != "*api errored*\nexit code 2" {
+		t.Errorf("text = %q", payload["text"])
+	}
+	if got.header.Get("Content-Type") != "application/json" || got.header.Get("X-Team") != "infra" {
+		t.Errorf("headers = %v", got.header)
+	}
+}
+
+func TestWebhook_Template(t *testing.T) {
+	srv, got := newCaptureServer(t, nil)
+	sink := Webhook{
+		URL:        srv.URL,
+		Template:   `{"content": {{json .Title}}, "sprite": {{json .Sprite}}}`,
+		HTTPClient: srv.Client(),
+	}
+
+	e := Event{Sprite: `say "hi"`, To: sprites.StatusWaiting}
+	if err := sink.Notify(context.Background(), e); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	var payload map[string]string
+	if err := json.Unmarshal([]byte(got.body), &payload); err != nil {
+		t.Fatalf("template output is not JSON: %q", got.body)
+	}
+	if payload["content"] != `say "hi" needs attention` {
+		t.Errorf("content = %q", payload["content"])
+	}
+
+	if _, err := ParseWebhookTemplate(`{{.Nope`); err == nil {
+		t.Errorf("expected a parse error")
+	}
+}
+
+func TestRetry(t *testing.T) {
+	srv, got := newCaptureServer(t, func(n int32) int {
+		if n < 3 {
+			return http.StatusServiceUnavailable
+		}
+		return http.StatusOK
+	})
+	sink := Webhook{URL: srv.URL, HTTPClient: srv.Client()}
+
+	err := retry(context.Background(), 3, time.Millisecond, func() error {
+		return sink.Notify(context.Background(), Event{Sprite: "a"})
+	})
+	if err != nil || got.calls.Load() != 3 {
+		t.Errorf("err=%v calls=%d, want success on third try", err, got.calls.Load())
+	}
+}
+
+func TestRetry_ClientErrorIsPermanent(t *testing.T) {
+	srv, got := newCaptureServer(t, func(int32) int { return http.StatusBadRequest })
+	sink := Webhook{URL: srv.URL, HTTPClient: srv.Client()}
+
+	err := retry(context.Background(), 3, time.Millisecond, func() error {
+		return sink.Notify(context.Background(), Event{Sprite: "a"})
+	})
+	var he *HTTPError
+	if !errors.As(err, &he) || he.StatusCode != http.StatusBadRequest {
+		t.Fatalf("err = %v, want HTTPError 400", err)
+	}
+	if got.calls.Load() != 1 {
+		t.Errorf("calls = %d, 4xx should not be retried", got.calls.Load())
+	}
+}
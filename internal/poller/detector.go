@@ -0,0 +1,332 @@
+package poller
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// DefaultPromptPatterns match the questions Claude Code stops on: its
+// permission dialogs and y/n confirmations. They are kept to that text so
+// the idle input box or a question in Claude's prose does not count as
+// WAITING. Patterns are POSIX extended regexes, run with grep -E.
+var DefaultPromptPatterns = []string{
+	"Do you want to (proceed|make this edit|create|allow)",
+	"Yes, and don't ask again",
+	"No, and tell Claude what to do differently",
+	`[([][Yy]/[Nn][])]`,
+}
+
+// Detector types, as named in the config file.
+const (
+	DetectorTmux    = "tmux"
+	DetectorProcess = "process"
+	DetectorMarker  = "marker"
+	DetectorScript  = "script"
+)
+
+// DefaultProcess is the agent process the built-in detectors look for.
+const DefaultProcess = "claude"
+
+// DefaultTarget is the tmux session templates start Claude Code in. It is
+// the pane used when none is configured and the session exists.
+const DefaultTarget = "claude"
+
+// ExitFile is where sessions started from a template leave Claude Code's
+// exit code, relative to the home directory. Tmux reads it once the agent
+// has exited.
+const ExitFile = ".slua/claude-exit"
+
+// DefaultMarkerFile is where Marker reads the state, relative to the home
+// directory.
+const DefaultMarkerFile = ".slua/status"
+
+// Detection is what a Detector made of one check of a Sprite.
+type Detection struct {
+	Status   string `json:"status"`
+	Detail   string `json:"detail"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// Detector works out what the agent on a Sprite is doing from the output
+// of a command run there. The poller checks several Sprites at once, so
+// implementations must be safe for concurrent use.
+type Detector interface {
+	// Command returns the argv to run on the Sprite.
+	Command(sprite string) []string
+	// Parse classifies what the command printed. An error counts as a
+	// failed check.
+	Parse(sprite string, out []byte) (Detection, error)
+}
+
+// Agent is implemented by detectors that know the agent's process and the
+// tmux pane it runs in, so answers and prompts reach the pane detection
+// reads.
+type Agent interface {
+	Agent() (process, target string)
+}
+
+// AgentOf returns the agent process and tmux target d watches. Detectors
+// that do not say, and nil, give DefaultProcess and no target.
+func AgentOf(d Detector) (process, target string) {
+	if a, ok := d.(Agent); ok {
+		return a.Agent()
+	}
+	return DefaultProcess, ""
+}
+
+// Tmux is the default detector. While Process runs, a line matching
+// Patterns among the last lines of the tmux pane means WAITING and
+// anything else WORKING. Once it exits, the exit code in ExitFile tells
+// FINISHED from ERROR.
+type Tmux struct {
+	// Process is the agent's process name. Empty means DefaultProcess.
+	Process string
+	// Target is the pane to read, as given to tmux -t. Empty means the
+	// DefaultTarget session if it exists, else the current pane.
+	Target string
+	// Patterns are extended regexes for prompts that need the user. Empty
+	// means DefaultPromptPatterns.
+	Patterns []string
+}
+
+// targetScript sets an empty $TARGET to DefaultTarget when that session
+// exists.
+const targetScript = `if [ -z "$TARGET" ] && tmux has-session -t ` + DefaultTarget + ` 2>/dev/null; then
+  TARGET=` + DefaultTarget + `
+fi
+`
+
+// tmuxScript prints the state on the first line and, for WAITING, the
+// matched prompt line on the second. The %s are replaced by the
+// shell-quoted prompt regex, process name and pane.
+const tmuxScript = `PATTERN=%s
+PROC=%s
+TARGET=%s
+` + targetScript + `if pgrep -- "$PROC" >/dev/null 2>&1; then
+  RECENT=$(tmux capture-pane -p ${TARGET:+-t "$TARGET"} 2>/dev/null | grep -v '^[[:space:]]*$' | tail -n 5)
+  MATCH=$(printf '%%s\n' "$RECENT" | grep -E "$PATTERN" | tail -n 1)
+  if [ -n "$MATCH" ]; then
+    echo WAITING
+    printf '%%s\n' "$MATCH"
+  else
+    echo WORKING
+  fi
+else
+  EXIT=$(cat "$HOME/` + ExitFile + `" 2>/dev/null)
+  if [ -z "$EXIT" ] || [ "$EXIT" = "0" ]; then
+    echo FINISHED
+  else
+    echo "ERROR:$EXIT"
+  fi
+fi
+`
+
+// Command implements Detector.
+func (t Tmux) Command(string) []string {
+	script := fmt.Sprintf(tmuxScript,
+		shellQuote(promptRegex(t.Patterns)), shellQuote(orDefault(t.Process, DefaultProcess)), shellQuote(t.Target))
+	return []string{"sh", "-c", script}
+}
+
+// Parse implements Detector.
+func (Tmux) Parse(_ string, out []byte) (Detection, error) {
+	return parseText(out), nil
+}
+
+// Agent implements Agent.
+func (t Tmux) Agent() (process, target string) {
+	return orDefault(t.Process, DefaultProcess), t.Target
+}
+
+// Process reports WORKING while a process runs and FINISHED otherwise,
+// for agents that exit when done and never stop to ask.
+type Process struct {
+	// Name is the process name. Empty means DefaultProcess.
+	Name string
+}
+
+const processScript = `if pgrep -- "$1" >/dev/null 2>&1; then echo WORKING; else echo FINISHED; fi`
+
+// Command implements Detector.
+func (p Process) Command(string) []string {
+	return []string{"sh", "-c", processScript, "sh", orDefault(p.Name, DefaultProcess)}
+}
+
+// Parse implements Detector.
+func (Process) Parse(_ string, out []byte) (Detection, error) {
+	return parseText(out), nil
+}
+
+// Agent implements Agent.
+func (p Process) Agent() (process, target string) {
+	return orDefault(p.Name, DefaultProcess), ""
+}
+
+// Marker reads the state from a file the agent keeps up to date, such as
+// from Claude Code hooks. The file holds the state in the form the Tmux
+// detector prints — WORKING, WAITING with the prompt on the next line,
+// FINISHED or ERROR:<code> — or as a JSON object like Script's. A missing
+// file means FINISHED.
+type Marker struct {
+	// Path is the file, relative to the home directory unless absolute.
+	// Empty means DefaultMarkerFile.
+	Path string
+	// Process, if set, is checked first so a file left behind by an agent
+	// that died is not believed: without it running the Sprite is FINISHED.
+	Process string
+}
+
+const markerScript = `cd
+if [ -n "$2" ] && ! pgrep -- "$2" >/dev/null 2>&1; then
+  echo FINISHED
+elif [ -f "$1" ]; then
+  cat -- "$1"
+else
+  echo FINISHED
+fi
+`
+
+// Command implements Detector.
+func (m Marker) Command(string) []string {
+	return []string{"sh", "-c", markerScript, "sh", orDefault(m.Path, DefaultMarkerFile), m.Process}
+}
+
+// Agent implements Agent.
+func (m Marker) Agent() (process, target string) {
+	return orDefault(m.Process, DefaultProcess), ""
+}
+
+// Parse implements Detector.
+func (Marker) Parse(_ string, out []byte) (Detection, error) {
+	if bytes.HasPrefix(bytes.TrimSpace(out), []byte("{")) {
+		return parseJSON(out)
+	}
+	return parseText(out), nil
+}
+
+// Script runs a shell script of the user's, with the Sprite name as $1,
+// that prints the state as a JSON object such as
+//
+//	{"status": "WAITING", "detail": "Allow Bash?"}
+//
+// where status is WORKING, WAITING, FINISHED, ERROR or SLEEPING and
+// exit_code may go with ERROR. It covers agents and multiplexers the
+// built-in detectors do not know.
+type Script struct {
+	Script string
+}
+
+// Command implements Detector.
+func (s Script) Command(sprite string) []string {
+	return []string{"sh", "-c", s.Script, "sh", sprite}
+}
+
+// Parse implements Detector.
+func (Script) Parse(_ string, out []byte) (Detection, error) {
+	return parseJSON(out)
+}
+
+// orDefault returns s, or def when s is empty.
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// replyScript types $2 into the pane $1, or the one detection reads
+// without a target, and presses Enter. -l sends the text literally so
+// words such as "Enter" are not taken as key names.
+const replyScript = `TARGET=$1
+` + targetScript + `tmux send-keys ${TARGET:+-t "$TARGET"} -l -- "$2" && tmux send-keys ${TARGET:+-t "$TARGET"} Enter`
+
+// ReplyCommand returns the argv that answers a WAITING prompt with text
+// in the tmux pane target, as AgentOf gives it.
+func ReplyCommand(target, text string) []string {
+	return []string{"sh", "-c", replyScript, "sh", target, text}
+}
+
+// promptRegex combines patterns into a single extended regex alternation.
+func promptRegex(patterns []string) string {
+	if len(patterns) == 0 {
+		patterns = DefaultPromptPatterns
+	}
+	parts := make([]string, len(patterns))
+	for i, p := range patterns {
+		parts[i] = "(" + p + ")"
+	}
+	return strings.Join(parts, "|")
+}
+
+// shellQuote wraps s in single quotes for safe use in a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// parseText maps output in the Tmux detector's form to a Detection.
+func parseText(out []byte) Detection {
+	var d Detection
+	d.Status, d.Detail, d.ExitCode = parseDetection(out)
+	return d
+}
+
+// parseDetection maps the detection script output to a status, detail and
+// exit code. Unrecognized output is treated as SLEEPING, the conservative
+// default.
+func parseDetection(out []byte) (status, detail string, exitCode int) {
+	lines := strings.Split(string(bytes.TrimSpace(out)), "\n")
+	first := strings.TrimSpace(lines[0])
+
+	switch {
+	case first == sprites.StatusWorking:
+		return sprites.StatusWorking, "", 0
+	case first == sprites.StatusFinished:
+		return sprites.StatusFinished, "", 0
+	case first == sprites.StatusWaiting:
+		if len(lines) > 1 {
+			detail = strings.TrimSpace(lines[1])
+		}
+		return sprites.StatusWaiting, detail, 0
+	case strings.HasPrefix(first, sprites.StatusError+":"):
+		code, err := strconv.Atoi(strings.TrimPrefix(first, sprites.StatusError+":"))
+		if err != nil {
+			return sprites.StatusError, "unknown exit code", 0
+		}
+		return sprites.StatusError, fmt.Sprintf("exit code %d", code), code
+	default:
+		return sprites.StatusSleeping, "", 0
+	}
+}
+
+// reportable are the statuses a detector may report.
+var reportable = []string{
+	sprites.StatusWorking,
+	sprites.StatusWaiting,
+	sprites.StatusFinished,
+	sprites.StatusError,
+	sprites.StatusSleeping,
+}
+
+// parseJSON decodes a Detection printed as JSON. Unlike the text form,
+// output that does not parse is an error, so a broken script shows as a
+// failing check rather than a Sprite that seems asleep.
+func parseJSON(out []byte) (Detection, error) {
+	var d Detection
+	if err := json.Unmarshal(bytes.TrimSpace(out), &d); err != nil {
+		return Detection{}, fmt.Errorf("reading detector output: %w", err)
+	}
+	d.Status = strings.ToUpper(strings.TrimSpace(d.Status))
+	if !slices.Contains(reportable, d.Status) {
+		return Detection{}, fmt.Errorf("detector reported unknown status %q", d.Status)
+	}
+	if d.Status == sprites.StatusError && d.Detail == "" && d.ExitCode != 0 {
+		d.Detail = fmt.Sprintf("exit code %d", d.ExitCode)
+	}
+	return d, nil
+}
@@ -0,0 +1,208 @@
+package poller
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+func TestParseDetection(t *testing.T) {
+	tests := []struct {
+		input    string
+		status   string
+		detail   string
+		exitCode int
+	}{
+		{"WORKING\n", sprites.StatusWorking, "", 0},
+		{"FINISHED\n", sprites.StatusFinished, "", 0},
+		{"WAITING\nAllow this tool? (y/n)\n", sprites.StatusWaiting, "Allow this tool? (y/n)", 0},
+		{"WAITING\n", sprites.StatusWaiting, "", 0},
+		{"ERROR:2\n", sprites.StatusError, "exit code 2", 2},
+		{"ERROR:abc\n", sprites.StatusError, "unknown exit code", 0},
+		{"", sprites.StatusSleeping, "", 0},
+		{"garbage\n", sprites.StatusSleeping, "", 0},
+	}
+
+	for _, tt := range tests {
+		status, detail, code := parseDetection([]byte(tt.input))
+		if status != tt.status || detail != tt.detail || code != tt.exitCode {
+			t.Errorf("parseDetection(%q) = (%q, %q, %d), want (%q, %q, %d)",
+				tt.input, status, detail, code, tt.status, tt.detail, tt.exitCode)
+		}
+	}
+}
+
+func TestPromptRegex(t *testing.T) {
+	got := promptRegex([]string{"Y/n", "Allow"})
+	if got != "(Y/n)|(Allow)" {
+		t.Errorf("promptRegex() = %q, want %q", got, "(Y/n)|(Allow)")
+	}
+
+	if promptRegex(nil) != promptRegex(DefaultPromptPatterns) {
+		t.Errorf("promptRegex(nil) should fall back to DefaultPromptPatterns")
+	}
+}
+
+func TestDefaultPromptPatterns(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"Do you want to proceed?", true},
+		{"Do you want to make this edit to main.go?", true},
+		{"❯ 1. Yes", false},
+		{"  2. Yes, and don't ask again for npm test commands in /app", true},
+		{"Overwrite config? (y/N)", true},
+		{"> ", false},
+		{">", false},
+		{"Should I also update the README? Let me check.", false},
+		{"Permission settings are in .claude/settings.json", false},
+	}
+	for _, tt := range tests {
+		cmd := exec.Command("grep", "-E", "-q", promptRegex(nil))
+		cmd.Stdin = strings.NewReader(tt.line + "\n")
+		err := cmd.Run()
+		if got := err == nil; got != tt.want {
+			t.Errorf("grep -E on %q matched = %v, want %v (%v)", tt.line, got, tt.want, err)
+		}
+	}
+}
+
+func TestShellQuote(t *testing.T) {
+	got := shellQuote("it's")
+	want := `'it'\''s'`
+	if got != want {
+		t.Errorf("shellQuote() = %q, want %q", got, want)
+	}
+}
+
+func TestTmuxCommand(t *testing.T) {
+	cmd := Tmux{Patterns: []string{"Y/n"}, Process: "aider"}.Command("api")
+	if len(cmd) != 3 || cmd[0] != "sh" || cmd[1] != "-c" {
+		t.Fatalf("unexpected command: %v", cmd)
+	}
+	for _, want := range []string{"PATTERN='(Y/n)'", "PROC='aider'", "TARGET=''"} {
+		if !strings.Contains(cmd[2], want) {
+			t.Errorf("script should contain %s, got:\n%s", want, cmd[2])
+		}
+	}
+	if strings.Contains(cmd[2], "%!") {
+		t.Errorf("script has formatting errors:\n%s", cmd[2])
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Detection
+		err   bool
+	}{
+		{`{"status": "working"}`, Detection{Status: sprites.StatusWorking}, false},
+		{`{"status": "WAITING", "detail": "Allow Bash?"}`, Detection{Status: sprites.StatusWaiting, Detail: "Allow Bash?"}, false},
+		{`{"status": "ERROR", "exit_code": 3}`, Detection{Status: sprites.StatusError, Detail: "exit code 3", ExitCode: 3}, false},
+		{`{"status": "BUSY"}`, Detection{}, true},
+		{"WORKING\n", Detection{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseJSON([]byte(tt.input))
+		if got != tt.want || (err != nil) != tt.err {
+			t.Errorf("parseJSON(%q) = %+v, %v, want %+v (error %v)", tt.input, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestMarker(t *testing.T) {
+	// A stand-in pgrep finds only the processes listed in $RUNNING.
+	bin := t.TempDir()
+	pgrep := "#!/bin/sh\nfor p in $RUNNING; do [ \"$p\" = \"$2\" ] && exit 0; done\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(bin, "pgrep"), []byte(pgrep), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	home := t.TempDir()
+
+	run := func(m Marker, running string) Detection {
+		t.Helper()
+		argv := m.Command("api")
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Env = append(os.Environ(), "PATH="+bin+":"+os.Getenv("PATH"), "HOME="+home, "RUNNING="+running)
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("marker script failed: %v", err)
+		}
+		d, err := m.Parse("api", out)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", out, err)
+		}
+		return d
+	}
+
+	if d := run(Marker{}, ""); d.Status != sprites.StatusFinished {
+		t.Errorf("without a file: status = %q, want FINISHED", d.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Join(home, ".slua"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(home, DefaultMarkerFile), []byte("WAITING\nAllow Bash?\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if d := run(Marker{}, ""); d.Status != sprites.StatusWaiting || d.Detail != "Allow Bash?" {
+		t.Errorf("text file: got %+v", d)
+	}
+
+	path := filepath.Join(home, "state.json")
+	if err := os.WriteFile(path, []byte(`{"status": "WORKING"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m := Marker{Path: path, Process: "aider"}
+	if d := run(m, "aider"); d.Status != sprites.StatusWorking {
+		t.Errorf("JSON file: status = %q, want WORKING", d.Status)
+	}
+	if d := run(m, ""); d.Status != sprites.StatusFinished {
+		t.Errorf("agent gone: status = %q, want FINISHED", d.Status)
+	}
+}
+
+func TestReplyCommand(t *testing.T) {
+	// A stand-in tmux records the arguments of each call.
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\nprintf '%s|' \"$@\" >> " + shellQuote(log) + "\necho >> " + shellQuote(log) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "tmux"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without a target the answer goes to the claude session, which the
+	// stand-in always has.
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"", "has-session|-t|claude|\nsend-keys|-t|claude|-l|--|it's \"Enter\"; $HOME|\nsend-keys|-t|claude|Enter|\n"},
+		{"work:1", "send-keys|-t|work:1|-l|--|it's \"Enter\"; $HOME|\nsend-keys|-t|work:1|Enter|\n"},
+	}
+	for _, tt := range tests {
+		os.Remove(log)
+		argv := ReplyCommand(tt.target, `it's "Enter"; $HOME`)
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Env = append(os.Environ(), "PATH="+dir+":"+os.Getenv("PATH"))
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("reply failed: %v\n%s", err, out)
+		}
+		if calls, _ := os.ReadFile(log); string(calls) != tt.want {
+			t.Errorf("target %q: tmux calls = %q, want %q", tt.target, calls, tt.want)
+		}
+	}
+
+	if process, target := AgentOf(Tmux{Process: "aider", Target: "work:1"}); process != "aider" || target != "work:1" {
+		t.Errorf("AgentOf(Tmux) = %s, %s", process, target)
+	}
+	if process, target := AgentOf(nil); process != DefaultProcess || target != "" {
+		t.Errorf("AgentOf(nil) = %s, %s, want the defaults", process, target)
+	}
+}
@@ -0,0 +1,295 @@
+package poller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Defaults for Poller fields left at their zero value.
+const (
+	DefaultInterval         = 15 * time.Second
+	DefaultExecTimeout      = 5 * time.Second
+	DefaultWorkers          = 10
+	DefaultFailureThreshold = 3
+)
+
+// backlog is how many cycles Run buffers for a consumer that is not
+// reading, such as a dashboard suspended for a console session. Beyond
+// that the oldest cycles are dropped so polling never stalls.
+const backlog = 64
+
+// Source is the subset of sprites.SpriteSource the poller needs.
+type Source interface {
+	List(ctx context.Context) ([]sprites.Sprite, error)
+	Exec(ctx context.Context, name string, command []string) (sprites.ExecResult, error)
+}
+
+// Poller periodically runs Claude Code state detection on every awake Sprite
+// and publishes the results as Cycles.
+type Poller struct {
+	// Interval is the time between poll cycles.
+	Interval time.Duration
+	// ExecTimeout bounds each per-Sprite detection command.
+	ExecTimeout time.Duration
+	// Workers caps the number of concurrent `sprite exec` calls.
+	Workers int
+	// FailureThreshold is the number of consecutive failed checks before a
+	// Sprite is reported UNREACHABLE. Earlier failures keep the last result.
+	FailureThreshold int
+	// DetectorFor picks the detector for each Sprite. Nil, or a nil
+	// result, means a Tmux detector with default settings.
+	DetectorFor func(sprite string) Detector
+
+	src       Source
+	updates   chan Cycle
+	trigger   chan struct{}
+	observers []func(Cycle)
+
+	mu       sync.Mutex
+	last     map[string]Result // last successful detection
+	reported map[string]string // last status handed to callers
+	failures map[string]int
+}
+
+// New creates a Poller with default settings.
+func New(src Source) *Poller {
+	return &Poller{
+		Interval:         DefaultInterval,
+		ExecTimeout:      DefaultExecTimeout,
+		Workers:          DefaultWorkers,
+		FailureThreshold: DefaultFailureThreshold,
+		src:              src,
+		updates:          make(chan Cycle, backlog),
+		trigger:          make(chan struct{}, 1),
+		last:             make(map[string]Result),
+		reported:         make(map[string]string),
+		failures:         make(map[string]int),
+	}
+}
+
+// Updates returns the channel on which Run publishes each Cycle.
+func (p *Poller) Updates() <-chan Cycle {
+	return p.updates
+}
+
+// Observe registers fn to be called with every Cycle from Run's goroutine,
+// before the cycle is published. Observers see cycles as they happen even
+// while the Updates consumer is busy, so fn must not block. A change found
+// by Poll outside a cycle is passed to fn too, from Poll's caller, as a
+// Cycle with that one result and no Sprites. Call Observe before Run.
+func (p *Poller) Observe(fn func(Cycle)) {
+	p.observers = append(p.observers, fn)
+}
+
+// Trigger asks Run to start the next cycle immediately. It never blocks.
+func (p *Poller) Trigger() {
+	select {
+	case p.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run polls until ctx is cancelled, publishing every Cycle on Updates.
+// The first cycle starts immediately. Run never waits for the consumer.
+func (p *Poller) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+
+	for {
+		c := p.PollOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		for _, fn := range p.observers {
+			fn(c)
+		}
+		p.publish(c)
+
+		select {
+		case <-ticker.C:
+		case <-p.trigger:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// publish queues c on Updates, dropping the oldest queued cycle if the
+// consumer has fallen a full backlog behind.
+func (p *Poller) publish(c Cycle) {
+	for {
+		select {
+		case p.updates <- c:
+			return
+		default:
+		}
+		select {
+		case <-p.updates:
+		default:
+		}
+	}
+}
+
+// PollOnce lists Sprites and runs detection on each pollable one.
+func (p *Poller) PollOnce(ctx context.Context) Cycle {
+	list, err := p.src.List(ctx)
+	if err != nil {
+		return Cycle{Err: err, At: time.Now()}
+	}
+
+	var targets []string
+	for _, s := range list {
+		if Pollable(s) {
+			targets = append(targets, s.Name)
+		}
+	}
+	results := p.PollAll(ctx, targets)
+	p.forgetExcept(results)
+
+	return Cycle{Sprites: list, Results: results, At: time.Now()}
+}
+
+// PollAll runs detection on each named Sprite using at most Workers
+// concurrent exec calls.
+func (p *Poller) PollAll(ctx context.Context, names []string) map[string]Result {
+	workers := p.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make(map[string]Result, len(names))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+
+	for _, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r := p.poll(ctx, name)
+			mu.Lock()
+			results[name] = r
+			mu.Unlock()
+		}(name)
+	}
+	wg.Wait()
+	return results
+}
+
+// Poll runs detection on a single Sprite outside a cycle. The next cycle
+// will not report a change Poll found, so Poll passes it to the observers.
+func (p *Poller) Poll(ctx context.Context, name string) Result {
+	r := p.poll(ctx, name)
+	if _, ok := r.Transition(); ok {
+		c := Cycle{Results: map[string]Result{name: r}, At: r.CheckedAt}
+		for _, fn := range p.observers {
+			fn(c)
+		}
+	}
+	return r
+}
+
+// poll runs detection on a single Sprite, applying the failure threshold.
+// Result.Previous is filled from the last reported status so each change
+// surfaces exactly once, whichever caller polled.
+func (p *Poller) poll(ctx context.Context, name string) Result {
+	r := p.detect(ctx, name)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if r.Err == nil {
+		p.failures[name] = 0
+		p.last[name] = r
+	} else {
+		p.failures[name]++
+		prev, ok := p.last[name]
+		if ok && p.failures[name] < p.threshold() {
+			prev.Err = r.Err
+			prev.CheckedAt = r.CheckedAt
+			r = prev
+		}
+	}
+
+	r.Previous = p.reported[name]
+	p.reported[name] = r.Status
+	return r
+}
+
+// Detector returns the Detector used for name.
+func (p *Poller) Detector(name string) Detector {
+	if p.DetectorFor != nil {
+		if d := p.DetectorFor(name); d != nil {
+			return d
+		}
+	}
+	return Tmux{}
+}
+
+// detect runs name's detector once and classifies its output.
+func (p *Poller) detect(ctx context.Context, name string) Result {
+	timeout := p.ExecTimeout
+	if timeout <= 0 {
+		timeout = DefaultExecTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	det := p.Detector(name)
+	r := Result{Name: name, CheckedAt: time.Now()}
+	res, err := p.src.Exec(ctx, name, det.Command(name))
+	if err == nil && res.ExitCode != 0 {
+		err = fmt.Errorf("detection exited with code %d", res.ExitCode)
+	}
+	if err != nil {
+		r.Status = sprites.StatusUnreachable
+		r.Detail = "connection lost"
+		r.Err = err
+		return r
+	}
+
+	d, err := det.Parse(name, res.Stdout)
+	if err != nil {
+		r.Status = sprites.StatusUnreachable
+		r.Detail = "detection failed"
+		r.Err = err
+		return r
+	}
+	r.Status, r.Detail, r.ExitCode = d.Status, d.Detail, d.ExitCode
+	return r
+}
+
+func (p *Poller) threshold() int {
+	if p.FailureThreshold < 1 {
+		return 1
+	}
+	return p.FailureThreshold
+}
+
+// forgetExcept drops tracked state for Sprites that were not polled in the
+// latest cycle, so a Sprite that wakes up starts fresh.
+func (p *Poller) forgetExcept(results map[string]Result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name := range p.last {
+		if _, ok := results[name]; !ok {
+			delete(p.last, name)
+		}
+	}
+	for name := range p.reported {
+		if _, ok := results[name]; !ok {
+			delete(p.reported, name)
+		}
+	}
+	for name := range p.failures {
+		if _, ok := results[name]; !ok {
+			delete(p.failures, name)
+		}
+	}
+}
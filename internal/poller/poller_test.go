@@ -0,0 +1,309 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// fakeSource returns canned detection output per Sprite name.
+type fakeSource struct {
+	sprites []sprites.Sprite
+	listErr error
+	outputs map[string]string
+	errs    map[string]error
+	delay   time.Duration
+
+	mu       sync.Mutex
+	calls    []string
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (f *fakeSource) List(_ context.Context) ([]sprites.Sprite, error) {
+	return f.sprites, f.listErr
+}
+
+func (f *fakeSource) Exec(ctx context.Context, name string, _ []string) (sprites.ExecResult, error) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		p := f.peak.Load()
+		if n <= p || f.peak.CompareAndSwap(p, n) {
+			break
+		}
+	}
+
+	f.mu.Lock()
+	f.calls = append(f.calls, name)
+	f.mu.Unlock()
+
+	if f.delay > 0 {
+		select {
+		case <-time.After(f.delay):
+		case <-ctx.Done():
+			return sprites.ExecResult{}, ctx.Err()
+		}
+	}
+	if err := f.errs[name]; err != nil {
+		return sprites.ExecResult{}, err
+	}
+	return sprites.ExecResult{Stdout: []byte(f.outputs[name])}, nil
+}
+
+func TestPollOnce_SkipsSleepingSprites(t *testing.T) {
+	src := &fakeSource{
+		sprites: []sprites.Sprite{
+			{Name: "busy", Status: sprites.StatusWorking},
+			{Name: "asking", Status: sprites.StatusWorking},
+			{Name: "napping", Status: sprites.StatusSleeping},
+		},
+		outputs: map[string]string{
+			"busy":   "WORKING\n",
+			"asking": "WAITING\nDo you want to proceed? (Y/n)\n",
+		},
+	}
+	p := New(src)
+
+	c := p.PollOnce(context.Background())
+	if c.Err != nil {
+		t.Fatalf("unexpected error: %v", c.Err)
+	}
+	if len(c.Sprites) != 3 {
+		t.Errorf("expected 3 sprites in cycle, got %d", len(c.Sprites))
+	}
+	if _, ok := c.Results["napping"]; ok {
+		t.Errorf("sleeping sprite should not be polled")
+	}
+	if got := c.Results["busy"].Status; got != sprites.StatusWorking {
+		t.Errorf("busy status = %q, want %q", got, sprites.StatusWorking)
+	}
+	if got := c.Results["asking"]; got.Status != sprites.StatusWaiting || got.Detail != "Do you want to proceed? (Y/n)" {
+		t.Errorf("asking result = %+v", got)
+	}
+}
+
+func TestPollOnce_ListError(t *testing.T) {
+	src := &fakeSource{listErr: errors.New("auth expired")}
+	p := New(src)
+
+	c := p.PollOnce(context.Background())
+	if c.Err == nil {
+		t.Fatal("expected list error in cycle")
+	}
+	if len(src.calls) != 0 {
+		t.Errorf("no sprites should be polled when list fails")
+	}
+}
+
+func TestPoll_FailureThreshold(t *testing.T) {
+	src := &fakeSource{outputs: map[string]string{"flaky": "WORKING\n"}}
+	p := New(src)
+	p.FailureThreshold = 3
+	ctx := context.Background()
+
+	if r := p.Poll(ctx, "flaky"); r.Status != sprites.StatusWorking {
+		t.Fatalf("initial status = %q, want %q", r.Status, sprites.StatusWorking)
+	}
+
+	src.errs = map[string]error{"flaky": errors.New("connection reset")}
+
+	for i := 1; i < 3; i++ {
+		r := p.Poll(ctx, "flaky")
+		if r.Status != sprites.StatusWorking || !r.Stale() {
+			t.Errorf("failure %d: got %q stale=%v, want last good status kept", i, r.Status, r.Stale())
+		}
+	}
+
+	r := p.Poll(ctx, "flaky")
+	if r.Status != sprites.StatusUnreachable {
+		t.Errorf("after threshold: status = %q, want %q", r.Status, sprites.StatusUnreachable)
+	}
+
+	src.errs = nil
+	if r := p.Poll(ctx, "flaky"); r.Status != sprites.StatusWorking || r.Err != nil {
+		t.Errorf("after recovery: got %+v", r)
+	}
+}
+
+func TestPoll_FirstFailureIsUnreachable(t *testing.T) {
+	src := &fakeSource{errs: map[string]error{"gone": errors.New("no route")}}
+	p := New(src)
+
+	if r := p.Poll(context.Background(), "gone"); r.Status != sprites.StatusUnreachable {
+		t.Errorf("status = %q, want %q", r.Status, sprites.StatusUnreachable)
+	}
+}
+
+func TestPoll_DetectorFor(t *testing.T) {
+	src := &fakeSource{outputs: map[string]string{
+		"api":   "FINISHED\n",
+		"aider": `{"status": "WAITING", "detail": "Apply edit?"}`,
+		"bad":   "WORKING\n",
+	}}
+	p := New(src)
+	p.DetectorFor = func(name string) Detector {
+		if name == "api" {
+			return nil
+		}
+		return Script{Script: "agent-state"}
+	}
+	ctx := context.Background()
+
+	if r := p.Poll(ctx, "api"); r.Status != sprites.StatusFinished {
+		t.Errorf("default detector: status = %q, want FINISHED", r.Status)
+	}
+	if r := p.Poll(ctx, "aider"); r.Status != sprites.StatusWaiting || r.Detail != "Apply edit?" {
+		t.Errorf("script detector: got %q %q", r.Status, r.Detail)
+	}
+	if r := p.Poll(ctx, "bad"); r.Status != sprites.StatusUnreachable || r.Err == nil {
+		t.Errorf("unparseable output: got %+v, want a failed check", r)
+	}
+}
+
+func TestPoll_ExecTimeout(t *testing.T) {
+	src := &fakeSource{
+		outputs: map[string]string{"slow": "WORKING\n"},
+		delay:   time.Second,
+	}
+	p := New(src)
+	p.ExecTimeout = 20 * time.Millisecond
+
+	start := time.Now()
+	r := p.Poll(context.Background(), "slow")
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("Poll did not respect ExecTimeout")
+	}
+	if r.Status != sprites.StatusUnreachable {
+		t.Errorf("status = %q, want %q", r.Status, sprites.StatusUnreachable)
+	}
+}
+
+func TestPollAll_BoundedWorkers(t *testing.T) {
+	src := &fakeSource{outputs: map[string]string{}, delay: 10 * time.Millisecond}
+	names := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	for _, n := range names {
+		src.outputs[n] = "WORKING\n"
+	}
+	p := New(src)
+	p.Workers = 3
+
+	results := p.PollAll(context.Background(), names)
+	if len(results) != len(names) {
+		t.Fatalf("expected %d results, got %d", len(names), len(results))
+	}
+	if peak := src.peak.Load(); peak > 3 {
+		t.Errorf("peak concurrency = %d, want <= 3", peak)
+	}
+}
+
+func TestRun_PublishesAndTriggers(t *testing.T) {
+	src := &fakeSource{
+		sprites: []sprites.Sprite{{Name: "busy", Status: sprites.StatusWorking}},
+		outputs: map[string]string{"busy": "WORKING\n"},
+	}
+	p := New(src)
+	p.Interval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	select {
+	case c := <-p.Updates():
+		if c.Results["busy"].Status != sprites.StatusWorking {
+			t.Errorf("unexpected first cycle: %+v", c)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("first cycle was not published")
+	}
+
+	p.Trigger()
+	select {
+	case <-p.Updates():
+	case <-time.After(time.Second):
+		t.Fatal("Trigger did not start a new cycle")
+	}
+}
+
+func TestPoll_ReportsTransitionsOnce(t *testing.T) {
+	src := &fakeSource{outputs: map[string]string{"job": "WORKING\n"}}
+	p := New(src)
+	ctx := context.Background()
+	var observed []Transition
+	p.Observe(func(c Cycle) { observed = append(observed, c.Transitions()...) })
+
+	if _, ok := p.Poll(ctx, "job").Transition(); ok {
+		t.Errorf("first check should not be a transition")
+	}
+
+	src.outputs["job"] = "FINISHED\n"
+	tr, ok := p.Poll(ctx, "job").Transition()
+	if !ok || tr.From != sprites.StatusWorking || tr.To != sprites.StatusFinished {
+		t.Errorf("expected WORKING→FINISHED, got %+v (ok=%v)", tr, ok)
+	}
+
+	if _, ok := p.Poll(ctx, "job").Transition(); ok {
+		t.Errorf("unchanged status should not repeat the transition")
+	}
+
+	// The next cycle will not see the change again, so observers hear of
+	// it from Poll.
+	if len(observed) != 1 || observed[0].To != sprites.StatusFinished {
+		t.Errorf("observed = %+v, want the one transition", observed)
+	}
+}
+
+func TestPollOnce_ForgetsSleepingSprites(t *testing.T) {
+	src := &fakeSource{
+		sprites: []sprites.Sprite{{Name: "job", Status: sprites.StatusWorking}},
+		outputs: map[string]string{"job": "FINISHED\n"},
+	}
+	p := New(src)
+	ctx := context.Background()
+	p.PollOnce(ctx)
+
+	src.sprites[0].Status = sprites.StatusSleeping
+	p.PollOnce(ctx)
+
+	// Waking up again starts fresh rather than reporting a transition.
+	src.sprites[0].Status = sprites.StatusWorking
+	src.outputs["job"] = "WORKING\n"
+	if ts := p.PollOnce(ctx).Transitions(); len(ts) != 0 {
+		t.Errorf("expected no transitions after wake, got %+v", ts)
+	}
+}
+
+func TestRun_ObservesWithoutConsumer(t *testing.T) {
+	src := &fakeSource{
+		sprites: []sprites.Sprite{{Name: "busy", Status: sprites.StatusWorking}},
+		outputs: map[string]string{"busy": "WORKING\n"},
+	}
+	p := New(src)
+	p.Interval = time.Millisecond
+
+	var seen atomic.Int32
+	p.Observe(func(Cycle) { seen.Add(1) })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.Run(ctx)
+
+	// Nobody reads Updates, yet polling continues past the backlog.
+	deadline := time.After(5 * time.Second)
+	for seen.Load() < backlog+5 {
+		select {
+		case <-deadline:
+			t.Fatalf("only %d cycles observed; Run is blocked on Updates", seen.Load())
+		case <-time.After(time.Millisecond):
+		}
+	}
+	if n := len(p.Updates()); n != backlog {
+		t.Errorf("queued cycles = %d, want backlog of %d", n, backlog)
+	}
+}
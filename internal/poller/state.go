@@ -0,0 +1,86 @@
+package poller
+
+import (
+	"sort"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Result is the outcome of one state detection run against a Sprite.
+type Result struct {
+	Name string
+	// Status is one of sprites.StatusWorking, StatusWaiting, StatusFinished,
+	// StatusError, StatusUnreachable or StatusSleeping.
+	Status string
+	// Detail is the matched prompt line for WAITING, or the failure reason
+	// for ERROR and UNREACHABLE.
+	Detail string
+	// ExitCode is Claude Code's last exit code when Status is ERROR.
+	ExitCode int
+	// Err is set when the most recent check failed. Below the failure
+	// threshold Status still carries the last good detection.
+	Err error
+	// Previous is the status reported by the check before this one, or
+	// empty if this is the first check since the Sprite woke up.
+	Previous  string
+	CheckedAt time.Time
+}
+
+// Transition is a change in a Sprite's detected status between checks.
+type Transition struct {
+	Name string
+	From string
+	To   string
+	At   time.Time
+}
+
+// Transition returns the status change this result represents. The second
+// result is false if the status did not change or this is a first check.
+func (r Result) Transition() (Transition, bool) {
+	if r.Previous == "" || r.Previous == r.Status {
+		return Transition{}, false
+	}
+	return Transition{Name: r.Name, From: r.Previous, To: r.Status, At: r.CheckedAt}, true
+}
+
+// Stale reports whether the result is a carried-over detection from before
+// a failed check.
+func (r Result) Stale() bool {
+	return r.Err != nil && r.Status != sprites.StatusUnreachable
+}
+
+// Cycle is the output of one full poll: the current Sprite list and the
+// detection results for every Sprite that was awake.
+type Cycle struct {
+	Sprites []sprites.Sprite
+	Results map[string]Result
+	// Err is set when the Sprite list could not be fetched. Sprites and
+	// Results are empty in that case.
+	Err error
+	At  time.Time
+}
+
+// Transitions returns every status change in the cycle, ordered by name.
+func (c Cycle) Transitions() []Transition {
+	var ts []Transition
+	for _, r := range c.Results {
+		if t, ok := r.Transition(); ok {
+			ts = append(ts, t)
+		}
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].Name < ts[j].Name })
+	return ts
+}
+
+// Pollable reports whether s should be checked with `sprite exec`.
+// Sleeping Sprites are skipped because exec would wake them, and
+// transient lifecycle states have nothing to detect.
+func Pollable(s sprites.Sprite) bool {
+	switch s.Status {
+	case sprites.StatusSleeping, sprites.StatusDestroying, sprites.StatusCreating:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,94 @@
+// Package queue decides which Sprites run the tasks queued in the state
+// file.
+package queue
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/state"
+)
+
+// Options configures the dispatcher.
+type Options struct {
+	// MaxSprites is the fleet size up to which Sprites are created for
+	// tasks no existing Sprite can take. Zero never creates Sprites.
+	MaxSprites int
+	// Template bootstraps Sprites created for tasks that name none. Empty
+	// means the blank template.
+	Template string
+}
+
+// Candidate is an idle Sprite without a task.
+type Candidate struct {
+	Name     string
+	Template string
+	Tags     []string
+}
+
+// Assignment pairs a task with the Sprite to run it. With Create set the
+// Sprite does not exist yet and is created from the task's template.
+type Assignment struct {
+	Task   state.Task
+	Sprite string
+	Create bool
+}
+
+// Idle reports whether a Sprite in status can be given a task: Claude
+// Code has finished, or the Sprite is asleep.
+func Idle(status string) bool {
+	return status == sprites.StatusFinished || status == sprites.StatusSleeping
+}
+
+// Candidates returns the Sprites in st named in idle that have no active
+// task, in list order.
+func Candidates(st *state.State, idle []string) []Candidate {
+	busy := st.CurrentTasks()
+	var list []Candidate
+	for _, s := range st.Sprites {
+		if _, ok := busy[s.Name]; ok || !slices.Contains(idle, s.Name) {
+			continue
+		}
+		l := st.Local[s.Name]
+		list = append(list, Candidate{Name: s.Name, Template: l.Template, Tags: l.Tags})
+	}
+	return list
+}
+
+// Fits reports whether c meets t's template and tag constraints.
+func Fits(t state.Task, c Candidate) bool {
+	return (t.Template == "" || t.Template == c.Template) && state.HasTags(c.Tags, t.Tags)
+}
+
+// Plan assigns queued tasks, in order, to the first idle Sprite they fit,
+// and to new Sprites while room allows. New Sprites get names taken does
+// not report. A task that fits nowhere stays queued without holding up the
+// tasks behind it.
+func Plan(queued []state.Task, idle []Candidate, room int, taken func(name string) bool) []Assignment {
+	idle = slices.Clone(idle)
+	var plan []Assignment
+	for _, t := range queued {
+		i := slices.IndexFunc(idle, func(c Candidate) bool { return Fits(t, c) })
+		switch {
+		case i >= 0:
+			plan = append(plan, Assignment{Task: t, Sprite: idle[i].Name})
+			idle = slices.Delete(idle, i, i+1)
+		case room > 0:
+			plan = append(plan, Assignment{Task: t, Sprite: SpriteName(t.ID, taken), Create: true})
+			room--
+		}
+	}
+	return plan
+}
+
+// SpriteName returns the name of the Sprite created for task id:
+// task-<id>, or if taken reports a Sprite of that name, the first of
+// task-<id>-2, task-<id>-3 and so on that it does not.
+func SpriteName(id int, taken func(name string) bool) string {
+	name := fmt.Sprintf("task-%d", id)
+	for n := 2; taken(name); n++ {
+		name = fmt.Sprintf("task-%d-%d", id, n)
+	}
+	return name
+}
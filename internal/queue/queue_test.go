@@ -0,0 +1,42 @@
+package queue
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/state"
+)
+
+func TestPlan(t *testing.T) {
+	st := &state.State{}
+	st.Reconcile([]sprites.Sprite{{Name: "web"}, {Name: "api"}, {Name: "busy"}}, time.Now())
+	st.Record(state.Event{Sprite: "web", Kind: state.EventTag, Tags: []string{"frontend"}})
+	st.Record(state.Event{Sprite: "api", Kind: state.EventCreate, Detail: "go"})
+	st.AssignTask(st.AddTask(state.Task{Prompt: "running"}).ID, "busy", time.Now())
+
+	st.AddTask(state.Task{Prompt: "any"})
+	st.AddTask(state.Task{Prompt: "needs go", Template: "go", Priority: 1})
+	st.AddTask(state.Task{Prompt: "needs frontend", Tags: []string{"frontend"}})
+	st.AddTask(state.Task{Prompt: "needs rust", Template: "rust"})
+	st.AddTask(state.Task{Prompt: "no room"})
+
+	idle := Candidates(st, []string{"web", "api", "busy"})
+	if len(idle) != 2 {
+		t.Fatalf("Candidates = %+v, want web and api; busy has a task", idle)
+	}
+	var got []string
+	// An old task-4 Sprite holds the name.
+	taken := func(name string) bool { return name == "task-4" }
+	for _, a := range Plan(st.Queued(), idle, 1, taken) {
+		got = append(got, fmt.Sprintf("%s→%s/%v", a.Task.Prompt, a.Sprite, a.Create))
+	}
+	// The go task goes first for its priority and "any" takes the other
+	// Sprite. The frontend task gets the one new Sprite there is room for,
+	// so the rest stay queued.
+	want := []string{"needs go→api/false", "any→web/false", "needs frontend→task-4-2/true"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Plan = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,388 @@
+package sprites
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the base URL of the Sprites REST API.
+const DefaultAPIURL = "https://api.sprites.dev"
+
+// TokenEnv is the environment variable checked first for an API token.
+const TokenEnv = "SPRITES_TOKEN"
+
+// Sentinel errors for common API failures. APIError unwraps to these.
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrNotFound     = errors.New("not found")
+)
+
+// APIError is returned for non-2xx responses from the Sprites API.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.Path, e.StatusCode, msg)
+}
+
+// Unwrap maps well-known status codes to sentinel errors.
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	default:
+		return nil
+	}
+}
+
+// API talks to the Sprites REST API directly instead of forking the sprite
+// CLI for every call.
+type API struct {
+	// BaseURL is the API root. Empty means DefaultAPIURL.
+	BaseURL string
+	// Token is sent as a bearer token on every request.
+	Token string
+	// Org is passed to the sprite CLI for operations the REST API cannot
+	// serve, such as interactive consoles.
+	Org string
+	// ListTimeout bounds list calls. Zero means ListTimeout.
+	ListTimeout time.Duration
+	// HTTPClient is used for all requests. Nil means a shared client that
+	// keeps connections alive between polls.
+	HTTPClient *http.Client
+}
+
+var _ SpriteSource = (*API)(nil)
+
+// defaultHTTPClient reuses connections across the many small requests the
+// poller makes.
+var defaultHTTPClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConns:        32,
+		MaxIdleConnsPerHost: 32,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+// NewAPI returns an API client using DefaultAPIURL.
+func NewAPI(token, org string) *API {
+	return &API{BaseURL: DefaultAPIURL, Token: token, Org: org}
+}
+
+// do sends a request and decodes a JSON response into out, if non-nil. A
+// *[]byte out receives the raw response body instead.
+func (a *API) do(ctx context.Context, method, path string, query url.Values, body, out any) error {
+	base := a.BaseURL
+	if base == "" {
+		base = DefaultAPIURL
+	}
+	u := strings.TrimRight(base, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("%s %s: encode body: %w", method, path, err)
+		}
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reqBody)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	req.Header.Set("Authorization", "Bearer "+a.Token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := a.HTTPClient
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%s %s: read response: %w", method, path, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &APIError{
+			Method:     method,
+			Path:       path,
+			StatusCode: resp.StatusCode,
+			Message:    apiErrorMessage(data),
+		}
+	}
+
+	if raw, ok := out.(*[]byte); ok {
+		*raw = data
+		return nil
+	}
+	if out == nil || len(bytes.TrimSpace(data)) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("%s %s: decode response: %w", method, path, err)
+	}
+	return nil
+}
+
+// apiErrorMessage extracts a human-readable message from an error body.
+func apiErrorMessage(data []byte) string {
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &body) == nil {
+		if body.Error != "" {
+			return body.Error
+		}
+		if body.Message != "" {
+			return body.Message
+		}
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// spritePath returns the API path for a Sprite sub-resource.
+func spritePath(name string, parts ...string) string {
+	p := "/v1/sprites/" + url.PathEscape(name)
+	for _, part := range parts {
+		p += "/" + url.PathEscape(part)
+	}
+	return p
+}
+
+// List returns all Sprites visible to the token.
+func (a *API) List(ctx context.Context) ([]Sprite, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(a.ListTimeout, ListTimeout))
+	defer cancel()
+
+	var data json.RawMessage
+	if err := a.do(ctx, http.MethodGet, "/v1/sprites", nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return parseSpritesJSON(data)
+}
+
+// Create creates a Sprite called name in region. An empty region lets
+// Sprites pick one.
+func (a *API) Create(ctx context.Context, name, region string) error {
+	ctx, cancel := context.WithTimeout(ctx, CreateTimeout)
+	defer cancel()
+
+	body := map[string]string{"name": name}
+	if region != "" {
+		body["region"] = region
+	}
+	return a.do(ctx, http.MethodPost, "/v1/sprites", nil, body, nil)
+}
+
+// Checkpoint snapshots the named Sprite with the given comment.
+func (a *API) Checkpoint(ctx context.Context, name, comment string) error {
+	ctx, cancel := context.WithTimeout(ctx, CheckpointTimeout)
+	defer cancel()
+
+	body := map[string]string{"comment": comment}
+	return a.do(ctx, http.MethodPost, spritePath(name, "checkpoints"), nil, body, nil)
+}
+
+// ListCheckpoints returns the named Sprite's checkpoints, newest first.
+func (a *API) ListCheckpoints(ctx context.Context, name string) ([]Checkpoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(a.ListTimeout, ListTimeout))
+	defer cancel()
+
+	var data json.RawMessage
+	if err := a.do(ctx, http.MethodGet, spritePath(name, "checkpoints"), nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return parseCheckpointsJSON(data)
+}
+
+// RestoreCheckpoint rolls the named Sprite back to checkpoint id.
+func (a *API) RestoreCheckpoint(ctx context.Context, name, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, RestoreTimeout)
+	defer cancel()
+
+	return a.do(ctx, http.MethodPost, spritePath(name, "checkpoints", id, "restore"), nil, nil, nil)
+}
+
+// DeleteCheckpoint removes checkpoint id from the named Sprite.
+func (a *API) DeleteCheckpoint(ctx context.Context, name, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, CheckpointTimeout)
+	defer cancel()
+
+	return a.do(ctx, http.MethodDelete, spritePath(name, "checkpoints", id), nil, nil, nil)
+}
+
+// Destroy permanently deletes the named Sprite.
+func (a *API) Destroy(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, DestroyTimeout)
+	defer cancel()
+
+	return a.do(ctx, http.MethodDelete, spritePath(name), nil, nil, nil)
+}
+
+// apiExecResult matches the JSON returned by POST /v1/sprites/{name}/exec.
+type apiExecResult struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// Exec runs command on the named Sprite via the HTTP exec endpoint.
+// Like CLI.Exec, a non-zero exit status is not an error.
+func (a *API) Exec(ctx context.Context, name string, command []string) (ExecResult, error) {
+	query := url.Values{"cmd": command}
+	var out apiExecResult
+	if err := a.do(ctx, http.MethodPost, spritePath(name, "exec"), query, nil, &out); err != nil {
+		return ExecResult{}, err
+	}
+	return ExecResult{
+		Stdout:   []byte(out.Stdout),
+		Stderr:   []byte(out.Stderr),
+		ExitCode: out.ExitCode,
+	}, nil
+}
+
+// ListSessions returns the exec sessions running on the named Sprite,
+// oldest first.
+func (a *API) ListSessions(ctx context.Context, name string) ([]ExecSession, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(a.ListTimeout, ListTimeout))
+	defer cancel()
+
+	var data json.RawMessage
+	if err := a.do(ctx, http.MethodGet, spritePath(name, "exec", "sessions"), nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return parseSessionsJSON(data)
+}
+
+// KillSession ends exec session id on the named Sprite.
+func (a *API) KillSession(ctx context.Context, name, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(a.ListTimeout, ListTimeout))
+	defer cancel()
+
+	return a.do(ctx, http.MethodDelete, spritePath(name, "exec", "sessions", id), nil, nil, nil)
+}
+
+// ListServices returns the services defined on the named Sprite.
+func (a *API) ListServices(ctx context.Context, name string) ([]Service, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(a.ListTimeout, ListTimeout))
+	defer cancel()
+
+	var data json.RawMessage
+	if err := a.do(ctx, http.MethodGet, spritePath(name, "services"), nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return parseServicesJSON(data)
+}
+
+// ServiceLogs returns the recent log output of service id.
+func (a *API) ServiceLogs(ctx context.Context, name, id string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(a.ListTimeout, ListTimeout))
+	defer cancel()
+
+	var data []byte
+	if err := a.do(ctx, http.MethodGet, spritePath(name, "services", id, "logs"), nil, nil, &data); err != nil {
+		return nil, err
+	}
+	return parseServiceLogs(data), nil
+}
+
+// ConsoleCmd returns a `sprite console` command. Interactive terminals need
+// the CLI's WebSocket TTY handling, so this still requires the sprite binary.
+func (a *API) ConsoleCmd(name string) *exec.Cmd {
+	return (&CLI{Org: a.Org}).ConsoleCmd(name)
+}
+
+// AttachCmd returns a `sprite attach` command, which like ConsoleCmd needs
+// the sprite binary.
+func (a *API) AttachCmd(name, id string) *exec.Cmd {
+	return (&CLI{Org: a.Org}).AttachCmd(name, id)
+}
+
+// credentials matches the sprite CLI's credential file.
+type credentials struct {
+	Token string `json:"token"`
+	Orgs  map[string]struct {
+		Token string `json:"token"`
+	} `json:"orgs"`
+}
+
+// CredentialsPath returns the location of the sprite CLI's credential file.
+func CredentialsPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".sprites", "credentials.json"), nil
+}
+
+// LoadToken returns an API token from TokenEnv, falling back to the sprite
+// CLI's credential file. An org-specific token is preferred when org is set.
+func LoadToken(org string) (string, error) {
+	if t := strings.TrimSpace(os.Getenv(TokenEnv)); t != "" {
+		return t, nil
+	}
+
+	path, err := CredentialsPath()
+	if err != nil {
+		return "", fmt.Errorf("locate sprite credentials: %w", err)
+	}
+	return loadTokenFile(path, org)
+}
+
+func loadTokenFile(path, org string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("no API token: set %s or run 'sprite login'", TokenEnv)
+		}
+		return "", fmt.Errorf("read sprite credentials: %w", err)
+	}
+
+	var creds credentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return "", fmt.Errorf("parse %s: %w", path, err)
+	}
+	if o, ok := creds.Orgs[org]; ok && org != "" && o.Token != "" {
+		return o.Token, nil
+	}
+	if creds.Token == "" {
+		return "", fmt.Errorf("no API token in %s: set %s or run 'sprite login'", path, TokenEnv)
+	}
+	return creds.Token, nil
+}
@@ -0,0 +1,214 @@
+package sprites
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestAPI starts an httptest server with handler and returns a client for it.
+func newTestAPI(t *testing.T, handler http.HandlerFunc) *API {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return &API{BaseURL: srv.URL, Token: "test-token", HTTPClient: srv.Client()}
+}
+
+func TestAPI_List(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/sprites" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("Authorization = %q, want bearer token", got)
+		}
+		w.Write([]byte(`{"sprites": [{"id": "sp1", "name": "web", "status": "running", "region": "ord"}]}`))
+	})
+
+	list, err := api.List(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("expected 1 sprite, got %d", len(list))
+	}
+	if list[0].Name != "web" || list[0].Status != StatusWorking || list[0].Region != "ord" {
+		t.Errorf("unexpected sprite: %+v", list[0])
+	}
+}
+
+func TestAPI_ErrorMapping(t *testing.T) {
+	tests := []struct {
+		status   int
+		body     string
+		sentinel error
+		message  string
+	}{
+		{http.StatusUnauthorized, `{"error": "token expired"}`, ErrUnauthorized, "token expired"},
+		{http.StatusForbidden, `{"message": "no access"}`, ErrUnauthorized, "no access"},
+		{http.StatusNotFound, `not here`, ErrNotFound, "not here"},
+		{http.StatusInternalServerError, ``, nil, ""},
+	}
+
+	for _, tt := range tests {
+		api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+			w.Write([]byte(tt.body))
+		})
+
+		_, err := api.List(context.Background())
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) {
+			t.Fatalf("status %d: expected *APIError, got %v", tt.status, err)
+		}
+		if apiErr.StatusCode != tt.status || apiErr.Message != tt.message {
+			t.Errorf("status %d: got %+v", tt.status, apiErr)
+		}
+		if tt.sentinel != nil && !errors.Is(err, tt.sentinel) {
+			t.Errorf("status %d: errors.Is(%v) = false", tt.status, tt.sentinel)
+		}
+	}
+}
+
+func TestAPI_Exec(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/sprites/web/exec" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		cmd := r.URL.Query()["cmd"]
+		if len(cmd) != 3 || cmd[0] != "sh" || cmd[2] != "echo hi" {
+			t.Errorf("unexpected cmd query: %v", cmd)
+		}
+		w.Write([]byte(`{"stdout": "hi\n", "stderr": "", "exit_code": 3}`))
+	})
+
+	res, err := api.Exec(context.Background(), "web", []string{"sh", "-c", "echo hi"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(res.Stdout) != "hi\n" || res.ExitCode != 3 {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+func TestLoadTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "credentials.json")
+	data := `{"token": "default-token", "orgs": {"acme": {"token": "acme-token"}}}`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		org  string
+		want string
+	}{
+		{"", "default-token"},
+		{"acme", "acme-token"},
+		{"other", "default-token"},
+	}
+	for _, tt := range tests {
+		got, err := loadTokenFile(path, tt.org)
+		if err != nil {
+			t.Fatalf("org %q: unexpected error: %v", tt.org, err)
+		}
+		if got != tt.want {
+			t.Errorf("org %q: token = %q, want %q", tt.org, got, tt.want)
+		}
+	}
+
+	if _, err := loadTokenFile(filepath.Join(dir, "missing.json"), ""); err == nil {
+		t.Error("expected error for missing credentials file")
+	}
+}
+
+func TestLoadToken_Env(t *testing.T) {
+	t.Setenv(TokenEnv, "env-token")
+	got, err := LoadToken("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "env-token" {
+		t.Errorf("token = %q, want %q", got, "env-token")
+	}
+}
+
+func TestAPI_Create(t *testing.T) {
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if r.Method != http.MethodPost || r.URL.Path != "/v1/sprites" || body["name"] != "web" || body["region"] != "ord" {
+			t.Errorf("unexpected request %s %s %v", r.Method, r.URL.Path, body)
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	if err := api.Create(context.Background(), "web", "ord"); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+}
+
+func TestAPI_CheckpointAndDestroy(t *testing.T) {
+	var got []string
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPost {
+			var body map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body["comment"] != "manual-20260205-143000" {
+				t.Errorf("unexpected checkpoint body: %v (%v)", body, err)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	ctx := context.Background()
+	if err := api.Checkpoint(ctx, "web", "manual-20260205-143000"); err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if err := api.Destroy(ctx, "web"); err != nil {
+		t.Fatalf("Destroy: %v", err)
+	}
+
+	want := []string{"POST /v1/sprites/web/checkpoints", "DELETE /v1/sprites/web"}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("requests = %v, want %v", got, want)
+	}
+}
+
+func TestAPI_ListAndRestoreCheckpoints(t *testing.T) {
+	var restored string
+	api := newTestAPI(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/sprites/web/checkpoints":
+			w.Write([]byte(`[{"id": "v1", "comment": "manual-20260205-100000", "created_at": "2026-02-05T10:00:00Z"}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/sprites/web/checkpoints/v1/restore":
+			restored = "v1"
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	ctx := context.Background()
+	cps, err := api.ListCheckpoints(ctx, "web")
+	if err != nil {
+		t.Fatalf("ListCheckpoints: %v", err)
+	}
+	if len(cps) != 1 || cps[0].ID != "v1" || cps[0].CreatedAt.IsZero() {
+		t.Errorf("unexpected checkpoints: %+v", cps)
+	}
+
+	if err := api.RestoreCheckpoint(ctx, "web", "v1"); err != nil {
+		t.Fatalf("RestoreCheckpoint: %v", err)
+	}
+	if restored != "v1" {
+		t.Errorf("restore endpoint not called")
+	}
+
+	if err := api.RestoreCheckpoint(ctx, "web", "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("restore of unknown checkpoint: got %v, want ErrNotFound", err)
+	}
+}
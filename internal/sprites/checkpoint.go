@@ -0,0 +1,157 @@
+package sprites
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Checkpoint name prefixes. Names follow the <prefix>-YYYYMMDD-HHMMSS
+// convention so manual and automatic snapshots are easy to tell apart.
+const (
+	CheckpointPrefixManual = "manual"
+	CheckpointPrefixAuto   = "auto"
+)
+
+// Defaults for automatic checkpoints.
+const (
+	DefaultAutoCheckpointKeep  = 5
+	DefaultAutoCheckpointDedup = 60 * time.Second
+)
+
+// Checkpoint is a saved snapshot of a Sprite's filesystem.
+type Checkpoint struct {
+	ID        string    `json:"id"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Label returns the checkpoint's comment, or its ID when it has none.
+func (c Checkpoint) Label() string {
+	if c.Comment != "" {
+		return c.Comment
+	}
+	return c.ID
+}
+
+// CheckpointName returns a checkpoint name like "manual-20260205-143000".
+func CheckpointName(prefix string, t time.Time) string {
+	return prefix + "-" + t.Format("20060102-150405")
+}
+
+// AutoCheckpointPolicy controls checkpoints taken when Claude Code finishes.
+type AutoCheckpointPolicy struct {
+	Enabled bool
+	// Keep is how many auto checkpoints to retain. Zero keeps all of them.
+	Keep int
+	// DedupWindow skips an auto checkpoint if one was taken this recently.
+	DedupWindow time.Duration
+}
+
+// AutoCheckpointPolicies holds the default policy and per-Sprite overrides.
+type AutoCheckpointPolicies struct {
+	Default   AutoCheckpointPolicy
+	PerSprite map[string]AutoCheckpointPolicy
+}
+
+// DefaultAutoCheckpointPolicies enables auto checkpoints for every Sprite.
+func DefaultAutoCheckpointPolicies() AutoCheckpointPolicies {
+	return AutoCheckpointPolicies{
+		Default: AutoCheckpointPolicy{
+			Enabled:     true,
+			Keep:        DefaultAutoCheckpointKeep,
+			DedupWindow: DefaultAutoCheckpointDedup,
+		},
+	}
+}
+
+// For returns the policy that applies to the named Sprite.
+func (p AutoCheckpointPolicies) For(name string) AutoCheckpointPolicy {
+	if sp, ok := p.PerSprite[name]; ok {
+		return sp
+	}
+	return p.Default
+}
+
+// ExpiredCheckpoints returns the checkpoints with the given prefix beyond
+// the newest keep. cps must be sorted newest first. Checkpoints with other
+// prefixes are never returned.
+func ExpiredCheckpoints(cps []Checkpoint, prefix string, keep int) []Checkpoint {
+	if keep <= 0 {
+		return nil
+	}
+	var expired []Checkpoint
+	seen := 0
+	for _, cp := range cps {
+		if !strings.HasPrefix(cp.Comment, prefix+"-") {
+			continue
+		}
+		seen++
+		if seen > keep {
+			expired = append(expired, cp)
+		}
+	}
+	return expired
+}
+
+// CheckpointAndPrune creates a checkpoint named with prefix and the current
+// time, then deletes the oldest prefix checkpoints so at most keep remain.
+// The returned comment is empty if the checkpoint itself failed; otherwise
+// any error came from pruning and the new checkpoint exists.
+func CheckpointAndPrune(ctx context.Context, src SpriteSource, name, prefix string, keep int) (comment string, pruned int, err error) {
+	comment = CheckpointName(prefix, time.Now())
+	if err := src.Checkpoint(ctx, name, comment); err != nil {
+		return "", 0, err
+	}
+	if keep <= 0 {
+		return comment, 0, nil
+	}
+
+	cps, err := src.ListCheckpoints(ctx, name)
+	if err != nil {
+		return comment, 0, fmt.Errorf("prune: %w", err)
+	}
+	for _, cp := range ExpiredCheckpoints(cps, prefix, keep) {
+		if err := src.DeleteCheckpoint(ctx, name, cp.ID); err != nil {
+			return comment, pruned, fmt.Errorf("prune %s: %w", cp.Label(), err)
+		}
+		pruned++
+	}
+	return comment, pruned, nil
+}
+
+// apiCheckpoint matches the JSON structure returned by the checkpoints API.
+type apiCheckpoint struct {
+	ID        string `json:"id"`
+	Comment   string `json:"comment"`
+	CreatedAt string `json:"created_at"`
+}
+
+// parseCheckpointsJSON parses a checkpoint list response, newest first.
+func parseCheckpointsJSON(data []byte) ([]Checkpoint, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var apiCheckpoints []apiCheckpoint
+	if err := decodeList(data, "checkpoints", &apiCheckpoints); err != nil {
+		return nil, err
+	}
+
+	checkpoints := make([]Checkpoint, len(apiCheckpoints))
+	for i, ac := range apiCheckpoints {
+		checkpoints[i] = Checkpoint{
+			ID:        ac.ID,
+			Comment:   ac.Comment,
+			CreatedAt: parseTime(ac.CreatedAt),
+		}
+	}
+	sort.SliceStable(checkpoints, func(i, j int) bool {
+		return checkpoints[i].CreatedAt.After(checkpoints[j].CreatedAt)
+	})
+	return checkpoints, nil
+}
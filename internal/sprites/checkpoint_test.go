@@ -0,0 +1,108 @@
+package sprites
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeCheckpointSource records checkpoint calls for CheckpointAndPrune.
+type fakeCheckpointSource struct {
+	checkpoints   []Checkpoint
+	checkpointErr error
+	deleted       []string
+}
+
+func (f *fakeCheckpointSource) List(context.Context) ([]Sprite, error)       { return nil, nil }
+func (f *fakeCheckpointSource) Create(context.Context, string, string) error { return nil }
+func (f *fakeCheckpointSource) Exec(context.Context, string, []string) (ExecResult, error) {
+	return ExecResult{}, nil
+}
+func (f *fakeCheckpointSource) ListSessions(context.Context, string) ([]ExecSession, error) {
+	return nil, nil
+}
+func (f *fakeCheckpointSource) ListServices(context.Context, string) ([]Service, error) {
+	return nil, nil
+}
+func (f *fakeCheckpointSource) ServiceLogs(context.Context, string, string) ([]byte, error) {
+	return nil, nil
+}
+func (f *fakeCheckpointSource) ConsoleCmd(name string) *exec.Cmd   { return exec.Command("true") }
+func (f *fakeCheckpointSource) AttachCmd(string, string) *exec.Cmd { return exec.Command("true") }
+func (f *fakeCheckpointSource) KillSession(context.Context, string, string) error {
+	return nil
+}
+func (f *fakeCheckpointSource) Destroy(context.Context, string) error { return nil }
+func (f *fakeCheckpointSource) RestoreCheckpoint(context.Context, string, string) error {
+	return nil
+}
+
+func (f *fakeCheckpointSource) Checkpoint(_ context.Context, _, comment string) error {
+	if f.checkpointErr != nil {
+		return f.checkpointErr
+	}
+	cp := Checkpoint{ID: comment, Comment: comment, CreatedAt: time.Now()}
+	f.checkpoints = append([]Checkpoint{cp}, f.checkpoints...)
+	return nil
+}
+
+func (f *fakeCheckpointSource) ListCheckpoints(context.Context, string) ([]Checkpoint, error) {
+	return f.checkpoints, nil
+}
+
+func (f *fakeCheckpointSource) DeleteCheckpoint(_ context.Context, _, id string) error {
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func TestExpiredCheckpoints(t *testing.T) {
+	cps := []Checkpoint{
+		{ID: "a3", Comment: "auto-20260205-130000"},
+		{ID: "m1", Comment: "manual-20260205-125000"},
+		{ID: "a2", Comment: "auto-20260205-120000"},
+		{ID: "a1", Comment: "auto-20260205-110000"},
+		{ID: "x", Comment: "automatic-but-not-ours"},
+	}
+
+	got := ExpiredCheckpoints(cps, CheckpointPrefixAuto, 2)
+	if len(got) != 1 || got[0].ID != "a1" {
+		t.Errorf("ExpiredCheckpoints(keep=2) = %+v, want [a1]", got)
+	}
+	if got := ExpiredCheckpoints(cps, CheckpointPrefixAuto, 0); got != nil {
+		t.Errorf("keep=0 should retain everything, got %+v", got)
+	}
+}
+
+func TestCheckpointAndPrune(t *testing.T) {
+	src := &fakeCheckpointSource{checkpoints: []Checkpoint{
+		{ID: "old2", Comment: "auto-20260205-120000"},
+		{ID: "keep", Comment: "manual-20260205-110000"},
+		{ID: "old1", Comment: "auto-20260205-100000"},
+	}}
+
+	comment, pruned, err := CheckpointAndPrune(context.Background(), src, "web", CheckpointPrefixAuto, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(comment, "auto-") {
+		t.Errorf("comment = %q, want auto- prefix", comment)
+	}
+	if pruned != 1 || len(src.deleted) != 1 || src.deleted[0] != "old1" {
+		t.Errorf("pruned %d, deleted %v; want only old1", pruned, src.deleted)
+	}
+}
+
+func TestCheckpointAndPrune_CheckpointFailure(t *testing.T) {
+	src := &fakeCheckpointSource{checkpointErr: errors.New("quota")}
+
+	comment, _, err := CheckpointAndPrune(context.Background(), src, "web", CheckpointPrefixAuto, 2)
+	if err == nil || comment != "" {
+		t.Errorf("got comment %q err %v; want empty comment and error", comment, err)
+	}
+	if len(src.deleted) != 0 {
+		t.Errorf("nothing should be pruned after a failed checkpoint")
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os/exec"
 	"strings"
 	"time"
@@ -12,14 +14,15 @@ import (
 
 // Status constants used across the codebase.
 const (
-	StatusWorking     = "WORKING"
-	StatusSleeping    = "SLEEPING"
-	StatusFinished    = "FINISHED"
-	StatusWaiting     = "WAITING"
-	StatusError       = "ERROR"
-	StatusUnreachable = "UNREACHABLE"
-	StatusDestroying  = "DESTROYING"
-	StatusCreating    = "CREATING"
+	StatusWorking       = "WORKING"
+	StatusSleeping      = "SLEEPING"
+	StatusFinished      = "FINISHED"
+	StatusWaiting       = "WAITING"
+	StatusError         = "ERROR"
+	StatusUnreachable   = "UNREACHABLE"
+	StatusDestroying    = "DESTROYING"
+	StatusCreating      = "CREATING"
+	StatusCheckpointing = "CHECKPOINTING"
 )
 
 // Sprite represents a remote Fly.io Sprite instance.
@@ -29,6 +32,9 @@ type Sprite struct {
 	Status    string    `json:"status"`
 	CreatedAt time.Time `json:"created_at"`
 	Region    string    `json:"region"`
+	// Org is the organization the Sprite was listed in, set when listing
+	// several organizations.
+	Org string `json:"org,omitempty"`
 }
 
 // Uptime returns the duration since the Sprite was created.
@@ -57,12 +63,28 @@ func (s Sprite) FormatUptime() string {
 type CLI struct {
 	// Org specifies the organization to use. Empty for default.
 	Org string
+	// ListTimeout bounds list calls. Zero means ListTimeout.
+	ListTimeout time.Duration
 }
 
 var _ SpriteSource = (*CLI)(nil)
 
-// ListTimeout is the default timeout for CLI.List operations.
-const ListTimeout = 10 * time.Second
+// Default timeouts for Sprite operations.
+const (
+	ListTimeout       = 10 * time.Second
+	CreateTimeout     = 2 * time.Minute
+	CheckpointTimeout = 30 * time.Second
+	RestoreTimeout    = 60 * time.Second
+	DestroyTimeout    = 15 * time.Second
+)
+
+// orDefault returns d, or def when d is not positive.
+func orDefault(d, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}
 
 // spriteCmd builds a sprite command with org flag if set.
 func (c *CLI) spriteCmd(ctx context.Context, args ...string) *exec.Cmd {
@@ -72,13 +94,10 @@ func (c *CLI) spriteCmd(ctx context.Context, args ...string) *exec.Cmd {
 	return exec.CommandContext(ctx, "sprite", args...)
 }
 
-// List returns all Sprites in the configured organization.
-// It uses `sprite api /sprites` to get JSON output.
-func (c *CLI) List(ctx context.Context) ([]Sprite, error) {
-	ctx, cancel := context.WithTimeout(ctx, ListTimeout)
-	defer cancel()
-
-	cmd := c.spriteCmd(ctx, "api", "/sprites")
+// run executes a sprite command and returns its stdout. On failure the
+// error carries the command's stderr.
+func (c *CLI) run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := c.spriteCmd(ctx, args...)
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -88,10 +107,85 @@ func (c *CLI) List(ctx context.Context) ([]Sprite, error) {
 		if errMsg == "" {
 			errMsg = err.Error()
 		}
-		return nil, fmt.Errorf("sprite api /sprites: %s", errMsg)
+		return nil, fmt.Errorf("sprite %s: %s", strings.Join(args, " "), errMsg)
+	}
+	return stdout.Bytes(), nil
+}
+
+// List returns all Sprites in the configured organization.
+// It uses `sprite api /sprites` to get JSON output.
+func (c *CLI) List(ctx context.Context) ([]Sprite, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(c.ListTimeout, ListTimeout))
+	defer cancel()
+
+	out, err := c.run(ctx, "api", "/sprites")
+	if err != nil {
+		return nil, err
+	}
+	return parseSpritesJSON(out)
+}
+
+// Create creates a Sprite called name in region. An empty region lets
+// Sprites pick one.
+func (c *CLI) Create(ctx context.Context, name, region string) error {
+	ctx, cancel := context.WithTimeout(ctx, CreateTimeout)
+	defer cancel()
+
+	args := []string{"create"}
+	if region != "" {
+		args = append(args, "--region", region)
 	}
+	_, err := c.run(ctx, append(args, name)...)
+	return err
+}
 
-	return parseSpritesJSON(stdout.Bytes())
+// Checkpoint snapshots the named Sprite with the given comment.
+func (c *CLI) Checkpoint(ctx context.Context, name, comment string) error {
+	ctx, cancel := context.WithTimeout(ctx, CheckpointTimeout)
+	defer cancel()
+
+	_, err := c.run(ctx, "checkpoint", "create", "-s", name, "--comment", comment)
+	return err
+}
+
+// ListCheckpoints returns the named Sprite's checkpoints, newest first.
+func (c *CLI) ListCheckpoints(ctx context.Context, name string) ([]Checkpoint, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(c.ListTimeout, ListTimeout))
+	defer cancel()
+
+	out, err := c.run(ctx, "api", cliPath(name, "checkpoints"))
+	if err != nil {
+		return nil, err
+	}
+	return parseCheckpointsJSON(out)
+}
+
+// RestoreCheckpoint rolls the named Sprite back to checkpoint id.
+func (c *CLI) RestoreCheckpoint(ctx context.Context, name, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, RestoreTimeout)
+	defer cancel()
+
+	_, err := c.run(ctx, "restore", "-s", name, id)
+	return err
+}
+
+// DeleteCheckpoint removes checkpoint id from the named Sprite.
+func (c *CLI) DeleteCheckpoint(ctx context.Context, name, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, CheckpointTimeout)
+	defer cancel()
+
+	_, err := c.run(ctx, "checkpoint", "delete", "-s", name, id)
+	return err
+}
+
+// Destroy permanently deletes the named Sprite. The CLI's own confirmation
+// prompt is skipped; callers are expected to confirm first.
+func (c *CLI) Destroy(ctx context.Context, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, DestroyTimeout)
+	defer cancel()
+
+	_, err := c.run(ctx, "destroy", "-s", name, "--force")
+	return err
 }
 
 // apiSprite matches the JSON structure returned by the Sprites API.
@@ -112,50 +206,69 @@ func parseSpritesJSON(data []byte) ([]Sprite, error) {
 	}
 
 	var apiSprites []apiSprite
-
-	// Try array first
-	if data[0] == '[' {
-		if err := json.Unmarshal(data, &apiSprites); err != nil {
-			return nil, fmt.Errorf("parse sprites JSON array: %w", err)
-		}
-	} else {
-		// Try object with data/sprites key
-		var wrapper map[string]json.RawMessage
-		if err := json.Unmarshal(data, &wrapper); err != nil {
-			return nil, fmt.Errorf("parse sprites JSON: %w", err)
-		}
-		// Try common keys
-		for _, key := range []string{"data", "sprites"} {
-			if raw, ok := wrapper[key]; ok {
-				if err := json.Unmarshal(raw, &apiSprites); err == nil {
-					break
-				}
-			}
-		}
-		if apiSprites == nil {
-			return nil, fmt.Errorf("unexpected API response format")
-		}
+	if err := decodeList(data, "sprites", &apiSprites); err != nil {
+		return nil, err
 	}
 
 	sprites := make([]Sprite, len(apiSprites))
 	for i, as := range apiSprites {
-		var createdAt time.Time
-		if as.CreatedAt != "" {
-			if t, err := time.Parse(time.RFC3339Nano, as.CreatedAt); err == nil {
-				createdAt = t
-			}
-		}
 		sprites[i] = Sprite{
 			ID:        as.ID,
 			Name:      as.Name,
 			Status:    normalizeStatus(as.Status),
-			CreatedAt: createdAt,
+			CreatedAt: parseTime(as.CreatedAt),
 			Region:    as.Region,
 		}
 	}
 	return sprites, nil
 }
 
+// decodeList decodes a list response into v. The API returns either a
+// bare JSON array or an object holding the array under "data" or under
+// key, such as "sprites".
+func decodeList(data []byte, key string, v any) error {
+	// Try array first
+	if data[0] == '[' {
+		if err := json.Unmarshal(data, v); err != nil {
+			return fmt.Errorf("parse %s JSON array: %w", key, err)
+		}
+		return nil
+	}
+
+	// Try object with data/<key> key
+	var wrapper map[string]json.RawMessage
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return fmt.Errorf("parse %s JSON: %w", key, err)
+	}
+	for _, k := range []string{"data", key} {
+		if raw, ok := wrapper[k]; ok {
+			if err := json.Unmarshal(raw, v); err == nil {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unexpected API response format")
+}
+
+// cliPath returns the `sprite api` path for a Sprite sub-resource, which
+// the CLI resolves against the API's versioned base.
+func cliPath(name string, parts ...string) string {
+	return strings.TrimPrefix(spritePath(name, parts...), "/v1")
+}
+
+// parseTime parses an RFC 3339 API timestamp, returning the zero time for
+// empty or malformed values.
+func parseTime(s string) time.Time {
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
 // normalizeStatus maps API status strings to display-friendly states.
 func normalizeStatus(s string) string {
 	switch strings.ToLower(s) {
@@ -175,12 +288,108 @@ func normalizeStatus(s string) string {
 	}
 }
 
+// ExecResult holds the output of a command run on a Sprite.
+type ExecResult struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+}
+
+// Exec runs command on the named Sprite via `sprite exec` and waits for it
+// to finish. A non-zero exit status is reported in ExecResult.ExitCode rather
+// than as an error; errors mean the command could not be run at all.
+// Callers should bound ctx with a timeout.
+func (c *CLI) Exec(ctx context.Context, name string, command []string) (ExecResult, error) {
+	var stdout, stderr bytes.Buffer
+	code, err := c.ExecStream(ctx, name, command, &stdout, &stderr)
+	return ExecResult{Stdout: stdout.Bytes(), Stderr: stderr.Bytes(), ExitCode: code}, err
+}
+
+// ExecStream is like Exec but copies output to stdout and stderr as the
+// command produces it.
+func (c *CLI) ExecStream(ctx context.Context, name string, command []string, stdout, stderr io.Writer) (int, error) {
+	args := append([]string{"exec", "-s", name, "--"}, command...)
+	cmd := c.spriteCmd(ctx, args...)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	// Stop waiting for output a child process still holds open once ctx
+	// is done.
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && ctx.Err() == nil {
+			return exitErr.ExitCode(), nil
+		}
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("sprite exec %s: %w", name, ctx.Err())
+		}
+		return 0, fmt.Errorf("sprite exec %s: %w", name, err)
+	}
+	return 0, nil
+}
+
+// ListSessions returns the exec sessions running on the named Sprite,
+// oldest first.
+func (c *CLI) ListSessions(ctx context.Context, name string) ([]ExecSession, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(c.ListTimeout, ListTimeout))
+	defer cancel()
+
+	out, err := c.run(ctx, "api", cliPath(name, "exec", "sessions"))
+	if err != nil {
+		return nil, err
+	}
+	return parseSessionsJSON(out)
+}
+
+// KillSession ends exec session id on the named Sprite.
+func (c *CLI) KillSession(ctx context.Context, name, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(c.ListTimeout, ListTimeout))
+	defer cancel()
+
+	_, err := c.run(ctx, "sessions", "kill", "-s", name, id)
+	return err
+}
+
+// ListServices returns the services defined on the named Sprite.
+func (c *CLI) ListServices(ctx context.Context, name string) ([]Service, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(c.ListTimeout, ListTimeout))
+	defer cancel()
+
+	out, err := c.run(ctx, "api", cliPath(name, "services"))
+	if err != nil {
+		return nil, err
+	}
+	return parseServicesJSON(out)
+}
+
+// ServiceLogs returns the recent log output of service id.
+func (c *CLI) ServiceLogs(ctx context.Context, name, id string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, orDefault(c.ListTimeout, ListTimeout))
+	defer cancel()
+
+	out, err := c.run(ctx, "api", cliPath(name, "services", id, "logs"))
+	if err != nil {
+		return nil, err
+	}
+	return parseServiceLogs(out), nil
+}
+
 // ConsoleCmd returns an *exec.Cmd for `sprite console -s <name>`.
 // The caller is responsible for setting Stdin/Stdout/Stderr and running it.
 func (c *CLI) ConsoleCmd(name string) *exec.Cmd {
 	return c.spriteCmd(context.Background(), "console", "-s", name)
 }
 
+// AttachCmd returns an *exec.Cmd for `sprite attach -s <name> <id>`, which
+// connects the terminal to a running exec session instead of starting a
+// new shell. The caller is responsible for setting Stdin/Stdout/Stderr and
+// running it.
+func (c *CLI) AttachCmd(name, id string) *exec.Cmd {
+	return c.spriteCmd(context.Background(), "attach", "-s", name, id)
+}
+
 // CheckSpriteCLI verifies that the sprite CLI is installed and accessible.
 func CheckSpriteCLI() error {
 	_, err := exec.LookPath("sprite")
@@ -144,3 +144,82 @@ func TestSprite_FormatUptime_HoursFormat(t *testing.T) {
 		t.Errorf("FormatUptime() = %q, want %q", got, "5h 03m")
 	}
 }
+
+func TestCheckpointName(t *testing.T) {
+	ts := time.Date(2026, 2, 5, 14, 30, 0, 0, time.UTC)
+	got := CheckpointName(CheckpointPrefixManual, ts)
+	if got != "manual-20260205-143000" {
+		t.Errorf("CheckpointName() = %q, want %q", got, "manual-20260205-143000")
+	}
+}
+
+func TestParseCheckpointsJSON(t *testing.T) {
+	data := `{"checkpoints": [
+		{"id": "v1", "comment": "manual-20260205-100000", "created_at": "2026-02-05T10:00:00Z"},
+		{"id": "v2", "comment": "", "created_at": "2026-02-05T12:00:00Z"}
+	]}`
+
+	cps, err := parseCheckpointsJSON([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cps) != 2 {
+		t.Fatalf("expected 2 checkpoints, got %d", len(cps))
+	}
+	if cps[0].ID != "v2" {
+		t.Errorf("expected newest checkpoint first, got %q", cps[0].ID)
+	}
+	if cps[0].Label() != "v2" {
+		t.Errorf("Label() without comment = %q, want ID", cps[0].Label())
+	}
+	if cps[1].Label() != "manual-20260205-100000" {
+		t.Errorf("Label() = %q, want comment", cps[1].Label())
+	}
+}
+
+func TestParseSessionsJSON(t *testing.T) {
+	data := `{"sessions": [
+		{"id": 12, "command": ["claude", "--resume"], "created_at": "2026-02-05T12:00:00Z", "tty": true},
+		{"id": "9", "command": "npm run dev", "created_at": "2026-02-05T10:00:00Z"}
+	]}`
+
+	sessions, err := parseSessionsJSON([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(sessions))
+	}
+	if sessions[0].ID != "9" || sessions[0].Command != "npm run dev" {
+		t.Errorf("expected oldest session first, got %+v", sessions[0])
+	}
+	if sessions[1].ID != "12" || sessions[1].Command != "claude --resume" || !sessions[1].TTY {
+		t.Errorf("numeric ID and argv command: got %+v", sessions[1])
+	}
+}
+
+func TestParseServicesJSON(t *testing.T) {
+	services, err := parseServicesJSON([]byte(`[{"id": 3, "name": "dev-server", "status": "running"}, {"id": "svc-b"}]`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(services) != 2 || services[0].ID != "3" || services[0].Label() != "dev-server" || services[1].Label() != "svc-b" {
+		t.Errorf("got %+v", services)
+	}
+}
+
+func TestParseServiceLogs(t *testing.T) {
+	if got := string(parseServiceLogs([]byte("plain\ntext\n"))); got != "plain\ntext\n" {
+		t.Errorf("plain text = %q", got)
+	}
+	got := string(parseServiceLogs([]byte(`{"logs": [{"message": "listening\n"}, {"line": "GET /"}]}`)))
+	if got != "listening\nGET /\n" {
+		t.Errorf("JSON entries = %q", got)
+	}
+}
+
+func TestCLIPath(t *testing.T) {
+	if got, want := cliPath("a b/c", "services", "x?y", "logs"), "/sprites/a%20b%2Fc/services/x%3Fy/logs"; got != want {
+		t.Errorf("cliPath() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package sprites
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// ReadyTimeout bounds how long WaitReady waits for a new Sprite.
+const ReadyTimeout = 3 * time.Minute
+
+// readyInterval is how often WaitReady lists Sprites.
+var readyInterval = 2 * time.Second
+
+var nameRE = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// ValidateName reports whether name can be used for a new Sprite: lowercase
+// letters, digits and hyphens, not starting or ending with a hyphen.
+func ValidateName(name string) error {
+	if name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !nameRE.MatchString(name) {
+		return fmt.Errorf("invalid name %q: use lowercase letters, digits and hyphens", name)
+	}
+	return nil
+}
+
+// WaitReady lists Sprites until name appears and is no longer CREATING,
+// or ReadyTimeout passes.
+func WaitReady(ctx context.Context, src SpriteSource, name string) error {
+	ctx, cancel := context.WithTimeout(ctx, ReadyTimeout)
+	defer cancel()
+
+	tick := time.NewTicker(readyInterval)
+	defer tick.Stop()
+	for {
+		list, err := src.List(ctx)
+		if err == nil {
+			for _, s := range list {
+				if s.Name == name && s.Status != StatusCreating {
+					return nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("waiting for %s: %w", name, err)
+			}
+			return fmt.Errorf("waiting for %s: %w", name, ctx.Err())
+		case <-tick.C:
+		}
+	}
+}
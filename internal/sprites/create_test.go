@@ -0,0 +1,16 @@
+package sprites
+
+import "testing"
+
+func TestValidateName(t *testing.T) {
+	for _, name := range []string{"web", "api-2", "a"} {
+		if err := ValidateName(name); err != nil {
+			t.Errorf("ValidateName(%q) = %v", name, err)
+		}
+	}
+	for _, name := range []string{"", "Web", "-web", "web-", "my_sprite", "a b"} {
+		if err := ValidateName(name); err == nil {
+			t.Errorf("ValidateName(%q) should fail", name)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package sprites
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Multi is a SpriteSource spanning several organizations. List lists them
+// concurrently and tags each Sprite with its Org; every other call goes to
+// the organization the named Sprite was listed in.
+//
+// Sprites are listed as "org/name", so a Sprite keeps its name whatever
+// other organizations hold. Other calls also take a bare name when only
+// one organization has a Sprite of that name.
+type Multi struct {
+	orgs    []string
+	sources map[string]SpriteSource
+
+	mu   sync.Mutex
+	last map[string][]Sprite // last successful list per org, names bare
+	errs map[string]error    // last list error per org
+}
+
+var (
+	_ SpriteSource = (*Multi)(nil)
+	_ StreamExecer = (*Multi)(nil)
+)
+
+// NewMulti returns a Multi over sources, keyed by organization and listed
+// in the order of orgs. The first organization is where new Sprites are
+// created unless named otherwise.
+func NewMulti(orgs []string, sources map[string]SpriteSource) *Multi {
+	return &Multi{
+		orgs:    orgs,
+		sources: sources,
+		last:    make(map[string][]Sprite),
+		errs:    make(map[string]error),
+	}
+}
+
+// Orgs returns the organizations in display order.
+func (m *Multi) Orgs() []string {
+	return m.orgs
+}
+
+// In returns the source for org alone, which takes bare names, or for the
+// first organization if org is empty. It returns nil for an organization
+// m does not span.
+func (m *Multi) In(org string) SpriteSource {
+	if org == "" {
+		org = m.orgs[0]
+	}
+	return m.sources[org]
+}
+
+// Qualify returns the name m lists name by, "org/name".
+func (m *Multi) Qualify(ctx context.Context, name string) (string, error) {
+	org, bare, err := m.route(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	return org + "/" + bare, nil
+}
+
+// OrgErrors returns the error of each organization whose last List failed.
+// Its Sprites are those from the last List that succeeded.
+func (m *Multi) OrgErrors() map[string]error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	errs := make(map[string]error, len(m.errs))
+	for org, err := range m.errs {
+		errs[org] = err
+	}
+	return errs
+}
+
+// List returns the Sprites of every organization, grouped in org order.
+// An organization that cannot be listed contributes its last known
+// Sprites; List fails only when every organization does.
+func (m *Multi) List(ctx context.Context) ([]Sprite, error) {
+	lists := make([][]Sprite, len(m.orgs))
+	errs := make([]error, len(m.orgs))
+	var wg sync.WaitGroup
+	for i, org := range m.orgs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			lists[i], errs[i] = m.sources[org].List(ctx)
+		}()
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var failed []error
+	for i, org := range m.orgs {
+		if errs[i] != nil {
+			m.errs[org] = errs[i]
+			failed = append(failed, fmt.Errorf("%s: %w", org, errs[i]))
+			continue
+		}
+		delete(m.errs, org)
+		for j := range lists[i] {
+			lists[i][j].Org = org
+		}
+		m.last[org] = lists[i]
+	}
+	if len(failed) == len(m.orgs) {
+		return nil, errors.Join(failed...)
+	}
+
+	var all []Sprite
+	for _, org := range m.orgs {
+		for _, s := range m.last[org] {
+			s.Name = org + "/" + s.Name
+			all = append(all, s)
+		}
+	}
+	return all, nil
+}
+
+// route returns the organization owning name and the name it knows the
+// Sprite by. Bare names not seen yet are looked up with a List.
+func (m *Multi) route(ctx context.Context, name string) (string, string, error) {
+	if org, rest, ok := strings.Cut(name, "/"); ok {
+		if _, ok := m.sources[org]; ok {
+			return org, rest, nil
+		}
+	}
+	owners := m.owners(name)
+	if len(owners) == 0 {
+		if _, err := m.List(ctx); err != nil {
+			return "", "", err
+		}
+		owners = m.owners(name)
+	}
+	switch len(owners) {
+	case 0:
+		return "", "", fmt.Errorf("no Sprite %s in %s", name, strings.Join(m.orgs, ", "))
+	case 1:
+		return owners[0], name, nil
+	}
+	return "", "", fmt.Errorf("%s is in several organizations; use org/%s", name, name)
+}
+
+// owners returns the organizations whose last list has name.
+func (m *Multi) owners(name string) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var orgs []string
+	for _, org := range m.orgs {
+		for _, s := range m.last[org] {
+			if s.Name == name {
+				orgs = append(orgs, org)
+				break
+			}
+		}
+	}
+	return orgs
+}
+
+// source is route returning the owning source itself.
+func (m *Multi) source(ctx context.Context, name string) (SpriteSource, string, error) {
+	org, bare, err := m.route(ctx, name)
+	if err != nil {
+		return nil, "", err
+	}
+	return m.sources[org], bare, nil
+}
+
+// Create creates name in the first organization. In(org).Create creates
+// it in another.
+func (m *Multi) Create(ctx context.Context, name, region string) error {
+	return m.In("").Create(ctx, name, region)
+}
+
+// Checkpoint checkpoints name in its organization.
+func (m *Multi) Checkpoint(ctx context.Context, name, comment string) error {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return err
+	}
+	return src.Checkpoint(ctx, bare, comment)
+}
+
+// ListCheckpoints lists name's checkpoints in its organization.
+func (m *Multi) ListCheckpoints(ctx context.Context, name string) ([]Checkpoint, error) {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return src.ListCheckpoints(ctx, bare)
+}
+
+// RestoreCheckpoint restores name in its organization.
+func (m *Multi) RestoreCheckpoint(ctx context.Context, name, id string) error {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return err
+	}
+	return src.RestoreCheckpoint(ctx, bare, id)
+}
+
+// DeleteCheckpoint deletes one of name's checkpoints in its organization.
+func (m *Multi) DeleteCheckpoint(ctx context.Context, name, id string) error {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return err
+	}
+	return src.DeleteCheckpoint(ctx, bare, id)
+}
+
+// Destroy destroys name in its organization.
+func (m *Multi) Destroy(ctx context.Context, name string) error {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return err
+	}
+	return src.Destroy(ctx, bare)
+}
+
+// Exec runs command on name in its organization.
+func (m *Multi) Exec(ctx context.Context, name string, command []string) (ExecResult, error) {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return ExecResult{}, err
+	}
+	return src.Exec(ctx, bare, command)
+}
+
+// ExecStream streams when the owning source can, and otherwise copies the
+// output of Exec once the command is done.
+func (m *Multi) ExecStream(ctx context.Context, name string, command []string, stdout, stderr io.Writer) (int, error) {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if se, ok := src.(StreamExecer); ok {
+		return se.ExecStream(ctx, bare, command, stdout, stderr)
+	}
+	res, err := src.Exec(ctx, bare, command)
+	stdout.Write(res.Stdout)
+	stderr.Write(res.Stderr)
+	return res.ExitCode, err
+}
+
+// ListSessions lists name's exec sessions in its organization.
+func (m *Multi) ListSessions(ctx context.Context, name string) ([]ExecSession, error) {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return src.ListSessions(ctx, bare)
+}
+
+// KillSession ends one of name's exec sessions in its organization.
+func (m *Multi) KillSession(ctx context.Context, name, id string) error {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return err
+	}
+	return src.KillSession(ctx, bare, id)
+}
+
+// ListServices lists name's services in its organization.
+func (m *Multi) ListServices(ctx context.Context, name string) ([]Service, error) {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return src.ListServices(ctx, bare)
+}
+
+// ServiceLogs reads a service log of name in its organization.
+func (m *Multi) ServiceLogs(ctx context.Context, name, id string) ([]byte, error) {
+	src, bare, err := m.source(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return src.ServiceLogs(ctx, bare, id)
+}
+
+// ConsoleCmd returns the owning organization's console command. A name
+// that cannot be routed is tried in the first organization, whose CLI
+// reports the problem.
+func (m *Multi) ConsoleCmd(name string) *exec.Cmd {
+	return m.cmdSource(name).ConsoleCmd(m.bare(name))
+}
+
+// AttachCmd is like ConsoleCmd for an exec session.
+func (m *Multi) AttachCmd(name, id string) *exec.Cmd {
+	return m.cmdSource(name).AttachCmd(m.bare(name), id)
+}
+
+func (m *Multi) cmdSource(name string) SpriteSource {
+	src, _, err := m.source(context.Background(), name)
+	if err != nil {
+		return m.sources[m.orgs[0]]
+	}
+	return src
+}
+
+func (m *Multi) bare(name string) string {
+	if org, rest, ok := strings.Cut(name, "/"); ok {
+		if _, ok := m.sources[org]; ok {
+			return rest
+		}
+	}
+	return name
+}
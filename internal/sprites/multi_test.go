@@ -0,0 +1,107 @@
+package sprites
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// orgSource is one organization's Sprites for Multi tests. Calls other
+// than List, Create and Destroy are not used.
+type orgSource struct {
+	SpriteSource
+	names     []string
+	err       error
+	destroyed []string
+}
+
+func (o *orgSource) Create(_ context.Context, name, _ string) error {
+	o.names = append(o.names, name)
+	return nil
+}
+
+func (o *orgSource) List(context.Context) ([]Sprite, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+	list := make([]Sprite, len(o.names))
+	for i, n := range o.names {
+		list[i] = Sprite{Name: n, Status: StatusWorking}
+	}
+	return list, nil
+}
+
+func (o *orgSource) Destroy(_ context.Context, name string) error {
+	o.destroyed = append(o.destroyed, name)
+	return nil
+}
+
+func TestMulti(t *testing.T) {
+	personal := &orgSource{names: []string{"blog", "web"}}
+	work := &orgSource{names: []string{"api", "web"}}
+	m := NewMulti([]string{"personal", "work"}, map[string]SpriteSource{"personal": personal, "work": work})
+	ctx := context.Background()
+
+	list, err := m.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range list {
+		got = append(got, s.Org+":"+s.Name)
+	}
+	if strings.Join(got, " ") != "personal:personal/blog personal:personal/web work:work/api work:work/web" {
+		t.Errorf("list = %v, want grouped by org, every name qualified", got)
+	}
+
+	// A Sprite keeps its name when another organization gets one with
+	// the same name.
+	if err := m.In("work").Create(ctx, "blog", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Create(ctx, "docs", ""); err != nil {
+		t.Fatal(err)
+	}
+	list, _ = m.List(ctx)
+	got = got[:0]
+	for _, s := range list {
+		got = append(got, s.Name)
+	}
+	if strings.Join(got, " ") != "personal/blog personal/web personal/docs work/api work/web work/blog" {
+		t.Errorf("list = %v, want new Sprites in the chosen org and names unchanged", got)
+	}
+	if key, err := m.Qualify(ctx, "api"); err != nil || key != "work/api" {
+		t.Errorf("Qualify(api) = %q, %v", key, err)
+	}
+	personal.names, work.names = []string{"blog", "web"}, []string{"api", "web"}
+	if _, err := m.List(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"blog", "api", "work/web"} {
+		if err := m.Destroy(ctx, name); err != nil {
+			t.Errorf("Destroy(%s): %v", name, err)
+		}
+	}
+	if strings.Join(personal.destroyed, ",") != "blog" || strings.Join(work.destroyed, ",") != "api,web" {
+		t.Errorf("destroyed personal %v, work %v", personal.destroyed, work.destroyed)
+	}
+	if err := m.Destroy(ctx, "web"); err == nil || !strings.Contains(err.Error(), "use org/web") {
+		t.Errorf("ambiguous name: err = %v", err)
+	}
+
+	// An unreachable org keeps its last known Sprites and reports why.
+	work.err = errors.New("unauthorized")
+	list, err = m.List(ctx)
+	if err != nil || len(list) != 4 {
+		t.Fatalf("partial failure: %d Sprites, err %v", len(list), err)
+	}
+	if errs := m.OrgErrors(); len(errs) != 1 || errs["work"] == nil {
+		t.Errorf("OrgErrors() = %v", errs)
+	}
+	personal.err = errors.New("offline")
+	if _, err := m.List(ctx); err == nil || !strings.Contains(err.Error(), "work: unauthorized") {
+		t.Errorf("all orgs failing: err = %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package sprites
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Service is a long-running process supervised by the Sprite, such as a
+// dev server.
+type Service struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// Label returns the service's name, or its ID when it has none.
+func (s Service) Label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return s.ID
+}
+
+// apiService matches the JSON returned by the services endpoint.
+type apiService struct {
+	ID     json.RawMessage `json:"id"`
+	Name   string          `json:"name"`
+	Status string          `json:"status"`
+}
+
+// parseServicesJSON parses a service list response.
+func parseServicesJSON(data []byte) ([]Service, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var apiServices []apiService
+	if err := decodeList(data, "services", &apiServices); err != nil {
+		return nil, err
+	}
+
+	services := make([]Service, len(apiServices))
+	for i, as := range apiServices {
+		services[i] = Service{
+			ID:     strings.Trim(string(as.ID), `"`),
+			Name:   as.Name,
+			Status: as.Status,
+		}
+	}
+	return services, nil
+}
+
+// apiLogLine is one entry of a JSON service log response.
+type apiLogLine struct {
+	Message string `json:"message"`
+	Line    string `json:"line"`
+}
+
+// parseServiceLogs returns a service log response as text. The endpoint
+// may answer with plain text or a JSON list of log entries.
+func parseServiceLogs(data []byte) []byte {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || (trimmed[0] != '[' && trimmed[0] != '{') {
+		return data
+	}
+	var entries []apiLogLine
+	if err := decodeList(trimmed, "logs", &entries); err != nil {
+		return data
+	}
+	var b bytes.Buffer
+	for _, e := range entries {
+		line := e.Message
+		if line == "" {
+			line = e.Line
+		}
+		b.WriteString(strings.TrimRight(line, "\n"))
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
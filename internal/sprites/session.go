@@ -0,0 +1,76 @@
+package sprites
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ExecSession is a command started with exec that keeps running on the
+// Sprite and can be attached to, such as a console or a detached job.
+type ExecSession struct {
+	ID        string    `json:"id"`
+	Command   string    `json:"command"`
+	CreatedAt time.Time `json:"created_at"`
+	// TTY reports whether the session has a terminal.
+	TTY bool `json:"tty"`
+}
+
+// FindSession returns the session in sessions with the given ID.
+func FindSession(sessions []ExecSession, id string) (ExecSession, bool) {
+	for _, s := range sessions {
+		if s.ID == id {
+			return s, true
+		}
+	}
+	return ExecSession{}, false
+}
+
+// apiSession matches the JSON returned by the exec sessions endpoint. The
+// ID may be a number or a string and the command a string or an argv.
+type apiSession struct {
+	ID        json.RawMessage `json:"id"`
+	Command   json.RawMessage `json:"command"`
+	CreatedAt string          `json:"created_at"`
+	TTY       bool            `json:"tty"`
+}
+
+// parseSessionsJSON parses an exec session list response, oldest first.
+func parseSessionsJSON(data []byte) ([]ExecSession, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var apiSessions []apiSession
+	if err := decodeList(data, "sessions", &apiSessions); err != nil {
+		return nil, err
+	}
+
+	sessions := make([]ExecSession, len(apiSessions))
+	for i, as := range apiSessions {
+		sessions[i] = ExecSession{
+			ID:        strings.Trim(string(as.ID), `"`),
+			Command:   rawCommand(as.Command),
+			CreatedAt: parseTime(as.CreatedAt),
+			TTY:       as.TTY,
+		}
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].CreatedAt.Before(sessions[j].CreatedAt)
+	})
+	return sessions, nil
+}
+
+// rawCommand renders a command given as a JSON string or array.
+func rawCommand(raw json.RawMessage) string {
+	var argv []string
+	if err := json.Unmarshal(raw, &argv); err == nil {
+		return strings.Join(argv, " ")
+	}
+	var s string
+	json.Unmarshal(raw, &s)
+	return s
+}
@@ -2,12 +2,34 @@ package sprites
 
 import (
 	"context"
+	"io"
 	"os/exec"
 )
 
-// SpriteSource provides sprite data and console access.
-// CLI implements this interface. Tests can provide mock implementations.
+// SpriteSource provides sprite data, lifecycle operations, command
+// execution and console access. CLI and API implement this interface.
+// Tests can provide mock implementations.
 type SpriteSource interface {
 	List(ctx context.Context) ([]Sprite, error)
+	Create(ctx context.Context, name, region string) error
+	Checkpoint(ctx context.Context, name, comment string) error
+	ListCheckpoints(ctx context.Context, name string) ([]Checkpoint, error)
+	RestoreCheckpoint(ctx context.Context, name, id string) error
+	DeleteCheckpoint(ctx context.Context, name, id string) error
+	Destroy(ctx context.Context, name string) error
+	Exec(ctx context.Context, name string, command []string) (ExecResult, error)
+	ListSessions(ctx context.Context, name string) ([]ExecSession, error)
+	KillSession(ctx context.Context, name, id string) error
+	ListServices(ctx context.Context, name string) ([]Service, error)
+	ServiceLogs(ctx context.Context, name, id string) ([]byte, error)
 	ConsoleCmd(name string) *exec.Cmd
+	AttachCmd(name, id string) *exec.Cmd
 }
+
+// StreamExecer is implemented by sources that can deliver command output
+// while the command runs. Callers fall back to Exec otherwise.
+type StreamExecer interface {
+	ExecStream(ctx context.Context, name string, command []string, stdout, stderr io.Writer) (exitCode int, err error)
+}
+
+var _ StreamExecer = (*CLI)(nil)
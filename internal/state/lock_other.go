@@ -0,0 +1,11 @@
+//go:build !unix
+
+package state
+
+import "os"
+
+// Without flock, updates are only serialized within one process.
+
+func lockFile(*os.File) error { return nil }
+
+func unlockFile(*os.File) error { return nil }
@@ -0,0 +1,317 @@
+// Package state remembers what slua last saw of each Sprite between runs:
+// its status, when it last changed or needed attention, who last
+// connected, its tags, how long it spent awake and asleep each day, and a
+// bounded log of recent events.
+package state
+
+import (
+	"os"
+	"os/user"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Version is the state file format written by this package.
+const Version = 1
+
+// DefaultMaxEvents is how many events a Store keeps unless told otherwise.
+const DefaultMaxEvents = 500
+
+// MaxPrompts is how many prompts are kept per Sprite, apart from the event
+// log so that other events do not push them out.
+const MaxPrompts = 50
+
+// Event kinds.
+const (
+	EventTransition = "transition"
+	EventCreate     = "create"
+	EventConnect    = "connect"
+	EventCheckpoint = "checkpoint"
+	EventRestore    = "restore"
+	EventDestroy    = "destroy"
+	EventTag        = "tag"
+	EventPrompt     = "prompt"
+	EventKill       = "kill"
+)
+
+// Event is one entry in the event log.
+type Event struct {
+	At     time.Time `json:"at"`
+	Sprite string    `json:"sprite"`
+	Kind   string    `json:"kind"`
+	// From and To are set for transitions.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Detail is the WAITING prompt, failure reason, template, checkpoint
+	// name or prompt sent to Claude Code.
+	Detail string `json:"detail,omitempty"`
+	// User is who triggered the event, for actions taken from slua.
+	User string `json:"user,omitempty"`
+	// Session is the exec session a connect attached to, empty for a new
+	// console, or the one a kill ended.
+	Session string `json:"session,omitempty"`
+	// Tags are a Sprite's tags after a tag event, empty when cleared.
+	Tags []string `json:"tags,omitempty"`
+	// Task is the queued task a prompt event delivered.
+	Task int `json:"task,omitempty"`
+}
+
+// Sprite is the last known state of one Sprite.
+type Sprite struct {
+	sprites.Sprite
+	// LastChange is when the status last differed from the one before.
+	LastChange time.Time `json:"last_change,omitzero"`
+	// LastAttention is when the Sprite last entered WAITING or ERROR.
+	LastAttention time.Time `json:"last_attention,omitzero"`
+	// LastConnected is when someone last opened a console to it, and
+	// ConnectedBy who.
+	LastConnected time.Time `json:"last_connected,omitzero"`
+	ConnectedBy   string    `json:"connected_by,omitempty"`
+	// Tracked is when the Sprite's time in its status was last added to
+	// the usage log.
+	Tracked time.Time `json:"tracked,omitzero"`
+}
+
+// Local is what only slua knows about a Sprite, as opposed to what the
+// list reports.
+type Local struct {
+	// Session is the exec session to attach to on connect, the one last
+	// attached to. Connecting to a new console clears it.
+	Session string `json:"session,omitempty"`
+	// Tags are local labels used to filter and group Sprites.
+	Tags []string `json:"tags,omitempty"`
+	// Template is what the Sprite was created from, if slua created it.
+	Template string `json:"template,omitempty"`
+	// Prompts are the last MaxPrompts prompts sent, oldest first.
+	Prompts []Event `json:"prompts,omitempty"`
+}
+
+// State is the contents of the state file.
+type State struct {
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	// Sprites are in the order of the most recent list.
+	Sprites []Sprite `json:"sprites"`
+	// Local is kept by Sprite name apart from Sprites, so a list that
+	// leaves a Sprite out, such as one taken while its org was
+	// unreachable, does not lose it. Entries go when the Sprite is
+	// destroyed.
+	Local map[string]Local `json:"local,omitempty"`
+	// Events are oldest first.
+	Events []Event `json:"events"`
+	// Usage is oldest day first.
+	Usage []Usage `json:"usage,omitempty"`
+	// Tasks are in the order they were added, and LastTask the highest
+	// ID given out.
+	Tasks    []Task `json:"tasks,omitempty"`
+	LastTask int    `json:"last_task,omitempty"`
+}
+
+// List returns the remembered Sprites in list order.
+func (st *State) List() []sprites.Sprite {
+	if len(st.Sprites) == 0 {
+		return nil
+	}
+	list := make([]sprites.Sprite, len(st.Sprites))
+	for i, s := range st.Sprites {
+		list[i] = s.Sprite
+	}
+	return list
+}
+
+// Find returns the record for name.
+func (st *State) Find(name string) (Sprite, bool) {
+	if i := st.index(name); i >= 0 {
+		return st.Sprites[i], true
+	}
+	return Sprite{}, false
+}
+
+func (st *State) index(name string) int {
+	for i, s := range st.Sprites {
+		if s.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Sessions returns the preferred exec session of each Sprite that has one.
+func (st *State) Sessions() map[string]string {
+	m := make(map[string]string)
+	for name, l := range st.Local {
+		if l.Session != "" {
+			m[name] = l.Session
+		}
+	}
+	return m
+}
+
+// Tags returns the tags of each Sprite that has any.
+func (st *State) Tags() map[string][]string {
+	m := make(map[string][]string)
+	for name, l := range st.Local {
+		if len(l.Tags) > 0 {
+			m[name] = l.Tags
+		}
+	}
+	return m
+}
+
+// Templates returns the template each Sprite was created from, for those
+// slua created.
+func (st *State) Templates() map[string]string {
+	m := make(map[string]string)
+	for name, l := range st.Local {
+		if l.Template != "" {
+			m[name] = l.Template
+		}
+	}
+	return m
+}
+
+// setLocal stores l for name, dropping the entry once it is empty.
+func (st *State) setLocal(name string, l Local) {
+	if l.Session == "" && len(l.Tags) == 0 && l.Template == "" && len(l.Prompts) == 0 {
+		delete(st.Local, name)
+		return
+	}
+	if st.Local == nil {
+		st.Local = make(map[string]Local)
+	}
+	st.Local[name] = l
+}
+
+// Prompts returns the prompts sent to each Sprite that has any, newest
+// first.
+func (st *State) Prompts() map[string][]Event {
+	m := make(map[string][]Event)
+	for name, l := range st.Local {
+		for _, e := range slices.Backward(l.Prompts) {
+			m[name] = append(m[name], e)
+		}
+	}
+	return m
+}
+
+// Reconcile replaces the remembered Sprites with list, keeping the
+// timestamps of those still present. Sprites missing from list are
+// forgotten; their events and usage stay in the log, and Local is left
+// alone.
+func (st *State) Reconcile(list []sprites.Sprite, at time.Time) {
+	for i := range st.Sprites {
+		st.accrue(&st.Sprites[i], at)
+	}
+	next := make([]Sprite, len(list))
+	for i, s := range list {
+		prev, known := st.Find(s.Name)
+		if !known {
+			prev.Tracked = at
+		}
+		next[i] = prev
+		next[i].Sprite = s
+		if known && prev.Status != s.Status {
+			next[i].LastChange = at
+		}
+		if needsAttention(s.Status) && (!known || !needsAttention(prev.Status)) {
+			next[i].LastAttention = at
+		}
+	}
+	st.Sprites = next
+}
+
+// Record appends e to the log and updates the Sprite it refers to.
+// Sessions, tags, templates and prompts are kept whether or not the
+// Sprite is in the last list.
+func (st *State) Record(e Event) {
+	st.Events = append(st.Events, e)
+	st.trackTask(e)
+	l := st.Local[e.Sprite]
+	switch e.Kind {
+	case EventConnect:
+		l.Session = e.Session
+		st.setLocal(e.Sprite, l)
+	case EventKill:
+		if l.Session == e.Session {
+			l.Session = ""
+			st.setLocal(e.Sprite, l)
+		}
+	case EventTag:
+		l.Tags = e.Tags
+		st.setLocal(e.Sprite, l)
+	case EventPrompt:
+		l.Prompts = append(l.Prompts, e)
+		if over := len(l.Prompts) - MaxPrompts; over > 0 {
+			l.Prompts = slices.Clone(l.Prompts[over:])
+		}
+		st.setLocal(e.Sprite, l)
+	case EventCreate:
+		// A new Sprite starts afresh, even if one had the name before. A
+		// failed setup is noted after the template name.
+		template, _, _ := strings.Cut(e.Detail, " (")
+		st.setLocal(e.Sprite, Local{Template: template})
+	case EventDestroy:
+		delete(st.Local, e.Sprite)
+	}
+
+	i := st.index(e.Sprite)
+	if i < 0 {
+		return
+	}
+	s := &st.Sprites[i]
+	switch e.Kind {
+	case EventTransition:
+		st.accrue(s, e.At)
+		s.Status = e.To
+		s.LastChange = e.At
+		if needsAttention(e.To) {
+			s.LastAttention = e.At
+		}
+	case EventConnect:
+		s.LastConnected = e.At
+		s.ConnectedBy = e.User
+	}
+}
+
+// ParseTags splits a comma or space separated list into tags, lowercased
+// and sorted, without duplicates.
+func ParseTags(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+	slices.Sort(fields)
+	return slices.Compact(fields)
+}
+
+// HasTags reports whether have includes every tag in want.
+func HasTags(have, want []string) bool {
+	for _, t := range want {
+		if !slices.Contains(have, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// trim drops the oldest events beyond max.
+func (st *State) trim(max int) {
+	if over := len(st.Events) - max; max > 0 && over > 0 {
+		st.Events = append([]Event(nil), st.Events[over:]...)
+	}
+}
+
+func needsAttention(status string) bool {
+	return status == sprites.StatusWaiting || status == sprites.StatusError
+}
+
+// CurrentUser returns the local user name to record against actions, or
+// an empty string if it cannot be determined.
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
@@ -0,0 +1,403 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+func TestLoad_Missing(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "state.json"))
+	st, err := s.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(st.Sprites) != 0 || len(st.Events) != 0 {
+		t.Errorf("state = %+v, want empty", st)
+	}
+}
+
+func TestUpdate_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "state.json")
+	s := NewStore(path)
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	err := s.Update(func(st *State) {
+		st.Reconcile([]sprites.Sprite{
+			{Name: "web", Status: sprites.StatusWorking},
+			{Name: "api", Status: sprites.StatusSleeping},
+		}, at)
+		st.Record(Event{At: at, Sprite: "web", Kind: EventTransition, From: sprites.StatusWorking, To: sprites.StatusWaiting})
+		st.Record(Event{At: at, Sprite: "web", Kind: EventConnect, User: "ana"})
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	st, err := NewStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	list := st.List()
+	if len(list) != 2 || list[0].Name != "web" || list[1].Name != "api" {
+		t.Fatalf("list = %+v, want web then api", list)
+	}
+	web, _ := st.Find("web")
+	if web.Status != sprites.StatusWaiting || !web.LastAttention.Equal(at) {
+		t.Errorf("web = %+v, want WAITING with attention at %v", web, at)
+	}
+	if web.ConnectedBy != "ana" || !web.LastConnected.Equal(at) {
+		t.Errorf("web connected = %q at %v", web.ConnectedBy, web.LastConnected)
+	}
+	if len(st.Events) != 2 {
+		t.Errorf("events = %d, want 2", len(st.Events))
+	}
+
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) == ".tmp" {
+			t.Errorf("temporary file %s left behind", e.Name())
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	t0 := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Minute)
+	st := &State{}
+	st.Reconcile([]sprites.Sprite{
+		{Name: "a", Status: sprites.StatusWorking},
+		{Name: "gone", Status: sprites.StatusWorking},
+	}, t0)
+	st.Record(Event{At: t0, Sprite: "a", Kind: EventConnect, User: "ana", Session: "12"})
+
+	st.Reconcile([]sprites.Sprite{
+		{Name: "b", Status: sprites.StatusError},
+		{Name: "a", Status: sprites.StatusFinished},
+	}, t1)
+
+	if _, ok := st.Find("gone"); ok {
+		t.Errorf("Sprites missing from the list should be forgotten")
+	}
+	a, _ := st.Find("a")
+	if !a.LastChange.Equal(t1) || a.ConnectedBy != "ana" {
+		t.Errorf("a = %+v, want change at t1 and connection kept", a)
+	}
+	if got := st.Sessions(); len(got) != 1 || got["a"] != "12" {
+		t.Errorf("Sessions() = %v, want a's session kept", got)
+	}
+	st.Record(Event{At: t1, Sprite: "a", Kind: EventConnect, User: "ana"})
+	if got := st.Sessions(); len(got) != 0 {
+		t.Errorf("Sessions() = %v, a new console should clear the preference", got)
+	}
+	b, _ := st.Find("b")
+	if !b.LastChange.IsZero() || !b.LastAttention.Equal(t1) {
+		t.Errorf("b = %+v, want no change (first sighting) but attention at t1", b)
+	}
+	if st.List()[0].Name != "b" {
+		t.Errorf("order should follow the latest list")
+	}
+}
+
+func TestTags(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	st := &State{}
+	st.Reconcile([]sprites.Sprite{{Name: "a"}, {Name: "b"}}, at)
+	st.Record(Event{At: at, Sprite: "a", Kind: EventTag, Tags: ParseTags("Frontend, urgent frontend")})
+
+	st.Reconcile([]sprites.Sprite{{Name: "a"}, {Name: "b"}}, at)
+	got := st.Tags()
+	if len(got) != 1 || strings.Join(got["a"], ",") != "frontend,urgent" {
+		t.Errorf("Tags() = %v, want a's tags kept across lists", got)
+	}
+	if !HasTags(got["a"], []string{"urgent"}) || HasTags(got["a"], []string{"urgent", "api"}) {
+		t.Errorf("HasTags should require every tag")
+	}
+
+	st.Record(Event{At: at, Sprite: "a", Kind: EventTag})
+	if got := st.Tags(); len(got) != 0 {
+		t.Errorf("Tags() = %v, an empty tag event should clear them", got)
+	}
+}
+
+func TestLocal_SurvivesPartialLists(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	st := &State{}
+	// Created before it shows up in any list.
+	st.Record(Event{At: at, Sprite: "a", Kind: EventCreate, Detail: "web (step 2/3 failed)"})
+	st.Reconcile([]sprites.Sprite{{Name: "a"}, {Name: "b"}}, at)
+	st.Record(Event{At: at, Sprite: "a", Kind: EventTag, Tags: []string{"frontend"}})
+	st.Record(Event{At: at, Sprite: "a", Kind: EventConnect, Session: "12"})
+
+	// A list missing a, say while its org is unreachable, and then one
+	// with it again.
+	st.Reconcile([]sprites.Sprite{{Name: "b"}}, at)
+	st.Reconcile([]sprites.Sprite{{Name: "a"}, {Name: "b"}}, at)
+	want := Local{Session: "12", Tags: []string{"frontend"}, Template: "web"}
+	if got := st.Local["a"]; got.Session != want.Session || strings.Join(got.Tags, ",") != "frontend" || got.Template != want.Template {
+		t.Errorf("a = %+v, want %+v", got, want)
+	}
+
+	st.Record(Event{At: at, Sprite: "a", Kind: EventDestroy})
+	if _, ok := st.Local["a"]; ok {
+		t.Error("destroying a Sprite should forget its tags, session and template")
+	}
+}
+
+func TestLoad_MigratesLocal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	old := `{"version": 1, "sprites": [{"name": "a", "session": "12", "tags": ["x"], "template": "web"}, {"name": "b"}]}`
+	if err := os.WriteFile(path, []byte(old), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	st, err := NewStore(path).Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(st.Local) != 1 || st.Local["a"].Session != "12" || st.Local["a"].Template != "web" || len(st.Local["a"].Tags) != 1 {
+		t.Errorf("Local = %+v, want a's session, tags and template", st.Local)
+	}
+}
+
+func TestPrompts(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	st := &State{}
+	st.Record(Event{At: at, Sprite: "a", Kind: EventPrompt, Detail: "first"})
+	st.Record(Event{At: at, Sprite: "b", Kind: EventConnect})
+	st.Record(Event{At: at.Add(time.Minute), Sprite: "a", Kind: EventPrompt, Detail: "second"})
+
+	got := st.Prompts()
+	if len(got) != 1 || len(got["a"]) != 2 || got["a"][0].Detail != "second" {
+		t.Errorf("Prompts() = %+v, want a's two prompts newest first", got)
+	}
+
+	// The event log's limit does not apply; MaxPrompts per Sprite does.
+	for i := range MaxPrompts {
+		st.Record(Event{At: at, Sprite: "a", Kind: EventPrompt, Detail: fmt.Sprint(i)})
+	}
+	st.trim(3)
+	got = st.Prompts()
+	if len(got["a"]) != MaxPrompts || got["a"][0].Detail != fmt.Sprint(MaxPrompts-1) {
+		t.Errorf("a has %d prompts, newest %q; want %d ending with the last", len(got["a"]), got["a"][0].Detail, MaxPrompts)
+	}
+}
+
+func TestTasks(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	st := &State{}
+	st.Reconcile([]sprites.Sprite{{Name: "a", Status: sprites.StatusFinished}}, at)
+	first := st.AddTask(Task{Prompt: "first"})
+	second := st.AddTask(Task{Prompt: "second", Priority: 2})
+	if q := st.Queued(); len(q) != 2 || q[0].ID != second.ID {
+		t.Fatalf("Queued() = %+v, want the higher priority first", q)
+	}
+
+	if !st.AssignTask(first.ID, "a", at) || st.AssignTask(first.ID, "b", at) {
+		t.Fatalf("a task should only be assigned once")
+	}
+	// Transitions before the prompt is delivered are not the task's.
+	st.Record(Event{At: at.Add(time.Second), Sprite: "a", Kind: EventTransition, From: "FINISHED", To: "WORKING"})
+	if got := st.FindTask(first.ID).Status; got != TaskAssigned {
+		t.Errorf("status = %s before delivery, want assigned", got)
+	}
+	st.Record(Event{At: at.Add(2 * time.Second), Sprite: "a", Kind: EventPrompt, Task: first.ID})
+	for _, to := range []string{"WAITING", "WORKING", "FINISHED"} {
+		st.Record(Event{At: at.Add(3 * time.Second), Sprite: "a", Kind: EventTransition, To: to})
+	}
+	if got := st.FindTask(first.ID); got.Status != TaskDone || got.EndedAt.IsZero() || len(st.CurrentTasks()) != 0 {
+		t.Errorf("task = %+v, want done once Claude Code finished", got)
+	}
+
+	if err := st.CancelTask(second.ID, at); err != nil || st.FindTask(second.ID).Status != TaskCanceled {
+		t.Errorf("CancelTask = %v, want canceled", err)
+	}
+	if err := st.CancelTask(first.ID, at); err == nil {
+		t.Errorf("canceling a done task should fail")
+	}
+
+	st.trimTasks(1)
+	if len(st.Tasks) != 1 || st.Tasks[0].ID != second.ID {
+		t.Errorf("trimTasks kept %+v, want only the newest ended task", st.Tasks)
+	}
+}
+
+func TestSettleTask(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	st := &State{}
+	st.Reconcile([]sprites.Sprite{{Name: "a", Status: sprites.StatusFinished}, {Name: "b", Status: sprites.StatusFinished}}, at)
+	quick := st.AddTask(Task{Prompt: "quick"})
+	broken := st.AddTask(Task{Prompt: "broken"})
+	st.AssignTask(quick.ID, "a", at)
+	st.AssignTask(broken.ID, "b", at)
+	st.Record(Event{At: at.Add(time.Second), Sprite: "a", Kind: EventPrompt, Task: quick.ID})
+	st.Record(Event{At: at.Add(time.Second), Sprite: "b", Kind: EventPrompt, Task: broken.ID})
+
+	// The run ended between polls: FINISHED before and after delivery.
+	st.SettleTask("a", sprites.StatusFinished, "", at)
+	if got := st.FindTask(quick.ID).Status; got != TaskAssigned {
+		t.Errorf("status = %s after a check from before delivery, want assigned", got)
+	}
+	st.SettleTask("a", sprites.StatusFinished, "", at.Add(2*time.Second))
+	st.SettleTask("b", sprites.StatusError, "exit code 1", at.Add(2*time.Second))
+	if got := st.FindTask(quick.ID); got.Status != TaskDone {
+		t.Errorf("quick task = %+v, want done", got)
+	}
+	if got := st.FindTask(broken.ID); got.Status != TaskFailed || got.Detail != "Claude Code failed: exit code 1" {
+		t.Errorf("broken task = %+v, want failed", got)
+	}
+}
+
+func TestExpireTasks(t *testing.T) {
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	st := &State{}
+	creating := st.AddTask(Task{Prompt: "creating"})
+	delivered := st.AddTask(Task{Prompt: "delivered"})
+	st.AssignTask(creating.ID, "task-1", at)
+	st.AssignTask(delivered.ID, "a", at)
+	st.Record(Event{At: at.Add(10 * time.Minute), Sprite: "a", Kind: EventPrompt, Task: delivered.ID})
+
+	if st.ExpireTasks(at.Add(TaskStartTimeout - time.Second)) {
+		t.Errorf("ExpireTasks failed tasks before the timeout: %+v", st.Tasks)
+	}
+	if !st.ExpireTasks(at.Add(TaskStartTimeout)) {
+		t.Fatal("ExpireTasks failed no task")
+	}
+	if got := st.FindTask(creating.ID).Status; got != TaskFailed {
+		t.Errorf("undelivered task is %s, want failed", got)
+	}
+	// The delivered task's time counts from delivery.
+	if got := st.FindTask(delivered.ID).Status; got != TaskAssigned {
+		t.Errorf("delivered task is %s, want still assigned", got)
+	}
+}
+
+func TestUpdate_BoundsEvents(t *testing.T) {
+	s := NewStore(filepath.Join(t.TempDir(), "state.json"))
+	s.MaxEvents = 3
+	for i := range 5 {
+		err := s.Update(func(st *State) {
+			st.Record(Event{Sprite: "a", Kind: EventCheckpoint, Detail: string(rune('0' + i))})
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	st, _ := s.Load()
+	if len(st.Events) != 3 || st.Events[0].Detail != "2" || st.Events[2].Detail != "4" {
+		t.Errorf("events = %+v, want the newest three", st.Events)
+	}
+}
+
+func TestUpdate_ConcurrentStores(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	// Separate Stores stand in for separate slua processes.
+	const writers, each = 4, 10
+	var wg sync.WaitGroup
+	for range writers {
+		s := NewStore(path)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range each {
+				if err := s.Update(func(st *State) { st.Record(Event{Sprite: "a", Kind: EventConnect}) }); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	st, err := NewStore(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Events) != writers*each {
+		t.Errorf("events = %d, want %d (updates lost)", len(st.Events), writers*each)
+	}
+}
+
+func TestUpdate_ReplacesCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := NewStore(path)
+	if _, err := s.Load(); err == nil {
+		t.Errorf("Load should report the corrupt file")
+	}
+	if err := s.Update(func(st *State) { st.Record(Event{Sprite: "a", Kind: EventConnect}) }); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if st, err := s.Load(); err != nil || len(st.Events) != 1 {
+		t.Errorf("state = %+v, %v", st, err)
+	}
+}
+
+func TestUpdate_RefusesNewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"version": 99}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewStore(path).Update(func(*State) {}); err == nil {
+		t.Fatal("expected an error")
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != `{"version": 99}` {
+		t.Errorf("newer state file was overwritten: %s", data)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	t0 := time.Date(2026, 5, 1, 20, 0, 0, 0, time.UTC)
+	st := &State{}
+	st.Reconcile([]sprites.Sprite{{Name: "a", Status: sprites.StatusWorking}}, t0)
+	st.Record(Event{At: t0.Add(2 * time.Hour), Sprite: "a", Kind: EventTransition, From: sprites.StatusWorking, To: sprites.StatusSleeping})
+	// Asleep from 22:00 until 03:00 the next day, when it is destroyed.
+	st.Reconcile(nil, t0.Add(7*time.Hour))
+
+	want := []Usage{
+		{Day: "2026-05-01", Sprite: "a", Awake: 2, Asleep: 2},
+		{Day: "2026-05-02", Sprite: "a", Asleep: 3},
+	}
+	if len(st.Usage) != len(want) {
+		t.Fatalf("usage = %+v, want %+v", st.Usage, want)
+	}
+	for i := range want {
+		if st.Usage[i] != want[i] {
+			t.Errorf("usage[%d] = %+v, want %+v", i, st.Usage[i], want[i])
+		}
+	}
+
+	// UsageAt adds the time in the current status without recording it.
+	st.Reconcile([]sprites.Sprite{{Name: "b", Status: sprites.StatusWorking}}, t0.Add(7*time.Hour))
+	got := st.UsageAt(t0.Add(8 * time.Hour))
+	if len(got) != 3 || got[2].Sprite != "b" || got[2].Awake != 1 {
+		t.Errorf("UsageAt = %+v, want an hour awake for b", got)
+	}
+	if len(st.Usage) != 2 {
+		t.Errorf("UsageAt should not modify the state")
+	}
+
+	for status, asleep := range map[string]bool{
+		sprites.StatusWaiting:    false,
+		sprites.StatusSleeping:   true,
+		sprites.StatusError:      true,
+		sprites.StatusDestroying: true,
+		"REBOOTING":              true,
+	} {
+		if Asleep(status) != asleep {
+			t.Errorf("Asleep(%s) = %v, want %v", status, !asleep, asleep)
+		}
+	}
+
+	st.trimUsage(1, t0.Add(48*time.Hour))
+	if len(st.Usage) != 1 || st.Usage[0].Day != "2026-05-02" {
+		t.Errorf("trimmed usage = %+v, want the last day only", st.Usage)
+	}
+}
@@ -0,0 +1,138 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Store reads and writes a state file that several slua processes may
+// share. Writes go to a temporary file that is renamed over the original,
+// so readers never see a partial file, and are serialized with a lock
+// file so concurrent updates are not lost.
+type Store struct {
+	Path string
+	// MaxEvents bounds the event log. Zero means DefaultMaxEvents.
+	MaxEvents int
+
+	mu sync.Mutex // serializes Update within this process
+}
+
+// NewStore returns a Store for the state file at path.
+func NewStore(path string) *Store {
+	return &Store{Path: path, MaxEvents: DefaultMaxEvents}
+}
+
+// Load reads the state file. A missing file yields an empty State.
+func (s *Store) Load() (*State, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &State{Version: Version}, nil
+	}
+	if err != nil {
+		return &State{Version: Version}, fmt.Errorf("reading state: %w", err)
+	}
+	var st State
+	if err := json.Unmarshal(data, &st); err != nil {
+		return &State{Version: Version}, fmt.Errorf("decoding %s: %w", s.Path, err)
+	}
+	if st.Version > Version {
+		return &State{Version: Version}, fmt.Errorf("%s was written by a newer slua (version %d)", s.Path, st.Version)
+	}
+	if st.Local == nil {
+		migrateLocal(&st, data)
+	}
+	return &st, nil
+}
+
+// migrateLocal moves sessions, tags and templates that older files kept
+// on each Sprite record into st.Local.
+func migrateLocal(st *State, data []byte) {
+	var old struct {
+		Sprites []struct {
+			Name string `json:"name"`
+			Local
+		} `json:"sprites"`
+	}
+	if json.Unmarshal(data, &old) != nil {
+		return
+	}
+	for _, s := range old.Sprites {
+		st.setLocal(s.Name, s.Local)
+	}
+}
+
+// Update applies fn to the current state and writes the result back,
+// holding the lock for the whole read-modify-write. A state file that
+// cannot be decoded is replaced, since it only caches what the next List
+// will report anyway.
+func (s *Store) Update(fn func(*State)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("creating state directory: %w", err)
+	}
+	lock, err := os.OpenFile(s.Path+".lock", os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening state lock: %w", err)
+	}
+	defer lock.Close()
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("locking state: %w", err)
+	}
+	defer unlockFile(lock)
+
+	st, err := s.Load()
+	if err != nil && !isDecodeError(err) {
+		return err
+	}
+	fn(st)
+	st.Version = Version
+	st.UpdatedAt = time.Now()
+	max := s.MaxEvents
+	if max == 0 {
+		max = DefaultMaxEvents
+	}
+	st.trim(max)
+	st.trimUsage(DefaultUsageDays, st.UpdatedAt)
+	st.trimTasks(DefaultEndedTasks)
+	return s.write(st)
+}
+
+func (s *Store) write(st *State) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.Path), "."+filepath.Base(s.Path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("writing state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing state: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.Path); err != nil {
+		return fmt.Errorf("writing state: %w", err)
+	}
+	return nil
+}
+
+func isDecodeError(err error) bool {
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	return errors.As(err, &syntax) || errors.As(err, &typ)
+}
@@ -0,0 +1,252 @@
+package state
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// DefaultEndedTasks is how many finished, failed or canceled tasks a Store
+// keeps.
+const DefaultEndedTasks = 200
+
+// TaskStartTimeout is how long a task may stay assigned: waiting for its
+// Sprite to be created and its prompt delivered, or delivered but never
+// seen running.
+const TaskStartTimeout = 30 * time.Minute
+
+// Task statuses. A task is queued until the dispatcher assigns it a
+// Sprite, then follows that Sprite's Claude Code state once its prompt is
+// delivered.
+const (
+	TaskQueued   = "queued"
+	TaskAssigned = "assigned" // Sprite chosen or being created, prompt not yet seen running
+	TaskWorking  = "working"
+	TaskWaiting  = "waiting"
+	TaskDone     = "done"
+	TaskFailed   = "failed"
+	TaskCanceled = "canceled"
+)
+
+// Task is a prompt queued for whichever Sprite is free.
+type Task struct {
+	ID     int    `json:"id"`
+	Prompt string `json:"prompt"`
+	// Template and Tags restrict the Sprites the task may run on: ones
+	// created from Template and having every tag. Sprites created for the
+	// task use Template and get the tags.
+	Template string   `json:"template,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	// Priority orders the queue, highest first; ties go oldest first.
+	Priority int    `json:"priority,omitempty"`
+	Status   string `json:"status"`
+	Sprite   string `json:"sprite,omitempty"`
+	// Detail is why the task failed.
+	Detail  string    `json:"detail,omitempty"`
+	AddedAt time.Time `json:"added_at"`
+	AddedBy string    `json:"added_by,omitempty"`
+	// AssignedAt is when the task was given its Sprite, StartedAt when
+	// the prompt was delivered, and EndedAt when the task left the queue
+	// for good.
+	AssignedAt time.Time `json:"assigned_at,omitzero"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	EndedAt    time.Time `json:"ended_at,omitzero"`
+}
+
+// Active reports whether t holds its Sprite.
+func (t Task) Active() bool {
+	switch t.Status {
+	case TaskAssigned, TaskWorking, TaskWaiting:
+		return true
+	}
+	return false
+}
+
+// Ended reports whether t is done, failed or canceled.
+func (t Task) Ended() bool {
+	return t.Status != TaskQueued && !t.Active()
+}
+
+// AddTask queues t with the next free ID and returns it.
+func (st *State) AddTask(t Task) Task {
+	st.LastTask++
+	t.ID = st.LastTask
+	t.Status = TaskQueued
+	st.Tasks = append(st.Tasks, t)
+	return t
+}
+
+// FindTask returns the task with id, or nil.
+func (st *State) FindTask(id int) *Task {
+	for i := range st.Tasks {
+		if st.Tasks[i].ID == id {
+			return &st.Tasks[i]
+		}
+	}
+	return nil
+}
+
+// Queued returns the tasks waiting for a Sprite in dispatch order.
+func (st *State) Queued() []Task {
+	var list []Task
+	for _, t := range st.Tasks {
+		if t.Status == TaskQueued {
+			list = append(list, t)
+		}
+	}
+	slices.SortStableFunc(list, func(a, b Task) int {
+		if a.Priority != b.Priority {
+			return b.Priority - a.Priority
+		}
+		return a.ID - b.ID
+	})
+	return list
+}
+
+// CurrentTasks returns the active task of each Sprite that has one.
+func (st *State) CurrentTasks() map[string]Task {
+	return CurrentTasks(st.Tasks)
+}
+
+// CurrentTasks returns the active task of each Sprite in tasks that has
+// one.
+func CurrentTasks(tasks []Task) map[string]Task {
+	m := make(map[string]Task)
+	for _, t := range tasks {
+		if t.Active() {
+			m[t.Sprite] = t
+		}
+	}
+	return m
+}
+
+// AssignTask gives the queued task id to sprite at the given time. It
+// reports false if the task is no longer queued, e.g. because it was
+// canceled meanwhile.
+func (st *State) AssignTask(id int, sprite string, at time.Time) bool {
+	t := st.FindTask(id)
+	if t == nil || t.Status != TaskQueued {
+		return false
+	}
+	t.Status = TaskAssigned
+	t.Sprite = sprite
+	t.AssignedAt = at
+	return true
+}
+
+// ExpireTasks fails the tasks that have been assigned for longer than
+// TaskStartTimeout at now, counting from delivery once the prompt was
+// delivered. It reports whether it failed any.
+func (st *State) ExpireTasks(now time.Time) bool {
+	expired := false
+	for i := range st.Tasks {
+		t := &st.Tasks[i]
+		since := t.AssignedAt
+		if !t.StartedAt.IsZero() {
+			since = t.StartedAt
+		}
+		if t.Status != TaskAssigned || since.IsZero() || now.Sub(since) < TaskStartTimeout {
+			continue
+		}
+		reason := "the prompt was not delivered within " + TaskStartTimeout.String()
+		if !t.StartedAt.IsZero() {
+			reason = "Claude Code was not seen running within " + TaskStartTimeout.String()
+		}
+		st.FailTask(t.ID, reason, now)
+		expired = true
+	}
+	return expired
+}
+
+// SettleTask ends sprite's task if a check begun at checked found Claude
+// Code FINISHED or in ERROR after the task's prompt was delivered. A run
+// can end between two checks, or while the Sprite sleeps, so the
+// transition that would end the task may never be seen.
+func (st *State) SettleTask(sprite, status, detail string, checked time.Time) {
+	if status == sprites.StatusFinished || status == sprites.StatusError {
+		st.trackTask(Event{At: checked, Sprite: sprite, Kind: EventTransition, To: status, Detail: detail})
+	}
+}
+
+// FailTask ends the task id with reason unless it has already ended.
+func (st *State) FailTask(id int, reason string, at time.Time) {
+	if t := st.FindTask(id); t != nil && !t.Ended() {
+		t.Status = TaskFailed
+		t.Detail = reason
+		t.EndedAt = at
+	}
+}
+
+// CancelTask takes the task id out of the queue, or stops tracking it if
+// it is running. Claude Code is left alone.
+func (st *State) CancelTask(id int, at time.Time) error {
+	t := st.FindTask(id)
+	switch {
+	case t == nil:
+		return fmt.Errorf("no task #%d", id)
+	case t.Ended():
+		return fmt.Errorf("task #%d is already %s", id, t.Status)
+	}
+	t.Status = TaskCanceled
+	t.EndedAt = at
+	return nil
+}
+
+// trackTask moves the active task of the Sprite in e along with it.
+// Transitions from before the task's prompt was delivered are ignored.
+func (st *State) trackTask(e Event) {
+	for i := range st.Tasks {
+		t := &st.Tasks[i]
+		if !t.Active() || t.Sprite != e.Sprite {
+			continue
+		}
+		switch {
+		case e.Kind == EventPrompt && e.Task == t.ID:
+			t.StartedAt = e.At
+			// A change seen after delivery may have been saved first.
+			if s, ok := st.Find(e.Sprite); ok && !s.LastChange.Before(e.At) {
+				st.trackTask(Event{At: s.LastChange, Sprite: s.Name, Kind: EventTransition, To: s.Status})
+			}
+		case e.Kind == EventDestroy:
+			st.FailTask(t.ID, "Sprite destroyed", e.At)
+		case e.Kind != EventTransition || t.StartedAt.IsZero() || e.At.Before(t.StartedAt):
+			// Not a change in the run of the task's prompt.
+		case e.To == sprites.StatusWorking:
+			t.Status = TaskWorking
+		case e.To == sprites.StatusWaiting:
+			t.Status = TaskWaiting
+		case e.To == sprites.StatusFinished:
+			t.Status = TaskDone
+			t.EndedAt = e.At
+		case e.To == sprites.StatusError:
+			reason := "Claude Code failed"
+			if e.Detail != "" {
+				reason += ": " + e.Detail
+			}
+			st.FailTask(t.ID, reason, e.At)
+		}
+	}
+}
+
+// trimTasks drops the oldest ended tasks beyond max.
+func (st *State) trimTasks(max int) {
+	ended := 0
+	for _, t := range st.Tasks {
+		if t.Ended() {
+			ended++
+		}
+	}
+	if ended <= max {
+		return
+	}
+	drop := ended - max
+	st.Tasks = slices.DeleteFunc(st.Tasks, func(t Task) bool {
+		if drop > 0 && t.Ended() {
+			drop--
+			return true
+		}
+		return false
+	})
+}
@@ -0,0 +1,98 @@
+package state
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// DefaultUsageDays is how many days of usage a Store keeps.
+const DefaultUsageDays = 400
+
+// DayFormat is the layout of Usage.Day.
+const DayFormat = "2006-01-02"
+
+// Usage is the time one Sprite spent awake and asleep on one day.
+type Usage struct {
+	Day    string  `json:"day"` // in DayFormat, local time
+	Sprite string  `json:"sprite"`
+	Awake  float64 `json:"awake_hours,omitempty"`
+	Asleep float64 `json:"asleep_hours,omitempty"`
+}
+
+// running are the statuses billed as awake: the Sprite is known to be up.
+var running = map[string]bool{
+	sprites.StatusWorking:       true,
+	sprites.StatusWaiting:       true,
+	sprites.StatusFinished:      true,
+	sprites.StatusCreating:      true,
+	sprites.StatusCheckpointing: true,
+}
+
+// Asleep reports whether time in status is billed as asleep: SLEEPING,
+// and every status not known to be running, such as ERROR, DESTROYING,
+// UNREACHABLE or one slua has not heard of.
+func Asleep(status string) bool {
+	return !running[status]
+}
+
+// accrue adds the time s spent in its current status since it was last
+// accrued, up to at, split at midnight. Time before slua first saw a
+// Sprite is not counted; time between runs is counted in the status it
+// had when slua last saw it.
+func (st *State) accrue(s *Sprite, at time.Time) {
+	if s.Tracked.IsZero() || !at.After(s.Tracked) {
+		if s.Tracked.IsZero() {
+			s.Tracked = at
+		}
+		return
+	}
+	for from := s.Tracked; from.Before(at); {
+		y, m, d := from.Date()
+		end := time.Date(y, m, d+1, 0, 0, 0, 0, from.Location())
+		if end.After(at) {
+			end = at
+		}
+		st.addUsage(from.Format(DayFormat), s.Name, s.Status, end.Sub(from).Hours())
+		from = end
+	}
+	s.Tracked = at
+}
+
+// addUsage adds hours in status to name's usage on day, keeping the log
+// ordered by day.
+func (st *State) addUsage(day, name, status string, hours float64) {
+	i := slices.IndexFunc(st.Usage, func(u Usage) bool {
+		return u.Day == day && u.Sprite == name
+	})
+	if i < 0 {
+		i = sort.Search(len(st.Usage), func(j int) bool { return st.Usage[j].Day > day })
+		st.Usage = slices.Insert(st.Usage, i, Usage{Day: day, Sprite: name})
+	}
+	if Asleep(status) {
+		st.Usage[i].Asleep += hours
+	} else {
+		st.Usage[i].Awake += hours
+	}
+}
+
+// UsageAt returns the recorded usage together with the time each Sprite
+// has spent in its current status since, as if accrued at now. It does not
+// modify st.
+func (st *State) UsageAt(now time.Time) []Usage {
+	tmp := State{Sprites: slices.Clone(st.Sprites), Usage: slices.Clone(st.Usage)}
+	for i := range tmp.Sprites {
+		tmp.accrue(&tmp.Sprites[i], now)
+	}
+	return tmp.Usage
+}
+
+// trimUsage drops the usage of days more than days before now.
+func (st *State) trimUsage(days int, now time.Time) {
+	oldest := now.AddDate(0, 0, -days).Format(DayFormat)
+	st.Usage = slices.DeleteFunc(st.Usage, func(u Usage) bool {
+		return u.Day < oldest
+	})
+}
@@ -0,0 +1,214 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectFile is the template file slua looks for in the current
+// directory.
+const ProjectFile = ".slua.yml"
+
+// LoadFile reads a template file. Without a name: field the template is
+// named after the file, or after its directory for ProjectFile.
+func LoadFile(path string) (Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Template{}, err
+	}
+
+	var t Template
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&t); err != nil && !errors.Is(err, io.EOF) {
+		return Template{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Template{}, err
+	}
+	t.dir = filepath.Dir(abs)
+	if t.Name == "" {
+		if filepath.Base(path) == ProjectFile {
+			t.Name = filepath.Base(t.dir)
+		} else {
+			t.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+	}
+
+	if probs := t.Problems(); len(probs) > 0 {
+		errs := make([]error, len(probs))
+		for i, p := range probs {
+			errs[i] = fmt.Errorf("%s: %w", path, p)
+		}
+		return Template{}, errors.Join(errs...)
+	}
+	return t, nil
+}
+
+// LoadDir reads every .yml and .yaml file in dir. A missing dir is empty.
+func LoadDir(dir string) (Set, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return Set{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading templates: %w", err)
+	}
+
+	set := Set{}
+	var errs []error
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			continue
+		}
+		t, err := LoadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		set[t.Name] = t
+	}
+	return set, errors.Join(errs...)
+}
+
+// Set is a collection of templates by name, before extends is resolved.
+type Set map[string]Template
+
+// Add copies every template in other into s, replacing any with the same
+// name. File sources in other are relative to dir.
+func (s Set) Add(dir string, other map[string]Template) {
+	for name, t := range other {
+		t.Name = name
+		if t.dir == "" {
+			t.dir = dir
+		}
+		s[name] = t
+	}
+}
+
+// Resolve returns the named template merged over everything it extends.
+func (s Set) Resolve(name string) (Template, error) {
+	return s.resolve(name, nil)
+}
+
+func (s Set) resolve(name string, seen []string) (Template, error) {
+	for _, n := range seen {
+		if n == name {
+			return Template{}, fmt.Errorf("template %s: extends cycle: %s", seen[0], strings.Join(append(seen, name), " → "))
+		}
+	}
+	t, ok := s[name]
+	if !ok {
+		if name == Blank {
+			return Sorted(nil)[0], nil
+		}
+		if len(seen) > 0 {
+			return Template{}, fmt.Errorf("template %s: extends unknown template %q", seen[len(seen)-1], name)
+		}
+		return Template{}, fmt.Errorf("unknown template %q", name)
+	}
+	t.Name = name
+	if t.Extends == "" {
+		return t, nil
+	}
+	parent, err := s.resolve(t.Extends, append(seen, name))
+	if err != nil {
+		return Template{}, err
+	}
+	return merge(parent, t), nil
+}
+
+// List resolves every template in s, sorted by name with Blank first.
+func (s Set) List() ([]Template, error) {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolved := make(map[string]Template, len(s))
+	var errs []error
+	for _, name := range names {
+		t, err := s.Resolve(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if _, err := t.Plan(); err != nil {
+			errs = append(errs, fmt.Errorf("template %s: %w", name, err))
+			continue
+		}
+		resolved[name] = t
+	}
+	return Sorted(resolved), errors.Join(errs...)
+}
+
+// merge applies child over parent.
+func merge(parent, child Template) Template {
+	out := child
+	out.Extends = parent.Name
+	out.Repos = concat(parent.Repos, child.Repos)
+	out.Packages = Packages{
+		Apt: concat(parent.Packages.Apt, child.Packages.Apt),
+		Npm: concat(parent.Packages.Npm, child.Packages.Npm),
+		Pip: concat(parent.Packages.Pip, child.Packages.Pip),
+	}
+	if len(parent.Env) > 0 {
+		out.Env = make(map[string]string, len(parent.Env)+len(child.Env))
+		for k, v := range parent.Env {
+			out.Env[k] = v
+		}
+		for k, v := range child.Env {
+			out.Env[k] = v
+		}
+	}
+	// Parent files are read relative to the parent's own file.
+	out.Files = nil
+	for _, f := range parent.Files {
+		out.Files = append(out.Files, f.resolved(parent.dir))
+	}
+	for _, f := range child.Files {
+		out.Files = append(out.Files, f.resolved(child.dir))
+	}
+	out.Steps = concat(parent.Steps, child.Steps)
+	if out.Claude.Command == "" {
+		out.Claude.Command = parent.Claude.Command
+	}
+	if out.Claude.Prompt == "" {
+		out.Claude.Prompt = parent.Claude.Prompt
+	}
+	if out.Claude.Dir == "" {
+		out.Claude.Dir = parent.Claude.Dir
+	}
+	out.Claude.Hooks = out.Claude.Hooks || parent.Claude.Hooks
+	if out.Detector == "" {
+		out.Detector = parent.Detector
+	}
+	return out
+}
+
+// resolved makes a relative Source absolute against dir.
+func (f File) resolved(dir string) File {
+	if f.Source != "" && dir != "" && !filepath.IsAbs(f.Source) {
+		f.Source = filepath.Join(dir, f.Source)
+	}
+	return f
+}
+
+func concat[T any](a, b []T) []T {
+	if len(a) == 0 {
+		return b
+	}
+	return append(append([]T(nil), a...), b...)
+}
@@ -0,0 +1,127 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "webapp")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, ProjectFile)
+	os.WriteFile(path, []byte(`
+repos:
+  - url: git@github.com:acme/webapp.git
+packages:
+  npm: [typescript]
+claude:
+  prompt: Run the tests
+`), 0o644)
+
+	tmpl, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if tmpl.Name != "webapp" {
+		t.Errorf("Name = %q, want the directory name", tmpl.Name)
+	}
+
+	os.WriteFile(path, []byte("repos:\n  - branch: main\nsteps:\n  - rnu: typo\n"), 0o644)
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "field rnu not found") {
+		t.Errorf("unknown field: err = %v", err)
+	}
+	os.WriteFile(path, []byte("repos:\n  - branch: main\nfiles:\n  - path: a\n    mode: rw\n"), 0o644)
+	_, err = LoadFile(path)
+	for _, want := range []string{"repos.0.url: is required", "files.0: set exactly one of content and source", "files.0.mode: invalid mode"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want %q", err, want)
+		}
+	}
+}
+
+func TestSet_ResolveExtends(t *testing.T) {
+	set := Set{
+		"base": {
+			Packages: Packages{Apt: []string{"git"}},
+			Env:      map[string]string{"EDITOR": "vi", "LANG": "C"},
+			Steps:    []Step{{Run: "base"}},
+			Claude:   Claude{Command: "claude --verbose", Hooks: true},
+			Detector: "hooks",
+		},
+		"node": {
+			Extends:  "base",
+			Packages: Packages{Apt: []string{"nodejs"}},
+			Env:      map[string]string{"EDITOR": "nano"},
+			Steps:    []Step{{Run: "node"}},
+			Claude:   Claude{Prompt: "hi"},
+		},
+	}
+	tmpl, err := set.Resolve("node")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if got := strings.Join(tmpl.Packages.Apt, ","); got != "git,nodejs" {
+		t.Errorf("apt = %s", got)
+	}
+	if tmpl.Env["EDITOR"] != "nano" || tmpl.Env["LANG"] != "C" {
+		t.Errorf("env = %v", tmpl.Env)
+	}
+	if len(tmpl.Steps) != 2 || tmpl.Steps[0].Run != "base" {
+		t.Errorf("steps = %+v", tmpl.Steps)
+	}
+	if tmpl.Claude.Command != "claude --verbose" || tmpl.Claude.Prompt != "hi" || !tmpl.Claude.Hooks {
+		t.Errorf("claude = %+v", tmpl.Claude)
+	}
+	if tmpl.Detector != "hooks" {
+		t.Errorf("detector = %q, want the parent's", tmpl.Detector)
+	}
+
+	set["base"] = Template{Extends: "node"}
+	if _, err := set.Resolve("node"); err == nil || !strings.Contains(err.Error(), "node → base → node") {
+		t.Errorf("cycle: err = %v", err)
+	}
+	set["base"] = Template{Extends: "missing"}
+	if _, err := set.Resolve("node"); err == nil || !strings.Contains(err.Error(), `extends unknown template "missing"`) {
+		t.Errorf("unknown: err = %v", err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	tmpl := Template{
+		Repos:  []Repo{{URL: "https://github.com/acme/api.git"}},
+		Env:    map[string]string{"TOKEN": "it's"},
+		Files:  []File{{Path: ".npmrc", Content: "x", Mode: "0600"}},
+		Steps:  []Step{{Run: "make"}},
+		Claude: Claude{Prompt: "fix it"},
+	}
+	steps, err := tmpl.Plan()
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	var names []string
+	for _, s := range steps {
+		names = append(names, s.Label())
+	}
+	want := "Set 1 env vars|Clone https://github.com/acme/api.git|Write .npmrc|make|Start Claude Code"
+	if got := strings.Join(names, "|"); got != want {
+		t.Errorf("steps = %s", got)
+	}
+	if !strings.Contains(steps[0].Run, shellQuote("export TOKEN='it'\\''s'\n")) {
+		t.Errorf("env step does not quote values: %s", steps[0].Run)
+	}
+	if steps[1].Unless != `test -d "$HOME"/'api'/.git` {
+		t.Errorf("clone check = %s", steps[1].Unless)
+	}
+	if !strings.Contains(steps[4].Run, `-c "$HOME"/'api'`) || steps[4].Unless == "" {
+		t.Errorf("claude step = %+v", steps[4])
+	}
+
+	tmpl.Files = []File{{Path: "big", Source: "missing.txt"}}
+	if _, err := tmpl.Plan(); err == nil {
+		t.Error("Plan with a missing source file should fail")
+	}
+}
@@ -0,0 +1,290 @@
+package templates
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/JPM1118/slua/internal/hooks"
+)
+
+// maxFileSize bounds uploaded files, which travel inside the exec command.
+const maxFileSize = 64 << 10
+
+// envFile holds the template's env vars on the Sprite. Login shells source
+// it through ~/.profile.
+const envFile = `"$HOME/.slua/env"`
+
+// claudeSession is the tmux session Claude Code is started in.
+const claudeSession = "claude"
+
+// exitFile receives Claude Code's exit code when it ends, for the tmux
+// detector (poller.ExitFile). A file outlives the session and with it the
+// tmux server, where a tmux variable would not.
+const exitFile = `"$HOME/.slua/claude-exit"`
+
+// clearExit forgets the exit code of a previous run before Claude Code is
+// started again.
+const clearExit = `mkdir -p "$HOME/.slua" && rm -f ` + exitFile
+
+// Plan compiles t into the shell steps Apply runs: env vars, packages,
+// repos, files, custom steps, Claude Code hooks and finally the Claude
+// Code session. Every
+// generated step either checks whether its work is done or overwrites the
+// previous result, so a plan can be applied again.
+func (t Template) Plan() ([]Step, error) {
+	var steps []Step
+
+	if len(t.Env) > 0 {
+		keys := make([]string, 0, len(t.Env))
+		for k := range t.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		for _, k := range keys {
+			fmt.Fprintf(&b, "export %s=%s\n", k, shellQuote(t.Env[k]))
+		}
+		source := ". " + envFile
+		steps = append(steps, Step{
+			Name: fmt.Sprintf("Set %d env vars", len(keys)),
+			Run: fmt.Sprintf("mkdir -p \"$HOME/.slua\" && printf '%%s' %s > %s && "+
+				"(grep -qxF %s \"$HOME/.profile\" 2>/dev/null || echo %s >> \"$HOME/.profile\")",
+				shellQuote(b.String()), envFile, shellQuote(source), shellQuote(source)),
+		})
+	}
+
+	if p := t.Packages.Apt; len(p) > 0 {
+		steps = append(steps, Step{
+			Name:   "Install apt packages: " + strings.Join(p, " "),
+			Unless: "dpkg -s " + quoteAll(p) + " >/dev/null 2>&1",
+			Run: `SUDO=$(command -v sudo || true); $SUDO apt-get update -qq && ` +
+				`$SUDO env DEBIAN_FRONTEND=noninteractive apt-get install -y -qq ` + quoteAll(p),
+		})
+	}
+	if p := t.Packages.Npm; len(p) > 0 {
+		steps = append(steps, Step{
+			Name:   "Install npm packages: " + strings.Join(p, " "),
+			Unless: "npm ls -g --depth=0 " + quoteAll(p) + " >/dev/null 2>&1",
+			Run:    "npm install -g " + quoteAll(p),
+		})
+	}
+	if p := t.Packages.Pip; len(p) > 0 {
+		steps = append(steps, Step{
+			Name:   "Install pip packages: " + strings.Join(p, " "),
+			Unless: "pip show " + quoteAll(p) + " >/dev/null 2>&1",
+			Run:    "pip install -q " + quoteAll(p),
+		})
+	}
+
+	for _, r := range t.Repos {
+		dir := homePath(r.repoPath())
+		clone := "git clone "
+		if r.Branch != "" {
+			clone += "--branch " + shellQuote(r.Branch) + " "
+		}
+		steps = append(steps, Step{
+			Name:   "Clone " + r.URL,
+			Unless: "test -d " + dir + "/.git",
+			Run:    clone + shellQuote(r.URL) + " " + dir,
+		})
+	}
+
+	for _, f := range t.Files {
+		data, err := f.read(t.dir)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxFileSize {
+			return nil, fmt.Errorf("file %s: larger than %d KiB", f.Path, maxFileSize>>10)
+		}
+		mode := f.Mode
+		if mode == "" {
+			mode = "0644"
+		}
+		dest := homePath(f.Path)
+		tmp := homePath(f.Path + ".slua-tmp")
+		steps = append(steps, Step{
+			Name: "Write " + f.Path,
+			Run: fmt.Sprintf("mkdir -p \"$(dirname %s)\" && printf '%%s' %s | base64 -d > %s && chmod %s %s && mv %s %s",
+				dest, base64.StdEncoding.EncodeToString(data), tmp, mode, tmp, tmp, dest),
+		})
+	}
+
+	steps = append(steps, t.Steps...)
+
+	if t.Claude.Hooks {
+		// Claude Code reads its hooks when it starts.
+		steps = append(steps, Step{
+			Name:    "Install Claude Code hooks",
+			Run:     "# merge slua's hooks into ~/" + hooks.SettingsFile,
+			install: hooks.Install,
+		})
+	}
+
+	if c := t.Claude; c.enabled() {
+		args := ""
+		if c.Prompt != "" {
+			args = shellQuote(c.Prompt)
+		}
+		steps = append(steps, Step{
+			Name:   "Start Claude Code",
+			Unless: "tmux has-session -t " + claudeSession + " 2>/dev/null",
+			Run: fmt.Sprintf("%s; tmux new-session -d -s %s -c %s %s",
+				clearExit, claudeSession, t.claudeDir(), shellQuote(t.claudeCommand(args))),
+		})
+	}
+	return steps, nil
+}
+
+// claudeCommand returns the command line that runs Claude Code with args,
+// which must already be shell-quoted, and then records its exit code in
+// exitFile for the detector.
+func (t Template) claudeCommand(args string) string {
+	command := t.Claude.Command
+	if command == "" {
+		command = "claude"
+	}
+	if args != "" {
+		command += " " + args
+	}
+	return command + "; echo $? > " + exitFile
+}
+
+// claudeDir returns the directory Claude Code runs in, quoted for the
+// shell: Claude.Dir, else the first repo, else home.
+func (t Template) claudeDir() string {
+	switch {
+	case t.Claude.Dir != "":
+		return homePath(t.Claude.Dir)
+	case len(t.Repos) > 0:
+		return homePath(t.Repos[0].repoPath())
+	}
+	return "\"$HOME\""
+}
+
+// restartScript starts Claude Code in its tmux session, or starts the
+// session if it is gone. A pane kept after its command exited is reused;
+// one still running something, such as an interactive Claude Code, is
+// left alone and the script fails. %[1]s is the session, %[2]s the quoted
+// directory and %[3]s the quoted command.
+const restartScript = `if tmux has-session -t %[1]s 2>/dev/null &&
+  [ "$(tmux display-message -p -t %[1]s '#{pane_dead}')" != 1 ]; then
+  echo "tmux session %[1]s is still running; not replacing it" >&2
+  exit 1
+fi
+` + clearExit + `
+if tmux has-session -t %[1]s 2>/dev/null; then
+  tmux respawn-pane -t %[1]s -c %[2]s %[3]s
+else
+  tmux new-session -d -s %[1]s -c %[2]s %[3]s
+fi`
+
+// RestartCommand returns the argv that restarts Claude Code on a Sprite
+// created from t, the way t starts it, resuming its most recent
+// conversation. It fails if Claude Code's session is still running.
+func (t Template) RestartCommand() []string {
+	return []string{"sh", "-c", t.startScript("--continue")}
+}
+
+// promptScript pastes $3 into the pane $2 and presses Enter if Claude
+// Code, process $1, is running, or starts Claude Code with $3 as its first
+// prompt. Without $2 it uses the claude session if there is one, as
+// detection does. Pasting keeps the newlines of a multi-line prompt from
+// sending it early. It prints what it did; %s is the start script.
+const promptScript = `TARGET=$2
+if [ -z "$TARGET" ] && tmux has-session -t ` + claudeSession + ` 2>/dev/null; then
+  TARGET=` + claudeSession + `
+fi
+if pgrep -- "$1" >/dev/null 2>&1; then
+  tmux set-buffer -b slua -- "$3" && tmux paste-buffer -p -d -b slua ${TARGET:+-t "$TARGET"} &&
+    tmux send-keys ${TARGET:+-t "$TARGET"} Enter && echo sent
+else
+  %s && echo started
+fi`
+
+// PromptCommand returns the argv that gives Claude Code a new prompt on a
+// Sprite created from t, starting it the way t does if it is not running.
+// process and target are the agent process and tmux pane the Sprite's
+// detector watches, as poller.AgentOf gives them. Its output satisfies
+// PromptStarted when it started Claude Code.
+func (t Template) PromptCommand(process, target, text string) []string {
+	return []string{"sh", "-c", fmt.Sprintf(promptScript, t.startScript(shellQuote(text))), "sh", process, target, text}
+}
+
+// TaskCommand returns the argv that runs a queued task's prompt on a
+// Sprite created from t. Claude Code is started the way t starts it, but
+// with -p so it exits when the task is done: every detector sees that,
+// where an interactive session would look busy forever. It fails if
+// Claude Code's session is still running. Its output satisfies
+// PromptStarted.
+func (t Template) TaskCommand(text string) []string {
+	return []string{"sh", "-c", t.startScript("-p "+shellQuote(text)) + " && echo started"}
+}
+
+// PromptStarted reports whether the output of a PromptCommand says Claude
+// Code was started rather than sent the prompt.
+func PromptStarted(out []byte) bool {
+	return strings.TrimSpace(string(out)) == "started"
+}
+
+// startScript returns the restart script for Claude Code with args, which
+// must already be shell-quoted.
+func (t Template) startScript(args string) string {
+	return fmt.Sprintf(restartScript, claudeSession, t.claudeDir(), shellQuote(t.claudeCommand(args)))
+}
+
+// repoPath returns where r is cloned, relative to home unless absolute.
+func (r Repo) repoPath() string {
+	if r.Path != "" {
+		return r.Path
+	}
+	name := path.Base(strings.TrimSuffix(strings.TrimRight(r.URL, "/"), ".git"))
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// read returns the file's contents, loading Source relative to dir.
+func (f File) read(dir string) ([]byte, error) {
+	if f.Source == "" {
+		return []byte(f.Content), nil
+	}
+	src := f.Source
+	if !filepath.IsAbs(src) && dir != "" {
+		src = filepath.Join(dir, src)
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return nil, fmt.Errorf("file %s: %w", f.Path, err)
+	}
+	return data, nil
+}
+
+// homePath quotes p for the shell, resolving relative paths against the
+// home directory.
+func homePath(p string) string {
+	if strings.HasPrefix(p, "/") {
+		return shellQuote(p)
+	}
+	p = strings.TrimPrefix(p, "~/")
+	return "\"$HOME\"/" + shellQuote(p)
+}
+
+// shellQuote wraps s in single quotes for safe use in a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func quoteAll(list []string) string {
+	q := make([]string, len(list))
+	for i, s := range list {
+		q[i] = shellQuote(s)
+	}
+	return strings.Join(q, " ")
+}
@@ -0,0 +1,289 @@
+// Package templates describes a Sprite environment — repos, packages,
+// environment variables, files and the Claude Code session to start — and
+// applies it over exec. Applying a template twice is safe: each step is
+// skipped or repeated harmlessly when its work is already done.
+package templates
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/hooks"
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Blank is the built-in template with no bootstrap steps.
+const Blank = "blank"
+
+// StepTimeout bounds a single bootstrap step.
+const StepTimeout = 10 * time.Minute
+
+// Template is a named Sprite environment. Templates are written in YAML,
+// either under "templates:" in the config file or one per file.
+type Template struct {
+	// Name identifies the template. In the config file the map key is used
+	// instead.
+	Name string `yaml:"name"`
+	// Extends names a template whose settings this one builds on. Lists
+	// are appended to the parent's, env vars and Claude settings override
+	// it.
+	Extends     string `yaml:"extends"`
+	Description string `yaml:"description"`
+
+	Repos    []Repo            `yaml:"repos"`
+	Packages Packages          `yaml:"packages"`
+	Env      map[string]string `yaml:"env"`
+	Files    []File            `yaml:"files"`
+	// Steps are extra shell commands, run after everything above.
+	Steps  []Step `yaml:"steps"`
+	Claude Claude `yaml:"claude"`
+	// Detector names how the state of Sprites created from the template is
+	// detected: a built-in detector or one under detection.detectors in
+	// the config file. Empty means detection.detector.
+	Detector string `yaml:"detector"`
+
+	dir string // directory of the file the template came from
+}
+
+// Repo is a git repository to clone.
+type Repo struct {
+	URL string `yaml:"url"`
+	// Path is where to clone, relative to the home directory. Empty means
+	// the repository name.
+	Path   string `yaml:"path"`
+	Branch string `yaml:"branch"`
+}
+
+// Packages lists packages to install, by package manager.
+type Packages struct {
+	Apt []string `yaml:"apt"`
+	Npm []string `yaml:"npm"`
+	Pip []string `yaml:"pip"`
+}
+
+// File is a file to write on the Sprite.
+type File struct {
+	// Path is relative to the home directory unless absolute.
+	Path string `yaml:"path"`
+	// Content is written as is. Exactly one of Content and Source is set.
+	Content string `yaml:"content"`
+	// Source is a local file to upload, relative to the template file.
+	Source string `yaml:"source"`
+	// Mode is an octal permission string like "0600". Empty means "0644".
+	Mode string `yaml:"mode"`
+}
+
+// Claude configures the Claude Code session started after setup.
+type Claude struct {
+	// Command launches Claude Code. Empty means "claude" if Prompt or Dir
+	// is set, and no session otherwise.
+	Command string `yaml:"command"`
+	// Prompt is passed to Command as its first argument.
+	Prompt string `yaml:"prompt"`
+	// Dir is the working directory. Empty means the first repo, or home.
+	Dir string `yaml:"dir"`
+	// Hooks installs slua's Claude Code hooks first, so state changes are
+	// logged for detection rather than read off the tmux pane.
+	Hooks bool `yaml:"hooks"`
+}
+
+// enabled reports whether a session should be started.
+func (c Claude) enabled() bool {
+	return c.Command != "" || c.Prompt != "" || c.Dir != ""
+}
+
+// Step is one shell command run on the Sprite.
+type Step struct {
+	// Name is shown in progress output. Empty means the command itself.
+	Name string `yaml:"name"`
+	Run  string `yaml:"run"`
+	// Unless is a shell command; when it succeeds the step is skipped.
+	Unless string `yaml:"unless"`
+
+	// install, if set, does the work instead of Run, for steps that need
+	// more than a shell.
+	install func(ctx context.Context, src hooks.Execer, name string) error
+}
+
+// Label returns the step's name, or the first line of its command.
+func (s Step) Label() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(s.Run), "\n")
+	return line
+}
+
+// Sorted returns the templates in set by name, with Blank first. Blank is
+// added if set does not define it.
+func Sorted(set map[string]Template) []Template {
+	list := make([]Template, 0, len(set)+1)
+	if _, ok := set[Blank]; !ok {
+		list = append(list, Template{Name: Blank, Description: "Empty Sprite, no setup"})
+	}
+	for name, t := range set {
+		t.Name = name
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if (list[i].Name == Blank) != (list[j].Name == Blank) {
+			return list[i].Name == Blank
+		}
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// Step outcomes reported in Progress.
+const (
+	StepRunning = "running"
+	StepOK      = "ok"
+	StepSkipped = "skipped"
+	StepFailed  = "failed"
+)
+
+// Progress reports what Provision or Apply is doing.
+type Progress struct {
+	// Step is the 1-based bootstrap step, or 0 while the Sprite itself is
+	// being created.
+	Step  int
+	Total int
+	Name  string
+	// Status is StepRunning when a step starts, then its outcome.
+	Status  string
+	Elapsed time.Duration
+}
+
+func (p Progress) String() string {
+	if p.Step == 0 {
+		return p.Name
+	}
+	s := fmt.Sprintf("step %d/%d: %s", p.Step, p.Total, p.Name)
+	switch p.Status {
+	case StepRunning, "":
+		return s
+	case StepSkipped:
+		return s + " (already done)"
+	default:
+		return fmt.Sprintf("%s (%s, %s)", s, p.Status, p.Elapsed.Round(100*time.Millisecond))
+	}
+}
+
+// Reports returns how many times Provision calls report for t at most,
+// for callers that buffer them.
+func (t Template) Reports() int {
+	steps, _ := t.Plan()
+	return 2*len(steps) + 2
+}
+
+// StepError reports a bootstrap step that failed or exited non-zero.
+type StepError struct {
+	Step     int
+	Name     string
+	ExitCode int
+	// Output is the last line of the step's stderr, or stdout if stderr
+	// was empty.
+	Output string
+	Err    error
+}
+
+func (e *StepError) Error() string {
+	msg := fmt.Sprintf("step %d (%s)", e.Step, e.Name)
+	switch {
+	case e.Err != nil:
+		msg += ": " + e.Err.Error()
+	default:
+		msg += fmt.Sprintf(": exit code %d", e.ExitCode)
+	}
+	if e.Output != "" {
+		msg += ": " + e.Output
+	}
+	return msg
+}
+
+func (e *StepError) Unwrap() error { return e.Err }
+
+// Provision creates name in region, waits for it to come up and applies t.
+// report is called before and after each stage and must not block.
+func Provision(ctx context.Context, src sprites.SpriteSource, name, region string, t Template, report func(Progress)) error {
+	if _, err := t.Plan(); err != nil {
+		return err
+	}
+	report(Progress{Name: "creating"})
+	if err := src.Create(ctx, name, region); err != nil {
+		return err
+	}
+	report(Progress{Name: "waiting for Sprite"})
+	if err := sprites.WaitReady(ctx, src, name); err != nil {
+		return err
+	}
+	return Apply(ctx, src, name, t, report)
+}
+
+// Apply runs t's steps on name in order, skipping those whose Unless check
+// passes and stopping at the first failure.
+func Apply(ctx context.Context, src sprites.SpriteSource, name string, t Template, report func(Progress)) error {
+	steps, err := t.Plan()
+	if err != nil {
+		return err
+	}
+	for i, s := range steps {
+		p := Progress{Step: i + 1, Total: len(steps), Name: s.Label(), Status: StepRunning}
+		report(p)
+		start := time.Now()
+
+		if s.Unless != "" {
+			res, err := run(ctx, src, name, s.Unless)
+			if err == nil && res.ExitCode == 0 {
+				p.Status, p.Elapsed = StepSkipped, time.Since(start)
+				report(p)
+				continue
+			}
+		}
+
+		var res sprites.ExecResult
+		if s.install != nil {
+			err = install(ctx, src, name, s.install)
+		} else {
+			res, err = run(ctx, src, name, s.Run)
+		}
+		p.Elapsed = time.Since(start)
+		if err != nil || res.ExitCode != 0 {
+			p.Status = StepFailed
+			report(p)
+			out := res.Stderr
+			if len(strings.TrimSpace(string(out))) == 0 {
+				out = res.Stdout
+			}
+			return &StepError{Step: i + 1, Name: s.Label(), ExitCode: res.ExitCode, Output: LastLine(out), Err: err}
+		}
+		p.Status = StepOK
+		report(p)
+	}
+	return nil
+}
+
+// install runs a step's install function within StepTimeout.
+func install(ctx context.Context, src sprites.SpriteSource, name string, fn func(context.Context, hooks.Execer, string) error) error {
+	ctx, cancel := context.WithTimeout(ctx, StepTimeout)
+	defer cancel()
+	return fn(ctx, src, name)
+}
+
+// run executes script in a login shell so profile changes made by earlier
+// steps, such as env vars, apply.
+func run(ctx context.Context, src sprites.SpriteSource, name, script string) (sprites.ExecResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, StepTimeout)
+	defer cancel()
+	return src.Exec(ctx, name, []string{"sh", "-lc", script})
+}
+
+// LastLine returns the last non-empty line of out, which is usually the
+// error in a failed command's output.
+func LastLine(out []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
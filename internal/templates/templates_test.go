@@ -0,0 +1,259 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JPM1118/slua/internal/hooks"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// fakeSource creates Sprites in memory and fails exec commands containing
+// "fail". Other SpriteSource methods are not used.
+type fakeSource struct {
+	sprites.SpriteSource
+	list []sprites.Sprite
+	ran  []string
+}
+
+func (f *fakeSource) List(context.Context) ([]sprites.Sprite, error) { return f.list, nil }
+
+func (f *fakeSource) Create(_ context.Context, name, region string) error {
+	f.list = append(f.list, sprites.Sprite{Name: name, Region: region, Status: sprites.StatusWorking})
+	return nil
+}
+
+func (f *fakeSource) Exec(_ context.Context, _ string, command []string) (sprites.ExecResult, error) {
+	script := command[len(command)-1]
+	f.ran = append(f.ran, script)
+	if strings.Contains(script, "fail") {
+		return sprites.ExecResult{ExitCode: 2, Stderr: []byte("npm ERR!\nnpm ERR! missing script\n")}, nil
+	}
+	return sprites.ExecResult{}, nil
+}
+
+func TestProvision(t *testing.T) {
+	src := &fakeSource{}
+	tmpl := Template{Name: "node", Steps: []Step{
+		{Name: "Clone", Run: "git clone repo"},
+		{Run: "npm ci\nnpm run build"},
+	}}
+
+	var got []string
+	err := Provision(context.Background(), src, "web", "ord", tmpl, func(p Progress) {
+		p.Elapsed = 0
+		got = append(got, p.String())
+	})
+	if err != nil {
+		t.Fatalf("Provision: %v", err)
+	}
+	want := []string{"creating", "waiting for Sprite",
+		"step 1/2: Clone", "step 1/2: Clone (ok, 0s)",
+		"step 2/2: npm ci", "step 2/2: npm ci (ok, 0s)"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("progress = %q, want %q", got, want)
+	}
+	if len(src.list) != 1 || src.list[0].Region != "ord" {
+		t.Errorf("created = %+v", src.list)
+	}
+	if len(got) > tmpl.Reports() {
+		t.Errorf("%d reports, Reports() = %d", len(got), tmpl.Reports())
+	}
+}
+
+func TestApply_SkipsDoneSteps(t *testing.T) {
+	src := &fakeSource{}
+	tmpl := Template{Steps: []Step{
+		{Name: "Install", Run: "install", Unless: "true"},
+		{Name: "Build", Run: "build", Unless: "fail-check"},
+	}}
+
+	var statuses []string
+	err := Apply(context.Background(), src, "web", tmpl, func(p Progress) {
+		if p.Status != StepRunning {
+			statuses = append(statuses, p.Name+"="+p.Status)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if got := strings.Join(statuses, ","); got != "Install=skipped,Build=ok" {
+		t.Errorf("statuses = %s", got)
+	}
+	if got := strings.Join(src.ran, ","); got != "true,fail-check,build" {
+		t.Errorf("ran = %s", got)
+	}
+}
+
+func TestApply_StopsAtFailedStep(t *testing.T) {
+	src := &fakeSource{}
+	tmpl := Template{Steps: []Step{{Run: "true"}, {Name: "Build", Run: "fail"}, {Run: "never"}}}
+
+	err := Apply(context.Background(), src, "web", tmpl, func(Progress) {})
+	var se *StepError
+	if !errors.As(err, &se) || se.Step != 2 || se.ExitCode != 2 {
+		t.Fatalf("err = %v, want StepError for step 2", err)
+	}
+	if se.Output != "npm ERR! missing script" {
+		t.Errorf("Output = %q, want the last stderr line", se.Output)
+	}
+	if len(src.ran) != 2 {
+		t.Errorf("ran %q, should stop after the failure", src.ran)
+	}
+}
+
+func TestApply_InstallsHooks(t *testing.T) {
+	src := &fakeSource{}
+	tmpl := Template{Claude: Claude{Prompt: "hi", Hooks: true}}
+
+	var names []string
+	err := Apply(context.Background(), src, "web", tmpl, func(p Progress) {
+		if p.Status != StepRunning {
+			names = append(names, p.Name+"="+p.Status)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if got := strings.Join(names, ","); got != "Install Claude Code hooks=ok,Start Claude Code=skipped" {
+		t.Errorf("steps = %s", got)
+	}
+	// Reading the settings, then writing them with the hooks added.
+	if len(src.ran) != 3 || !strings.Contains(src.ran[1], hooks.ScriptFile) {
+		t.Errorf("ran %q", src.ran)
+	}
+}
+
+func TestSorted(t *testing.T) {
+	ts := Sorted(map[string]Template{"zed": {}, "alpha": {}})
+	var names []string
+	for _, tm := range ts {
+		names = append(names, tm.Name)
+	}
+	if strings.Join(names, ",") != "blank,alpha,zed" {
+		t.Errorf("names = %v", names)
+	}
+}
+
+func TestPromptCommand(t *testing.T) {
+	// A stand-in tmux records its arguments and reports the pane dead
+	// unless $RUNNING is set, when a stand-in pgrep finds claude too.
+	dir := t.TempDir()
+	log := filepath.Join(dir, "calls")
+	tools := map[string]string{
+		"tmux": "#!/bin/sh\nprintf '%s|' \"$@\" >> " + shellQuote(log) + "\necho >> " + shellQuote(log) + "\n" +
+			"[ \"$1\" = display-message ] && { [ -n \"$RUNNING\" ] && echo 0 || echo 1; }\nexit 0\n",
+		"pgrep": "#!/bin/sh\n[ -n \"$RUNNING\" ]\n",
+	}
+	for name, script := range tools {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	text := "fix the build\nthen run it's tests"
+	tmpl := Template{Claude: Claude{Command: "claude --model opus", Dir: "/src/app"}}
+
+	tests := []struct {
+		running bool
+		started bool
+		want    string
+	}{
+		{true, false, "has-session|-t|claude|\nset-buffer|-b|slua|--|" + text + "|\npaste-buffer|-p|-d|-b|slua|-t|claude|\nsend-keys|-t|claude|Enter|\n"},
+		{false, true, "has-session|-t|claude|\nhas-session|-t|claude|\ndisplay-message|-p|-t|claude|#{pane_dead}|\nhas-session|-t|claude|\n" +
+			"respawn-pane|-t|claude|-c|/src/app|claude --model opus " + shellQuote(text) + "; echo $? > " + exitFile + "|\n"},
+	}
+	for _, tt := range tests {
+		os.Remove(log)
+		argv := tmpl.PromptCommand("claude", "", text)
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Env = append(os.Environ(), "PATH="+dir+":"+os.Getenv("PATH"), "HOME="+dir, "RUNNING=")
+		if tt.running {
+			cmd.Env = append(cmd.Env, "RUNNING=1")
+		}
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("running=%v: %v", tt.running, err)
+		}
+		if got := PromptStarted(out); got != tt.started {
+			t.Errorf("running=%v: PromptStarted(%q) = %v, want %v", tt.running, out, got, tt.started)
+		}
+		calls, _ := os.ReadFile(log)
+		if string(calls) != tt.want {
+			t.Errorf("running=%v: tmux calls = %q, want %q", tt.running, calls, tt.want)
+		}
+	}
+
+	// A queued task gets a run of its own in the session's dead pane, but
+	// never replaces a session that is still running.
+	for _, running := range []bool{false, true} {
+		os.Remove(log)
+		argv := tmpl.TaskCommand(text)
+		cmd := exec.Command(argv[0], argv[1:]...)
+		cmd.Env = append(os.Environ(), "PATH="+dir+":"+os.Getenv("PATH"), "HOME="+dir, "RUNNING=")
+		if running {
+			cmd.Env = append(cmd.Env, "RUNNING=1")
+		}
+		out, err := cmd.Output()
+		calls, _ := os.ReadFile(log)
+		if running {
+			if err == nil || strings.Contains(string(calls), "respawn-pane") {
+				t.Errorf("task on a running session: %v, tmux calls %q; want it refused", err, calls)
+			}
+			continue
+		}
+		if err != nil || !PromptStarted(out) {
+			t.Fatalf("TaskCommand: %q, %v", out, err)
+		}
+		want := "has-session|-t|claude|\ndisplay-message|-p|-t|claude|#{pane_dead}|\nhas-session|-t|claude|\n" +
+			"respawn-pane|-t|claude|-c|/src/app|claude --model opus -p " + shellQuote(text) + "; echo $? > " + exitFile + "|\n"
+		if string(calls) != want {
+			t.Errorf("task: tmux calls = %q, want %q", calls, want)
+		}
+	}
+}
+
+func TestClaudeExitCode(t *testing.T) {
+	// Claude Code has exited and the tmux server with it: pgrep and tmux
+	// find nothing, so only the exit file tells how it ended.
+	dir := t.TempDir()
+	for _, name := range []string{"tmux", "pgrep"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	env := append(os.Environ(), "PATH="+dir+":"+os.Getenv("PATH"), "HOME="+dir)
+
+	tests := []struct {
+		command string
+		want    poller.Detection
+	}{
+		{"sh -c 'exit 3'", poller.Detection{Status: sprites.StatusError, Detail: "exit code 3", ExitCode: 3}},
+		{"true", poller.Detection{Status: sprites.StatusFinished}},
+	}
+	for _, tt := range tests {
+		tmpl := Template{Claude: Claude{Command: tt.command}}
+		run := exec.Command("sh", "-c", clearExit+"; "+tmpl.claudeCommand(""))
+		run.Env = env
+		if out, err := run.CombinedOutput(); err != nil {
+			t.Fatalf("%s: %v\n%s", tt.command, err, out)
+		}
+
+		var tmux poller.Tmux
+		argv := tmux.Command("api")
+		check := exec.Command(argv[0], argv[1:]...)
+		check.Env = env
+		out, err := check.Output()
+		if err != nil {
+			t.Fatalf("%s: detector: %v", tt.command, err)
+		}
+		if got, _ := tmux.Parse("api", out); got != tt.want {
+			t.Errorf("%s: detection = %+v, want %+v", tt.command, got, tt.want)
+		}
+	}
+}
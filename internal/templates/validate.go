@@ -0,0 +1,76 @@
+package templates
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Problem is a validation failure at a YAML path within a template, such
+// as ["repos", "0", "url"].
+type Problem struct {
+	Path []string
+	Msg  string
+}
+
+func (p Problem) Error() string {
+	return strings.Join(p.Path, ".") + ": " + p.Msg
+}
+
+var (
+	envKeyRE  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	packageRE = regexp.MustCompile(`^[A-Za-z0-9@][A-Za-z0-9@._/+:=<>~-]*$`)
+	modeRE    = regexp.MustCompile(`^0?[0-7]{3}$`)
+)
+
+// Problems checks t on its own. Extends is checked when a Set resolves it.
+func (t Template) Problems() []Problem {
+	var probs []Problem
+	add := func(msg string, path ...string) {
+		probs = append(probs, Problem{Path: path, Msg: msg})
+	}
+
+	for i, r := range t.Repos {
+		if strings.TrimSpace(r.URL) == "" {
+			add("is required", "repos", strconv.Itoa(i), "url")
+		}
+	}
+
+	for _, pm := range []struct {
+		key  string
+		list []string
+	}{{"apt", t.Packages.Apt}, {"npm", t.Packages.Npm}, {"pip", t.Packages.Pip}} {
+		for i, p := range pm.list {
+			if !packageRE.MatchString(p) {
+				add(fmt.Sprintf("invalid package name %q", p), "packages", pm.key, strconv.Itoa(i))
+			}
+		}
+	}
+
+	for k := range t.Env {
+		if !envKeyRE.MatchString(k) {
+			add("invalid variable name", "env", k)
+		}
+	}
+
+	for i, f := range t.Files {
+		idx := strconv.Itoa(i)
+		if strings.TrimSpace(f.Path) == "" {
+			add("is required", "files", idx, "path")
+		}
+		if (f.Content == "") == (f.Source == "") {
+			add("set exactly one of content and source", "files", idx)
+		}
+		if f.Mode != "" && !modeRE.MatchString(f.Mode) {
+			add(fmt.Sprintf("invalid mode %q (want octal like 0644)", f.Mode), "files", idx, "mode")
+		}
+	}
+
+	for i, s := range t.Steps {
+		if strings.TrimSpace(s.Run) == "" {
+			add("must not be empty", "steps", strconv.Itoa(i), "run")
+		}
+	}
+	return probs
+}
@@ -0,0 +1,321 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/JPM1118/slua/internal/fleet"
+	"github.com/JPM1118/slua/internal/templates"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// execFinishedMsg reports a command run on one Sprite by a bulk restart
+// or exec.
+type execFinishedMsg struct {
+	name string
+	err  error
+}
+
+// bulkRun tracks one action applied to several Sprites, so each result is
+// counted in a single summary instead of replacing the last notice.
+type bulkRun struct {
+	label string // e.g. "Checkpoint"
+	names []string
+	errs  map[string]error // result per finished Sprite; nil on success
+}
+
+func newBulkRun(label string, names []string) *bulkRun {
+	return &bulkRun{label: label, names: names, errs: make(map[string]error)}
+}
+
+// record stores name's result. It reports false when name is not part of
+// the run or already finished, so the result is reported on its own.
+func (b *bulkRun) record(name string, err error) bool {
+	if b == nil || !slices.Contains(b.names, name) {
+		return false
+	}
+	if _, done := b.errs[name]; done {
+		return false
+	}
+	b.errs[name] = err
+	return true
+}
+
+func (b *bulkRun) done() bool {
+	return len(b.errs) == len(b.names)
+}
+
+// summary returns the run's progress, or once done its outcome. failed
+// lists each failed Sprite with its reason, in the order they were chosen.
+func (b *bulkRun) summary() (text string, failed bool) {
+	var failures []string
+	for _, name := range b.names {
+		if err := b.errs[name]; err != nil {
+			failures = append(failures, name+": "+err.Error())
+		}
+	}
+	if !b.done() {
+		text = fmt.Sprintf("%s: %d of %d done", b.label, len(b.errs), len(b.names))
+		if len(failures) > 0 {
+			text += fmt.Sprintf(", %d failed", len(failures))
+		}
+		return text + "…", false
+	}
+	if len(failures) == 0 {
+		return fmt.Sprintf("%s: done on %s", b.label, plural(len(b.names), "Sprite")), false
+	}
+	return fmt.Sprintf("%s: failed on %d of %d — %s", b.label, len(failures), len(b.names), strings.Join(failures, "; ")), true
+}
+
+// commandPrompt is the input for a command to run on several Sprites, or
+// with reply set for an answer to one Sprite's prompt.
+type commandPrompt struct {
+	names []string
+	input string
+	reply bool
+}
+
+// recordBulk counts name's result towards the bulk run in progress and
+// shows its summary. It reports false when name is not part of one.
+func (d *Dashboard) recordBulk(name string, err error) bool {
+	if !d.bulk.record(name, err) {
+		return false
+	}
+	d.showBulk()
+	return true
+}
+
+// showBulk shows the bulk run's summary in the notification bar.
+func (d *Dashboard) showBulk() {
+	text, failed := d.bulk.summary()
+	if failed {
+		d.lastErr, d.notice = text, ""
+	} else {
+		d.lastErr, d.notice = "", text
+	}
+}
+
+// targets returns the Sprites an action applies to: the marked ones in
+// list order, or the one under the cursor when none are marked.
+func (d Dashboard) targets() []string {
+	var names []string
+	for _, s := range d.sprites {
+		if d.marked[s.Name] {
+			names = append(names, s.Name)
+		}
+	}
+	if len(names) == 0 {
+		if s, ok := d.selected(); ok {
+			names = append(names, s.Name)
+		}
+	}
+	return names
+}
+
+// toggleMark marks or unmarks the Sprite under the cursor and moves down.
+func (d *Dashboard) toggleMark() {
+	s, ok := d.selected()
+	if !ok {
+		return
+	}
+	if d.marked[s.Name] {
+		delete(d.marked, s.Name)
+	} else {
+		d.marked[s.Name] = true
+	}
+	d.anchor = s.Name
+	if d.cursor < len(d.visible())-1 {
+		d.cursor++
+	}
+}
+
+// markRange marks every row between the last one toggled and the cursor.
+func (d *Dashboard) markRange() {
+	list := d.visible()
+	if len(list) == 0 {
+		return
+	}
+	from := d.cursor
+	for i, m := range list {
+		if m.folded == 0 && m.sprite.Name == d.anchor {
+			from = i
+		}
+	}
+	for i := min(from, d.cursor); i <= max(from, d.cursor); i++ {
+		if list[i].folded == 0 {
+			d.marked[list[i].sprite.Name] = true
+		}
+	}
+	if s, ok := d.selected(); ok {
+		d.anchor = s.Name
+	}
+}
+
+// markAll marks every row matching the filter, or unmarks them if they
+// already are.
+func (d *Dashboard) markAll() {
+	var list []match
+	for _, m := range d.visible() {
+		if m.folded == 0 {
+			list = append(list, m)
+		}
+	}
+	all := true
+	for _, m := range list {
+		all = all && d.marked[m.sprite.Name]
+	}
+	for _, m := range list {
+		if all {
+			delete(d.marked, m.sprite.Name)
+		} else {
+			d.marked[m.sprite.Name] = true
+		}
+	}
+}
+
+// confirmBulk asks to apply action to the targets.
+func (d Dashboard) confirmBulk(action confirmAction) (tea.Model, tea.Cmd) {
+	names := d.targets()
+	if len(names) == 0 {
+		return d, nil
+	}
+	if len(names) == 1 && len(d.marked) == 0 {
+		// A single Sprite keeps the familiar dialogs.
+		switch action {
+		case actionCheckpointDestroy:
+			d.confirm = newDestroyDialog(names[0])
+			return d, nil
+		case actionBulkCheckpoint:
+			return d.startCheckpoint(names[0])
+		}
+	}
+	d.confirm = newBulkDialog(action, names, "")
+	return d, nil
+}
+
+// handlePromptKey handles input while typing a command for exec or an
+// answer.
+func (d Dashboard) handlePromptKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := d.prompt
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return d, tea.Quit
+	case tea.KeyEsc:
+		d.prompt = nil
+	case tea.KeyEnter:
+		if p.reply {
+			// An empty answer just presses Enter.
+			d.prompt = nil
+			return d.startReply(p.names[0], p.input)
+		}
+		if strings.TrimSpace(p.input) == "" {
+			return d, nil
+		}
+		d.prompt = nil
+		d.confirm = newBulkDialog(actionBulkExec, p.names, p.input)
+	case tea.KeyBackspace:
+		if r := []rune(p.input); len(r) > 0 {
+			p.input = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		p.input += string(msg.Runes)
+	}
+	return d, nil
+}
+
+// startBulk applies action to names concurrently, at most
+// fleet.DefaultConcurrency at a time. Sprites busy with another operation
+// are skipped and reported as failed.
+func (d Dashboard) startBulk(action confirmAction, names []string, command string) (tea.Model, tea.Cmd) {
+	clear(d.marked)
+	run := newBulkRun(bulkLabel(action, command), names)
+	d.bulk = run
+
+	sem := make(chan struct{}, fleet.DefaultConcurrency)
+	limit := func(cmd tea.Cmd) tea.Cmd {
+		return func() tea.Msg {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			return cmd()
+		}
+	}
+
+	cmds := []tea.Cmd{d.startSpinner()}
+	for _, name := range names {
+		if st, busy := d.pending[name]; busy {
+			run.record(name, fmt.Errorf("already %s", strings.ToLower(st)))
+			continue
+		}
+		switch action {
+		case actionBulkCheckpoint:
+			cmds = append(cmds, limit(d.checkpointCmd(name)))
+		case actionCheckpointDestroy, actionDestroy:
+			cmds = append(cmds, limit(d.destroyCmd(name, action == actionCheckpointDestroy)))
+		case actionBulkRestart:
+			cmds = append(cmds, limit(d.execCmd(name, d.templateOf(name).RestartCommand())))
+		case actionBulkExec:
+			cmds = append(cmds, limit(d.execCmd(name, []string{"sh", "-c", command})))
+		}
+	}
+	d.showBulk()
+	return d, tea.Batch(cmds...)
+}
+
+// execCmd returns the command that runs command on name, failing on a
+// non-zero exit with the last line of its error output.
+func (d Dashboard) execCmd(name string, command []string) tea.Cmd {
+	src := d.cli
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), fleet.DefaultTimeout)
+		defer cancel()
+		res, err := src.Exec(ctx, name, command)
+		if err == nil && res.ExitCode != 0 {
+			err = fmt.Errorf("exit code %d", res.ExitCode)
+			if line := templates.LastLine(res.Stderr); line != "" {
+				err = fmt.Errorf("exit code %d: %s", res.ExitCode, line)
+			}
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %s", fleet.DefaultTimeout)
+		}
+		return execFinishedMsg{name: name, err: err}
+	}
+}
+
+// bulkLabel names action in the bulk summary.
+func bulkLabel(action confirmAction, command string) string {
+	switch action {
+	case actionBulkCheckpoint:
+		return "Checkpoint"
+	case actionCheckpointDestroy:
+		return "Checkpoint and destroy"
+	case actionDestroy:
+		return "Destroy"
+	case actionBulkRestart:
+		return "Restart Claude Code"
+	case actionBulkExec:
+		return "Run " + truncate(command, 30)
+	}
+	return ""
+}
+
+// plural returns "1 Sprite" or "3 Sprites".
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// nameList joins names for a dialog, eliding them beyond what fits in
+// width.
+func nameList(names []string, width int) string {
+	text := strings.Join(names, ", ")
+	for n := len(names) - 1; n > 0 && len(text) > width; n-- {
+		text = fmt.Sprintf("%s and %d more", strings.Join(names[:n], ", "), len(names)-n)
+	}
+	return text
+}
@@ -0,0 +1,90 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+const colCheckpoint = 32
+
+// checkpointBrowser is the sub-view listing one Sprite's checkpoints.
+type checkpointBrowser struct {
+	sprite      string
+	checkpoints []sprites.Checkpoint
+	cursor      int
+	loading     bool
+	err         string
+}
+
+func newCheckpointBrowser(name string) *checkpointBrowser {
+	return &checkpointBrowser{sprite: name, loading: true}
+}
+
+// selected returns the checkpoint under the cursor.
+func (b *checkpointBrowser) selected() (sprites.Checkpoint, bool) {
+	if len(b.checkpoints) == 0 {
+		return sprites.Checkpoint{}, false
+	}
+	return b.checkpoints[b.cursor], true
+}
+
+func (b *checkpointBrowser) setCheckpoints(cps []sprites.Checkpoint, err error) {
+	b.loading = false
+	if err != nil {
+		b.err = err.Error()
+		return
+	}
+	b.err = ""
+	b.checkpoints = cps
+	if b.cursor >= len(cps) {
+		b.cursor = max(0, len(cps)-1)
+	}
+}
+
+// View renders the browser into a width x height region.
+func (b *checkpointBrowser) View(width, height int) string {
+	var s strings.Builder
+	s.WriteString(headerStyle.Render("  Checkpoints · " + b.sprite))
+	s.WriteString("\n\n")
+	s.WriteString(columnHeaderStyle.Render("  " + padRight("NAME", colCheckpoint) + "CREATED"))
+	s.WriteString("\n")
+	rows := height - 3
+
+	switch {
+	case b.loading:
+		return padLines(s.String()+"  Loading checkpoints...\n", height)
+	case b.err != "":
+		return padLines(s.String()+"  "+truncate(b.err, width-4)+"\n", height)
+	case len(b.checkpoints) == 0:
+		return padLines(s.String()+"  No checkpoints yet. Press c to create one.\n", height)
+	}
+
+	start := 0
+	if b.cursor >= rows {
+		start = b.cursor - rows + 1
+	}
+	end := min(start+rows, len(b.checkpoints))
+
+	for i := start; i < end; i++ {
+		cp := b.checkpoints[i]
+		prefix := "  "
+		if i == b.cursor {
+			prefix = cursorStyle.Render("▸ ")
+		}
+		label := padRight(truncate(cp.Label(), colCheckpoint-2), colCheckpoint)
+		s.WriteString(prefix + label + mutedStyle.Render(formatCheckpointTime(cp.CreatedAt)) + "\n")
+	}
+	return padLines(s.String(), height)
+}
+
+// formatCheckpointTime renders a timestamp with its age, like
+// "2026-02-05 14:30 (3h ago)".
+func formatCheckpointTime(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	return fmt.Sprintf("%s (%s)", t.Local().Format("2006-01-02 15:04"), formatAgo(time.Since(t)))
+}
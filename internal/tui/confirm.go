@@ -0,0 +1,151 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// confirmAction identifies what a confirmation dialog option does.
+type confirmAction int
+
+const (
+	actionCancel confirmAction = iota
+	actionCheckpointDestroy
+	actionDestroy
+	actionRestore
+	actionKillSession
+	actionBulkCheckpoint
+	actionBulkRestart
+	actionBulkExec
+	actionReplyYes
+	actionReplyNo
+	actionReplyText
+)
+
+type confirmOption struct {
+	key    string
+	label  string
+	action confirmAction
+}
+
+// confirmDialog is a modal prompt. While open it receives all key input
+// until an option is chosen or it is cancelled with Esc.
+type confirmDialog struct {
+	title   string
+	body    string
+	target  string   // Sprite the action applies to
+	names   []string // Sprites a bulk action applies to, instead of target
+	ref     string   // secondary target, such as a checkpoint ID or command
+	options []confirmOption
+}
+
+func newDestroyDialog(name string) *confirmDialog {
+	return &confirmDialog{
+		title:  fmt.Sprintf("Destroy %s?", name),
+		body:   "This cannot be undone.",
+		target: name,
+		options: []confirmOption{
+			{key: "1", label: "Checkpoint then destroy", action: actionCheckpointDestroy},
+			{key: "2", label: "Destroy without checkpoint", action: actionDestroy},
+			{key: "3", label: "Cancel", action: actionCancel},
+		},
+	}
+}
+
+func newRestoreDialog(name string, cp sprites.Checkpoint) *confirmDialog {
+	return &confirmDialog{
+		title:  fmt.Sprintf("Restore %s to %s?", name, cp.Label()),
+		body:   "Changes since this checkpoint will be lost.",
+		target: name,
+		ref:    cp.ID,
+		options: []confirmOption{
+			{key: "y", label: "Restore", action: actionRestore},
+			{key: "n", label: "Cancel", action: actionCancel},
+		},
+	}
+}
+
+func newKillSessionDialog(name string, es sprites.ExecSession) *confirmDialog {
+	return &confirmDialog{
+		title:  fmt.Sprintf("Kill session %s on %s?", es.ID, name),
+		body:   truncate(es.Command, 60),
+		target: name,
+		ref:    es.ID,
+		options: []confirmOption{
+			{key: "y", label: "Kill", action: actionKillSession},
+			{key: "n", label: "Cancel", action: actionCancel},
+		},
+	}
+}
+
+// newBulkDialog confirms applying action to names. command is the command
+// for actionBulkExec.
+func newBulkDialog(action confirmAction, names []string, command string) *confirmDialog {
+	c := &confirmDialog{
+		body:  nameList(names, 60),
+		names: names,
+		ref:   command,
+	}
+	n := plural(len(names), "Sprite")
+	switch action {
+	case actionCheckpointDestroy, actionDestroy:
+		c.title = fmt.Sprintf("Destroy %s?", n)
+		c.body += "\nThis cannot be undone."
+		c.options = []confirmOption{
+			{key: "1", label: "Checkpoint then destroy", action: actionCheckpointDestroy},
+			{key: "2", label: "Destroy without checkpoint", action: actionDestroy},
+			{key: "3", label: "Cancel", action: actionCancel},
+		}
+		return c
+	case actionBulkCheckpoint:
+		c.title = fmt.Sprintf("Checkpoint %s?", n)
+	case actionBulkRestart:
+		c.title = fmt.Sprintf("Restart Claude Code on %s?", n)
+		c.body += "\nIt resumes the latest conversation; anything in progress stops."
+	case actionBulkExec:
+		c.title = fmt.Sprintf("Run on %s?", n)
+		c.body = "$ " + truncate(command, 56) + "\n" + c.body
+	}
+	c.options = []confirmOption{
+		{key: "y", label: "Run", action: action},
+		{key: "n", label: "Cancel", action: actionCancel},
+	}
+	return c
+}
+
+// choose returns the action bound to key. Esc always cancels. The second
+// result is false when the key is not bound.
+func (c *confirmDialog) choose(key string) (confirmAction, bool) {
+	if key == "esc" {
+		return actionCancel, true
+	}
+	for _, o := range c.options {
+		if o.key == key {
+			return o.action, true
+		}
+	}
+	return actionCancel, false
+}
+
+// View renders the dialog centered in a width x height region.
+func (c *confirmDialog) View(width, height int) string {
+	var b strings.Builder
+	b.WriteString(dialogTitleStyle.Render(c.title))
+	b.WriteString("\n")
+	if c.body != "" {
+		b.WriteString(mutedStyle.Render(c.body))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	for _, o := range c.options {
+		b.WriteString(cursorStyle.Render(o.key) + "  " + o.label + "\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(mutedStyle.Render("Esc to cancel"))
+
+	box := dialogStyle.Render(b.String())
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
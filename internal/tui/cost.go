@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"time"
+
+	"github.com/JPM1118/slua/internal/cost"
+	"github.com/JPM1118/slua/internal/state"
+)
+
+const colCost = 10
+
+// WithCost shows each Sprite's estimated cost this month and the fleet's
+// totals for today and the month, priced with rates. Usage is read from
+// the state file, so costs need WithState too.
+func WithCost(rates cost.Rates) Option {
+	return func(d *Dashboard) {
+		d.rates = rates
+	}
+}
+
+// showCost reports whether costs are shown.
+func (d Dashboard) showCost() bool {
+	return d.rates.Enabled() && d.usage != nil
+}
+
+// showCostColumn reports whether the COST column fits. The detail pane
+// shows the cost instead.
+func (d Dashboard) showCostColumn() bool {
+	return d.showCost() && !d.detail && d.width >= d.cols.Name+d.cols.Status+d.cols.Uptime+colCost
+}
+
+// costs returns each Sprite's cost this month, and the fleet's totals for
+// today and this month, counting the time in the current status up to now.
+func (d Dashboard) costs(now time.Time) (bySprite map[string]float64, today, month float64) {
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	day := now.Format(state.DayFormat)
+	bySprite = make(map[string]float64)
+	for _, u := range cost.Since(d.usage.UsageAt(now), monthStart) {
+		c := d.rates.Of(u.Awake, u.Asleep)
+		bySprite[u.Sprite] += c
+		month += c
+		if u.Day == day {
+			today += c
+		}
+	}
+	return bySprite, today, month
+}
@@ -2,24 +2,42 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"time"
 
+	"github.com/JPM1118/slua/internal/cost"
+	"github.com/JPM1118/slua/internal/notify"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/queue"
 	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/templates"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
 
 const (
-	colName     = 24
-	colStatus   = 14
-	colUptime   = 11
 	minWidth    = 80
 	minHeight   = 24
 	headerLines = 4 // header + subheader + column header + separator
 	footerLines = 2 // status bar + notification bar
 )
 
+// Columns holds the width of each Sprite list column, in cells.
+type Columns struct {
+	Name   int
+	Status int
+	Uptime int
+}
+
+// DefaultColumns are the column widths used unless WithColumns says
+// otherwise.
+var DefaultColumns = Columns{Name: 24, Status: 16, Uptime: 11}
+
 // Messages
 
 type spritesLoadedMsg struct {
@@ -31,24 +49,265 @@ type consoleFinishedMsg struct {
 	err error
 }
 
+type pollCycleMsg struct {
+	cycle poller.Cycle
+}
+
+type destroyFinishedMsg struct {
+	name string
+	err  error
+}
+
+type checkpointFinishedMsg struct {
+	name    string
+	comment string
+	err     error
+}
+
+type autoCheckpointFinishedMsg struct {
+	name    string
+	comment string // empty if the checkpoint was not created
+	pruned  int
+	err     error
+}
+
+type checkpointsLoadedMsg struct {
+	name        string
+	checkpoints []sprites.Checkpoint
+	err         error
+}
+
+type restoreFinishedMsg struct {
+	name string
+	id   string
+	err  error
+}
+
+type stateSavedMsg struct {
+	err   error
+	saved *state.State // Sprites, usage and tasks as saved
+}
+
+type createProgressMsg struct {
+	name     string
+	progress templates.Progress
+	next     tea.Cmd // waits for the following message
+}
+
+type createFinishedMsg struct {
+	name     string
+	template string
+	err      error
+	sprites  []sprites.Sprite // listed after the attempt; nil if listing failed
+}
+
 // Dashboard is the main Bubble Tea model.
 type Dashboard struct {
-	cli        sprites.SpriteSource
-	sprites    []sprites.Sprite
-	cursor     int
-	width      int
-	height     int
-	err        error
-	loading    bool
-	lastErr    string // transient error shown in notification bar
+	cli      sprites.SpriteSource
+	poller   *poller.Poller
+	notifier *notify.Notifier
+	store    *state.Store
+	saved    string // signature of the Sprite list last written to store
+	policies sprites.AutoCheckpointPolicies
+	cols     Columns
+	lastCkpt map[string]time.Time // last successful checkpoint per Sprite
+	sprites  []sprites.Sprite
+	results  map[string]poller.Result // latest detection per Sprite
+	lastPoll time.Time
+	pending  map[string]string // in-flight lifecycle state per Sprite
+	confirm  *confirmDialog    // open modal dialog, if any
+	browser  *checkpointBrowser
+	logs     *logViewer
+	picker   *sessionPicker
+	// preferred is the exec session Enter attaches to per Sprite, and
+	// checking the Sprite whose session is being looked up first.
+	preferred map[string]string
+	checking  string
+	marked    map[string]bool          // rows marked for a bulk action
+	anchor    string                   // row last marked or unmarked, where V starts
+	bulk      *bulkRun                 // latest bulk action
+	orgs      []string                 // organizations listed, if several
+	orgErrs   map[string]error         // organizations whose last list failed
+	shared    map[string]bool          // names listed by several organizations
+	tags      map[string][]string      // local tags per Sprite
+	madeFrom  map[string]string        // template each Sprite was created from
+	tagger    *tagEditor               // tags being edited
+	task      *taskEditor              // prompt being written for Claude Code
+	prompts   map[string][]state.Event // prompts sent per Sprite, newest first
+	queue     *queue.Options           // task dispatch settings, nil if off
+	tasks     []state.Task             // tasks as last saved
+	forTask   map[string]state.Task    // task each Sprite is being created for
+	assigning bool                     // a dispatch is under way
+	groupBy   string                   // how the list is grouped, see groupModes
+	collapsed map[string]bool          // groups shown as a single header row
+	rates     cost.Rates
+	usage     *state.State       // last saved Sprites and usage, nil without a store
+	onSave    func(*state.State) // called with each saved state
+	prompt    *commandPrompt     // command being typed to run on Sprites
+	wizard    *spriteWizard
+	tmpls     []templates.Template
+	creating  map[string]string // progress of Sprites being created
+	detail    bool              // detail pane open
+	details   map[string]*spriteDetail
+	frame     int // spinner frame
+	spinning  bool
+	cursor    int    // index into the filtered list
+	filter    string // search query; empty shows every Sprite
+	search    bool   // typing into the filter
+	width     int
+	height    int
+	err       error
+	loading   bool
+	lastErr   string // transient error shown in notification bar
+	notice    string // informational message shown when there is no error
+	bar       notify.Bar
+}
+
+// Option configures a Dashboard.
+type Option func(*Dashboard)
+
+// WithPoller feeds state detection results from p into the dashboard.
+// The caller is responsible for running p.
+func WithPoller(p *poller.Poller) Option {
+	return func(d *Dashboard) {
+		d.poller = p
+	}
+}
+
+// WithNotifier suspends n while a console has the terminal and shows what
+// it held back in the notification bar afterwards. The caller is
+// responsible for feeding n, typically with poller.Observe.
+func WithNotifier(n *notify.Notifier) Option {
+	return func(d *Dashboard) {
+		d.notifier = n
+	}
+}
+
+// WithState starts the dashboard from the Sprites remembered in store, so
+// the list shows immediately, and records list changes, transitions and
+// actions back to it. The first List reconciles the remembered Sprites.
+func WithState(store *state.Store) Option {
+	return func(d *Dashboard) {
+		d.store = store
+		st, err := store.Load()
+		if err != nil {
+			d.lastErr = fmt.Sprintf("Could not read saved state: %s", err.Error())
+		}
+		d.sprites = st.List()
+		d.saved = signature(d.sprites)
+		d.preferred = st.Sessions()
+		d.tags = st.Tags()
+		d.madeFrom = st.Templates()
+		d.prompts = st.Prompts()
+		d.tasks = st.Tasks
+		d.usage = st
+	}
+}
+
+// WithOnSave calls fn with the state each time the dashboard saves it,
+// from the Update loop, so fn must not block.
+func WithOnSave(fn func(*state.State)) Option {
+	return func(d *Dashboard) {
+		d.onSave = fn
+	}
+}
+
+// WithAutoCheckpoint checkpoints Sprites when Claude Code goes from WORKING
+// to FINISHED, according to policies.
+func WithAutoCheckpoint(policies sprites.AutoCheckpointPolicies) Option {
+	return func(d *Dashboard) {
+		d.policies = policies
+	}
+}
+
+// WithTemplates sets the templates offered when creating a Sprite. The
+// built-in blank template is always available.
+func WithTemplates(ts []templates.Template) Option {
+	return func(d *Dashboard) {
+		if len(ts) > 0 {
+			d.tmpls = ts
+		}
+	}
+}
+
+// templateOf returns the template name was created from, or the blank
+// template if slua did not create it or the template is gone.
+func (d Dashboard) templateOf(name string) templates.Template {
+	if from, ok := d.madeFrom[name]; ok {
+		for _, t := range d.tmpls {
+			if t.Name == from {
+				return t
+			}
+		}
+	}
+	return templates.Template{Name: templates.Blank}
+}
+
+// agent returns the agent process and tmux pane name's detector watches.
+func (d Dashboard) agent(name string) (process, target string) {
+	var det poller.Detector
+	if d.poller != nil {
+		det = d.poller.Detector(name)
+	}
+	return poller.AgentOf(det)
+}
+
+// WithWarnings shows problems found while starting up, such as settings
+// that cannot take effect on this machine, until the next message.
+func WithWarnings(warnings ...string) Option {
+	return func(d *Dashboard) {
+		if len(warnings) > 0 {
+			d.notice = "Warning: " + strings.Join(warnings, "; ")
+		}
+	}
+}
+
+// WithColumns overrides the Sprite list column widths. Zero fields keep
+// the default.
+func WithColumns(c Columns) Option {
+	return func(d *Dashboard) {
+		if c.Name > 0 {
+			d.cols.Name = c.Name
+		}
+		if c.Status > 0 {
+			d.cols.Status = c.Status
+		}
+		if c.Uptime > 0 {
+			d.cols.Uptime = c.Uptime
+		}
+	}
 }
 
 // NewDashboard creates a new dashboard model.
-func NewDashboard(cli sprites.SpriteSource) Dashboard {
-	return Dashboard{
-		cli:     cli,
-		loading: true,
+func NewDashboard(cli sprites.SpriteSource, opts ...Option) Dashboard {
+	d := Dashboard{
+		cli:       cli,
+		cols:      DefaultColumns,
+		loading:   true,
+		results:   make(map[string]poller.Result),
+		pending:   make(map[string]string),
+		creating:  make(map[string]string),
+		details:   make(map[string]*spriteDetail),
+		preferred: make(map[string]string),
+		marked:    make(map[string]bool),
+		tags:      make(map[string][]string),
+		madeFrom:  make(map[string]string),
+		prompts:   make(map[string][]state.Event),
+		forTask:   make(map[string]state.Task),
+		collapsed: make(map[string]bool),
+		lastCkpt:  make(map[string]time.Time),
+		tmpls:     templates.Sorted(nil),
+	}
+	if src, ok := cli.(orgSource); ok {
+		d.orgs = src.Orgs()
+		if len(d.orgs) > 1 {
+			d.groupBy = groupOrg
+		}
+	}
+	for _, opt := range opts {
+		opt(&d)
 	}
+	return d
 }
 
 // Err returns any fatal error that occurred.
@@ -56,8 +315,11 @@ func (d Dashboard) Err() error {
 	return d.err
 }
 
-// Init loads the initial sprite list.
+// Init loads the initial sprite list and starts listening for poll results.
 func (d Dashboard) Init() tea.Cmd {
+	if d.poller != nil {
+		return tea.Batch(d.loadSprites(), d.waitForPoll())
+	}
 	return d.loadSprites()
 }
 
@@ -68,12 +330,161 @@ func (d Dashboard) loadSprites() tea.Cmd {
 	}
 }
 
+// waitForPoll blocks until the poller publishes its next cycle.
+func (d Dashboard) waitForPoll() tea.Cmd {
+	if d.poller == nil {
+		return nil
+	}
+	updates := d.poller.Updates()
+	return func() tea.Msg {
+		return pollCycleMsg{cycle: <-updates}
+	}
+}
+
+// setSprites replaces the Sprite list, overlaying the latest detection
+// results on Sprites that are still awake. Pending destroys are resolved
+// once the Sprite no longer appears in the list.
+func (d *Dashboard) setSprites(list []sprites.Sprite) {
+	present := make(map[string]bool, len(list))
+	for _, s := range list {
+		present[s.Name] = true
+	}
+	for name := range d.marked {
+		if !present[name] {
+			delete(d.marked, name)
+		}
+	}
+	for name, st := range d.pending {
+		if st == sprites.StatusDestroying && !present[name] {
+			delete(d.pending, name)
+			d.notice = fmt.Sprintf("Destroyed %s", name)
+		}
+	}
+
+	// Keep a row for Sprites still being created until the API lists them.
+	for name := range d.creating {
+		if !present[name] {
+			list = append(list, sprites.Sprite{Name: name, Status: sprites.StatusCreating})
+		}
+	}
+
+	if src, ok := d.cli.(orgSource); ok {
+		d.orgErrs = src.OrgErrors()
+		d.shared = d.sharedNames(list)
+	}
+
+	for i, s := range list {
+		if !poller.Pollable(s) {
+			delete(d.results, s.Name)
+			continue
+		}
+		if r, ok := d.results[s.Name]; ok {
+			list[i].Status = r.Status
+		}
+	}
+	d.sprites = list
+	d.clampCursor()
+}
+
+// displayStatus returns the status to show for s, preferring any in-flight
+// lifecycle operation over the polled state.
+func (d Dashboard) displayStatus(s sprites.Sprite) string {
+	if st, ok := d.pending[s.Name]; ok {
+		return st
+	}
+	return s.Status
+}
+
+// startSpinner begins the spinner animation unless it is already running.
+func (d *Dashboard) startSpinner() tea.Cmd {
+	if d.spinning {
+		return nil
+	}
+	d.spinning = true
+	return spinnerTick()
+}
+
+func (d *Dashboard) clampCursor() {
+	if n := len(d.visible()); d.cursor >= n {
+		d.cursor = max(0, n-1)
+	}
+}
+
+// visible returns the rows of the list: the Sprites that pass the current
+// filter, grouped when a grouping is chosen.
+func (d Dashboard) visible() []match {
+	list := filterSprites(d.sprites, d.filter, d.tags, d.displayName)
+	if d.groupBy == groupNone {
+		return list
+	}
+	return d.group(list)
+}
+
+// selected returns the Sprite under the cursor in the filtered list. It
+// reports false on a collapsed group's header.
+func (d Dashboard) selected() (sprites.Sprite, bool) {
+	v := d.visible()
+	if len(v) == 0 || v[d.cursor].folded > 0 {
+		return sprites.Sprite{}, false
+	}
+	return v[d.cursor].sprite, true
+}
+
+// setFilter changes the search query, keeping the cursor on the same
+// Sprite when it still matches.
+func (d *Dashboard) setFilter(query string) {
+	current, _ := d.selected()
+	d.filter = query
+	d.cursor = 0
+	for i, m := range d.visible() {
+		if m.sprite.Name == current.Name {
+			d.cursor = i
+			break
+		}
+	}
+}
+
 // Update handles messages.
 func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 
 	case tea.KeyMsg:
-		return d.handleKey(msg)
+		prev, _ := d.selected()
+		model, cmd := d.handleKey(msg)
+		// Moving the cursor fetches the new Sprite's details.
+		if next, ok := model.(Dashboard); ok {
+			if s, ok := next.selected(); ok && s.Name != prev.Name {
+				cmd = tea.Batch(cmd, next.scheduleDetail())
+			}
+		}
+		return model, cmd
+
+	case logsLoadedMsg, logTickMsg, servicesLoadedMsg:
+		return d.updateLogs(msg)
+
+	case sessionsLoadedMsg, sessionKilledMsg, sessionCheckedMsg:
+		return d.updateSessions(msg)
+
+	case execFinishedMsg:
+		d.recordBulk(msg.name, msg.err)
+		return d, d.invalidateDetail(msg.name)
+
+	case replySentMsg:
+		return d.updateReply(msg)
+
+	case taskSentMsg:
+		return d.updateTask(msg)
+
+	case tasksDispatchedMsg:
+		return d.updateDispatch(msg)
+
+	case detailDueMsg:
+		return d, d.loadDetail(msg.name)
+
+	case detailLoadedMsg:
+		det := msg.detail
+		d.details[msg.name] = &det
+		return d, nil
 
 	case tea.WindowSizeMsg:
 		d.width = msg.Width
@@ -91,16 +502,148 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				d.lastErr = fmt.Sprintf("Refresh failed: %s", msg.err.Error())
 			}
 		} else {
-			d.sprites = msg.sprites
+			d.setSprites(msg.sprites)
 			d.lastErr = ""
 		}
-		// Clamp cursor
-		if d.cursor >= len(d.sprites) {
-			d.cursor = max(0, len(d.sprites)-1)
+		return d, d.saveState()
+
+	case pollCycleMsg:
+		cmd := d.applyCycle(msg.cycle)
+		if d.notifier != nil {
+			if err := d.notifier.Err(); err != nil {
+				d.lastErr = fmt.Sprintf("Notification failed: %s", err.Error())
+			}
+		}
+		if msg.cycle.Err == nil {
+			cmd = tea.Batch(cmd, d.dispatch())
+		}
+		return d, tea.Batch(cmd, d.waitForPoll())
+
+	case spinnerTickMsg:
+		if len(d.pending) == 0 {
+			d.spinning = false
+			return d, nil
+		}
+		d.frame = (d.frame + 1) % len(spinnerFrames)
+		return d, spinnerTick()
+
+	case destroyFinishedMsg:
+		bulk := d.recordBulk(msg.name, msg.err)
+		if msg.err != nil {
+			delete(d.pending, msg.name)
+			if !bulk {
+				d.lastErr = fmt.Sprintf("Destroy %s failed: %s", msg.name, msg.err.Error())
+			}
+			return d, nil
+		}
+		// Keep DESTROYING until the next list confirms the Sprite is gone.
+		if !bulk {
+			d.notice = fmt.Sprintf("Destroying %s…", msg.name)
+		}
+		destroyed := state.Event{At: time.Now(), Sprite: msg.name, Kind: state.EventDestroy, User: state.CurrentUser()}
+		return d, tea.Batch(d.refresh(), d.saveState(destroyed))
+
+	case checkpointFinishedMsg:
+		delete(d.pending, msg.name)
+		bulk := d.recordBulk(msg.name, msg.err)
+		if msg.err != nil {
+			if !bulk {
+				d.lastErr = fmt.Sprintf("Checkpoint %s failed: %s", msg.name, msg.err.Error())
+			}
+			return d, nil
+		}
+		d.lastCkpt[msg.name] = time.Now()
+		if !bulk {
+			d.notice = fmt.Sprintf("Checkpointed %s as %s", msg.name, msg.comment)
+		}
+		return d, tea.Batch(d.reloadBrowser(msg.name), d.invalidateDetail(msg.name), d.saveState(checkpointEvent(msg.name, msg.comment, state.CurrentUser())))
+
+	case autoCheckpointFinishedMsg:
+		delete(d.pending, msg.name)
+		switch {
+		case msg.comment == "":
+			d.lastErr = fmt.Sprintf("Auto-checkpoint %s failed: %s", msg.name, msg.err.Error())
+			return d, nil
+		case msg.err != nil:
+			d.lastErr = fmt.Sprintf("Auto-checkpointed %s as %s, but %s", msg.name, msg.comment, msg.err.Error())
+		default:
+			d.notice = fmt.Sprintf("Auto-checkpointed %s as %s", msg.name, msg.comment)
+			if msg.pruned > 0 {
+				d.notice += fmt.Sprintf(" (pruned %d old)", msg.pruned)
+			}
+		}
+		d.lastCkpt[msg.name] = time.Now()
+		return d, tea.Batch(d.reloadBrowser(msg.name), d.invalidateDetail(msg.name), d.saveState(checkpointEvent(msg.name, msg.comment, "")))
+
+	case checkpointsLoadedMsg:
+		if d.browser != nil && d.browser.sprite == msg.name {
+			d.browser.setCheckpoints(msg.checkpoints, msg.err)
 		}
 		return d, nil
 
+	case restoreFinishedMsg:
+		if msg.err != nil {
+			d.lastErr = fmt.Sprintf("Restore %s failed: %s", msg.name, msg.err.Error())
+			return d, nil
+		}
+		d.notice = fmt.Sprintf("Restored %s to %s", msg.name, msg.id)
+		restored := state.Event{At: time.Now(), Sprite: msg.name, Kind: state.EventRestore, Detail: msg.id, User: state.CurrentUser()}
+		return d, tea.Batch(d.refresh(), d.invalidateDetail(msg.name), d.saveState(restored))
+
+	case stateSavedMsg:
+		if msg.err != nil {
+			d.lastErr = fmt.Sprintf("Saving state failed: %s", msg.err.Error())
+			return d, nil
+		}
+		d.usage = msg.saved
+		d.tasks = msg.saved.Tasks
+		d.madeFrom = msg.saved.Templates()
+		if d.onSave != nil {
+			d.onSave(msg.saved)
+		}
+		return d, nil
+
+	case createProgressMsg:
+		if _, ok := d.creating[msg.name]; ok {
+			d.creating[msg.name] = msg.progress.String()
+		}
+		return d, msg.next
+
+	case createFinishedMsg:
+		list := msg.sprites
+		if list == nil {
+			list = d.listed()
+		}
+		delete(d.creating, msg.name)
+		delete(d.pending, msg.name)
+		d.setSprites(list)
+		if d.poller != nil {
+			d.poller.Trigger()
+		}
+
+		d.notice = ""
+		d.madeFrom[msg.name] = msg.template
+		created := state.Event{At: time.Now(), Sprite: msg.name, Kind: state.EventCreate, Detail: msg.template, User: state.CurrentUser()}
+		var stepErr *templates.StepError
+		switch {
+		case msg.err == nil:
+			d.notice = fmt.Sprintf("Created %s from %s", msg.name, msg.template)
+		case errors.As(msg.err, &stepErr):
+			// The Sprite exists but is only partly set up.
+			d.lastErr = fmt.Sprintf("Created %s, but setup failed at %s", msg.name, msg.err.Error())
+			created.Detail += " (" + msg.err.Error() + ")"
+		default:
+			d.lastErr = fmt.Sprintf("Create %s failed: %s", msg.name, msg.err.Error())
+			_, start := d.createdForTask(msg.name, msg.err)
+			return d, tea.Batch(d.saveState(), start)
+		}
+		events, start := d.createdForTask(msg.name, msg.err)
+		return d, tea.Batch(d.saveState(append([]state.Event{created}, events...)...), start)
+
 	case consoleFinishedMsg:
+		if d.notifier != nil {
+			d.bar.Push(d.notifier.Resume()...)
+		}
 		if msg.err != nil {
 			d.lastErr = fmt.Sprintf("Console error: %s", msg.err.Error())
 		}
@@ -112,12 +655,40 @@ func (d Dashboard) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (d Dashboard) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if d.confirm != nil {
+		return d.handleConfirmKey(msg)
+	}
+	if d.wizard != nil {
+		return d.handleWizardKey(msg)
+	}
+	if d.prompt != nil {
+		return d.handlePromptKey(msg)
+	}
+	if d.tagger != nil {
+		return d.handleTagKey(msg)
+	}
+	if d.task != nil {
+		return d.handleTaskKey(msg)
+	}
+	if d.logs != nil {
+		return d.handleLogsKey(msg)
+	}
+	if d.picker != nil {
+		return d.handleSessionsKey(msg)
+	}
+	if d.browser != nil {
+		return d.handleBrowserKey(msg)
+	}
+	if d.search {
+		return d.handleSearchKey(msg)
+	}
+
 	switch msg.String() {
 	case "q", "ctrl+c":
 		return d, tea.Quit
 
 	case "j", "down":
-		if d.cursor < len(d.sprites)-1 {
+		if d.cursor < len(d.visible())-1 {
 			d.cursor++
 		}
 		return d, nil
@@ -128,23 +699,133 @@ func (d Dashboard) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return d, nil
 
+	case "/":
+		d.search = true
+		return d, nil
+
+	case "esc":
+		if len(d.marked) > 0 {
+			clear(d.marked)
+			return d, nil
+		}
+		d.setFilter("")
+		return d, nil
+
+	case " ":
+		d.toggleMark()
+		return d, nil
+
+	case "V":
+		d.markRange()
+		return d, nil
+
+	case "*":
+		d.markAll()
+		return d, nil
+
+	case "R":
+		return d.confirmBulk(actionBulkRestart)
+
+	case "e":
+		if names := d.targets(); len(names) > 0 {
+			d.prompt = &commandPrompt{names: names}
+		}
+		return d, nil
+
 	case "enter":
-		if len(d.sprites) == 0 {
+		s, ok := d.selected()
+		if !ok {
+			d.toggleGroup()
 			return d, nil
 		}
-		s := d.sprites[d.cursor]
-		c := d.cli.ConsoleCmd(s.Name)
-		return d, tea.ExecProcess(c, func(err error) tea.Msg {
-			return consoleFinishedMsg{err: err}
-		})
+		id := d.preferred[s.Name]
+		if id == "" {
+			return d.connect(s.Name, "")
+		}
+		d.checking = s.Name
+		d.notice = fmt.Sprintf("Attaching to session %s on %s…", id, s.Name)
+		return d, d.checkSession(s.Name, id)
+
+	case "d":
+		s, ok := d.selected()
+		if !ok {
+			return d, nil
+		}
+		if st, busy := d.pending[s.Name]; busy && len(d.marked) == 0 {
+			d.notice = fmt.Sprintf("%s is already %s", s.Name, strings.ToLower(st))
+			return d, nil
+		}
+		return d.confirmBulk(actionCheckpointDestroy)
+
+	case "c":
+		return d.confirmBulk(actionBulkCheckpoint)
+
+	case "n":
+		d.wizard = newSpriteWizard(d.tmpls, d.sprites, d.orgs, d.spriteKey)
+		return d, nil
+
+	case "C":
+		s, ok := d.selected()
+		if !ok {
+			return d, nil
+		}
+		name := s.Name
+		d.browser = newCheckpointBrowser(name)
+		return d, d.loadCheckpoints(name)
+
+	case "s":
+		s, ok := d.selected()
+		if !ok {
+			return d, nil
+		}
+		d.picker = newSessionPicker(s.Name, d.preferred[s.Name])
+		return d, d.loadSessions(s.Name)
+
+	case "l":
+		s, ok := d.selected()
+		if !ok {
+			return d, nil
+		}
+		return d.openLogs(s)
+
+	case "a":
+		return d.openReply()
+
+	case "p":
+		if s, ok := d.selected(); ok {
+			d.task = &taskEditor{sprite: s.Name}
+		}
+		return d, nil
+
+	case "t":
+		if s, ok := d.selected(); ok {
+			d.tagger = newTagEditor(s.Name, d.tags[s.Name])
+		}
+		return d, nil
+
+	case "b":
+		d.cycleGroup()
+		return d, nil
+
+	case "z":
+		d.toggleGroup()
+		return d, nil
+
+	case "i":
+		d.detail = !d.detail
+		return d, d.scheduleDetail()
 
 	case "r":
 		d.loading = true
-		return d, d.loadSprites()
+		cmd := d.refresh()
+		if s, ok := d.selected(); ok {
+			cmd = tea.Batch(cmd, d.invalidateDetail(s.Name))
+		}
+		return d, cmd
 
 	case "G":
-		if len(d.sprites) > 0 {
-			d.cursor = len(d.sprites) - 1
+		if n := len(d.visible()); n > 0 {
+			d.cursor = n - 1
 		}
 		return d, nil
 
@@ -156,6 +837,425 @@ func (d Dashboard) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return d, nil
 }
 
+// handleSearchKey edits the filter while search mode is active. Enter
+// keeps the filter, Esc clears it; both return to normal mode.
+func (d Dashboard) handleSearchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return d, tea.Quit
+
+	case tea.KeyEnter:
+		d.search = false
+		return d, nil
+
+	case tea.KeyEsc:
+		d.search = false
+		d.setFilter("")
+		return d, nil
+
+	case tea.KeyBackspace:
+		if r := []rune(d.filter); len(r) > 0 {
+			d.setFilter(string(r[:len(r)-1]))
+		}
+		return d, nil
+
+	case tea.KeyDown:
+		if d.cursor < len(d.visible())-1 {
+			d.cursor++
+		}
+		return d, nil
+
+	case tea.KeyUp:
+		if d.cursor > 0 {
+			d.cursor--
+		}
+		return d, nil
+
+	case tea.KeyRunes, tea.KeySpace:
+		d.setFilter(d.filter + string(msg.Runes))
+		return d, nil
+	}
+	return d, nil
+}
+
+// handleConfirmKey routes input to the open dialog. Everything except
+// ctrl+c is blocked until the dialog is answered.
+func (d Dashboard) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.String() == "ctrl+c" {
+		return d, tea.Quit
+	}
+	action, ok := d.confirm.choose(msg.String())
+	if !ok {
+		return d, nil
+	}
+	name, names, ref := d.confirm.target, d.confirm.names, d.confirm.ref
+	d.confirm = nil
+	if len(names) > 0 && action != actionCancel {
+		return d.startBulk(action, names, ref)
+	}
+
+	switch action {
+	case actionCheckpointDestroy:
+		return d.startDestroy(name, true)
+	case actionDestroy:
+		return d.startDestroy(name, false)
+	case actionRestore:
+		return d.startRestore(name, ref)
+	case actionKillSession:
+		return d.startKillSession(name, ref)
+	case actionReplyYes:
+		return d.startReply(name, "y")
+	case actionReplyNo:
+		return d.startReply(name, "n")
+	case actionReplyText:
+		d.prompt = &commandPrompt{names: []string{name}, reply: true}
+	}
+	return d, nil
+}
+
+// handleWizardKey routes input to the new-Sprite wizard and starts the
+// creation once it is complete.
+func (d Dashboard) handleWizardKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return d, tea.Quit
+	case "esc":
+		d.wizard = nil
+		return d, nil
+	}
+	w := d.wizard
+	if !w.key(msg) {
+		return d, nil
+	}
+	d.wizard = nil
+	return d.startCreate(w.organization(), w.name, w.region, w.template())
+}
+
+// handleBrowserKey handles input while the checkpoint browser is open.
+func (d Dashboard) handleBrowserKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	b := d.browser
+	switch msg.String() {
+	case "ctrl+c":
+		return d, tea.Quit
+
+	case "esc", "q":
+		d.browser = nil
+		return d, nil
+
+	case "j", "down":
+		if b.cursor < len(b.checkpoints)-1 {
+			b.cursor++
+		}
+		return d, nil
+
+	case "k", "up":
+		if b.cursor > 0 {
+			b.cursor--
+		}
+		return d, nil
+
+	case "c":
+		return d.startCheckpoint(b.sprite)
+
+	case "r":
+		b.loading = true
+		return d, d.loadCheckpoints(b.sprite)
+
+	case "enter":
+		if cp, ok := b.selected(); ok {
+			d.confirm = newRestoreDialog(b.sprite, cp)
+		}
+		return d, nil
+	}
+	return d, nil
+}
+
+// startCheckpoint marks name as CHECKPOINTING and creates a manual
+// checkpoint in the background.
+func (d Dashboard) startCheckpoint(name string) (tea.Model, tea.Cmd) {
+	if st, busy := d.pending[name]; busy {
+		d.notice = fmt.Sprintf("%s is already %s", name, strings.ToLower(st))
+		return d, nil
+	}
+	d.lastErr = ""
+	d.notice = fmt.Sprintf("Checkpointing %s…", name)
+	return d, tea.Batch(d.checkpointCmd(name), d.startSpinner())
+}
+
+// checkpointCmd marks name as CHECKPOINTING and returns the command that
+// creates a manual checkpoint of it.
+func (d *Dashboard) checkpointCmd(name string) tea.Cmd {
+	d.pending[name] = sprites.StatusCheckpointing
+	src := d.cli
+	comment := sprites.CheckpointName(sprites.CheckpointPrefixManual, time.Now())
+	return func() tea.Msg {
+		err := src.Checkpoint(context.Background(), name, comment)
+		return checkpointFinishedMsg{name: name, comment: comment, err: err}
+	}
+}
+
+// startCreate creates name in org ("" for the first) from t in the
+// background, showing it as CREATING with the current step until it is
+// done.
+func (d Dashboard) startCreate(org, name, region string, t templates.Template) (tea.Model, tea.Cmd) {
+	key := d.spriteKey(org, name)
+	d.pending[key] = sprites.StatusCreating
+	d.creating[key] = "creating"
+	d.lastErr = ""
+	d.notice = fmt.Sprintf("Creating %s…", name)
+	d.setSprites(d.listed())
+
+	src := d.cli
+	var dst sprites.SpriteSource = src
+	if multi, ok := src.(orgSource); ok {
+		dst = multi.In(org)
+	}
+	create := func() tea.Msg {
+		// Buffered for every report so Provision never waits on the UI.
+		progress := make(chan templates.Progress, t.Reports())
+		done := make(chan createFinishedMsg, 1)
+		go func() {
+			ctx := context.Background()
+			err := templates.Provision(ctx, dst, name, region, t, func(p templates.Progress) {
+				progress <- p
+			})
+			// List here so the result and the new row arrive together.
+			list, _ := src.List(ctx)
+			close(progress)
+			done <- createFinishedMsg{name: key, template: t.Name, err: err, sprites: list}
+		}()
+		return nextCreateMsg(key, progress, done)
+	}
+	return d, tea.Batch(create, d.startSpinner())
+}
+
+// nextCreateMsg waits for the next progress report, or the result once
+// progress is closed.
+func nextCreateMsg(name string, progress <-chan templates.Progress, done <-chan createFinishedMsg) tea.Msg {
+	p, ok := <-progress
+	if !ok {
+		return <-done
+	}
+	return createProgressMsg{name: name, progress: p, next: func() tea.Msg {
+		return nextCreateMsg(name, progress, done)
+	}}
+}
+
+// listed returns the Sprites from the last list, without placeholders for
+// Sprites still being created.
+func (d Dashboard) listed() []sprites.Sprite {
+	var list []sprites.Sprite
+	for _, s := range d.sprites {
+		if _, ok := d.creating[s.Name]; ok && s.Status == sprites.StatusCreating && s.ID == "" {
+			continue
+		}
+		list = append(list, s)
+	}
+	return list
+}
+
+// startRestore restores name to checkpoint id in the background.
+func (d Dashboard) startRestore(name, id string) (tea.Model, tea.Cmd) {
+	d.lastErr = ""
+	d.notice = fmt.Sprintf("Restoring %s to %s…", name, id)
+
+	src := d.cli
+	return d, func() tea.Msg {
+		err := src.RestoreCheckpoint(context.Background(), name, id)
+		return restoreFinishedMsg{name: name, id: id, err: err}
+	}
+}
+
+// startAutoCheckpoint checkpoints name after Claude Code finished, unless
+// the policy disables it, another operation is in flight, or the Sprite was
+// checkpointed within the dedup window.
+func (d *Dashboard) startAutoCheckpoint(name string) tea.Cmd {
+	policy := d.policies.For(name)
+	if !policy.Enabled {
+		return nil
+	}
+	if _, busy := d.pending[name]; busy {
+		return nil
+	}
+	if last, ok := d.lastCkpt[name]; ok && time.Since(last) < policy.DedupWindow {
+		return nil
+	}
+
+	d.pending[name] = sprites.StatusCheckpointing
+	d.notice = fmt.Sprintf("Auto-checkpointing %s…", name)
+
+	src := d.cli
+	checkpoint := func() tea.Msg {
+		comment, pruned, err := sprites.CheckpointAndPrune(context.Background(), src, name, sprites.CheckpointPrefixAuto, policy.Keep)
+		return autoCheckpointFinishedMsg{name: name, comment: comment, pruned: pruned, err: err}
+	}
+	return tea.Batch(checkpoint, d.startSpinner())
+}
+
+// reloadBrowser refreshes the checkpoint browser if it shows name.
+func (d Dashboard) reloadBrowser(name string) tea.Cmd {
+	if d.browser == nil || d.browser.sprite != name {
+		return nil
+	}
+	return d.loadCheckpoints(name)
+}
+
+func (d Dashboard) loadCheckpoints(name string) tea.Cmd {
+	src := d.cli
+	return func() tea.Msg {
+		cps, err := src.ListCheckpoints(context.Background(), name)
+		return checkpointsLoadedMsg{name: name, checkpoints: cps, err: err}
+	}
+}
+
+// startDestroy marks name as DESTROYING and runs the destroy in the
+// background, checkpointing first when requested.
+func (d Dashboard) startDestroy(name string, checkpointFirst bool) (tea.Model, tea.Cmd) {
+	d.lastErr = ""
+	if checkpointFirst {
+		d.notice = fmt.Sprintf("Checkpointing %s before destroy…", name)
+	} else {
+		d.notice = fmt.Sprintf("Destroying %s…", name)
+	}
+	return d, tea.Batch(d.destroyCmd(name, checkpointFirst), d.startSpinner())
+}
+
+// destroyCmd marks name as DESTROYING and returns the command that
+// destroys it, checkpointing first if asked.
+func (d *Dashboard) destroyCmd(name string, checkpointFirst bool) tea.Cmd {
+	d.pending[name] = sprites.StatusDestroying
+	src := d.cli
+	return func() tea.Msg {
+		ctx := context.Background()
+		if checkpointFirst {
+			comment := sprites.CheckpointName(sprites.CheckpointPrefixManual, time.Now())
+			if err := src.Checkpoint(ctx, name, comment); err != nil {
+				return destroyFinishedMsg{name: name, err: fmt.Errorf("checkpoint failed, not destroying: %w", err)}
+			}
+		}
+		return destroyFinishedMsg{name: name, err: src.Destroy(ctx, name)}
+	}
+}
+
+// refresh reloads the Sprite list and asks the poller for an early cycle.
+func (d Dashboard) refresh() tea.Cmd {
+	if d.poller != nil {
+		d.poller.Trigger()
+	}
+	return d.loadSprites()
+}
+
+// applyCycle merges a poll cycle into the dashboard and reacts to status
+// transitions. A failed list keeps the previous data on screen.
+func (d *Dashboard) applyCycle(c poller.Cycle) tea.Cmd {
+	if c.Err != nil {
+		if d.sprites != nil {
+			d.lastErr = fmt.Sprintf("Poll failed: %s", c.Err.Error())
+		}
+		return nil
+	}
+	d.results = c.Results
+	if d.results == nil {
+		d.results = make(map[string]poller.Result)
+	}
+	d.lastPoll = c.At
+	d.loading = false
+	d.lastErr = ""
+	d.setSprites(c.Sprites)
+
+	var cmds []tea.Cmd
+	var events []state.Event
+	for _, t := range c.Transitions() {
+		cmds = append(cmds, d.handleTransition(t))
+		events = append(events, state.Event{
+			At:     t.At,
+			Sprite: t.Name,
+			Kind:   state.EventTransition,
+			From:   t.From,
+			To:     t.To,
+			Detail: d.results[t.Name].Detail,
+		})
+	}
+	cmds = append(cmds, d.saveState(events...), d.settleTasks(c.Results))
+	return tea.Batch(cmds...)
+}
+
+// saveState writes events, and the Sprite list if it changed since the
+// last write, to the state store in the background.
+func (d *Dashboard) saveState(events ...state.Event) tea.Cmd {
+	if d.store == nil {
+		return nil
+	}
+	var list []sprites.Sprite
+	if current := d.listed(); signature(current) != d.saved {
+		list = append([]sprites.Sprite{}, current...)
+		d.saved = signature(current)
+	}
+	if list == nil && len(events) == 0 {
+		return nil
+	}
+
+	at := time.Now()
+	return d.updateState(func(st *state.State) {
+		if list != nil {
+			st.Reconcile(list, at)
+		}
+		for _, e := range events {
+			st.Record(e)
+		}
+	})
+}
+
+// updateState applies fn to the state store in the background.
+func (d *Dashboard) updateState(fn func(*state.State)) tea.Cmd {
+	if d.store == nil {
+		return nil
+	}
+	store := d.store
+	return func() tea.Msg {
+		saved := &state.State{}
+		err := store.Update(func(st *state.State) {
+			fn(st)
+			saved.Sprites = slices.Clone(st.Sprites)
+			saved.Usage = slices.Clone(st.Usage)
+			saved.Tasks = slices.Clone(st.Tasks)
+			saved.Local = maps.Clone(st.Local)
+		})
+		return stateSavedMsg{err: err, saved: saved}
+	}
+}
+
+// signature identifies a Sprite list by names and statuses, to skip
+// writing state when nothing changed.
+func signature(list []sprites.Sprite) string {
+	var b strings.Builder
+	for _, s := range list {
+		b.WriteString(s.Name)
+		b.WriteByte(0)
+		b.WriteString(s.Status)
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// checkpointEvent records a checkpoint. user is empty for automatic ones.
+func checkpointEvent(name, comment, user string) state.Event {
+	return state.Event{At: time.Now(), Sprite: name, Kind: state.EventCheckpoint, Detail: comment, User: user}
+}
+
+// handleTransition reacts to a single detected status change.
+func (d *Dashboard) handleTransition(t poller.Transition) tea.Cmd {
+	if inBar(t.To) {
+		d.bar.Push(notify.FromTransition(t, d.results[t.Name]))
+		d.notice = ""
+	}
+	refetch := d.invalidateDetail(t.Name)
+	if t.From == sprites.StatusWorking && t.To == sprites.StatusFinished {
+		return tea.Batch(refetch, d.startAutoCheckpoint(t.Name))
+	}
+	return refetch
+}
+
 // View renders the dashboard.
 func (d Dashboard) View() string {
 	if d.width < minWidth || d.height < minHeight {
@@ -180,9 +1280,29 @@ func (d Dashboard) View() string {
 	b.WriteString(d.renderSeparator())
 	b.WriteString("\n")
 
-	// Sprite list
+	// Sprite list, or the modal dialog in its place
 	listHeight := d.height - headerLines - footerLines
-	b.WriteString(d.renderSpriteList(listHeight))
+	switch {
+	case d.confirm != nil:
+		b.WriteString(d.confirm.View(d.width, listHeight))
+		b.WriteString("\n")
+	case d.wizard != nil:
+		b.WriteString(d.wizard.View(d.width, listHeight))
+		b.WriteString("\n")
+	case d.task != nil:
+		b.WriteString(d.task.View(d.width, listHeight))
+		b.WriteString("\n")
+	case d.logs != nil:
+		b.WriteString(d.logs.View(d.width, listHeight))
+	case d.picker != nil:
+		b.WriteString(d.picker.View(d.width, listHeight))
+	case d.browser != nil:
+		b.WriteString(d.browser.View(d.width, listHeight))
+	case d.detail:
+		b.WriteString(d.renderListWithDetail(listHeight))
+	default:
+		b.WriteString(d.renderSpriteList(listHeight))
+	}
 
 	// Notification bar
 	b.WriteString(d.renderNotificationBar())
@@ -200,14 +1320,22 @@ func (d Dashboard) renderHeader() string {
 	// Count attention-needing sprites
 	attention := 0
 	for _, s := range d.sprites {
-		if s.Status == sprites.StatusWaiting || s.Status == sprites.StatusError {
+		st := d.displayStatus(s)
+		if st == sprites.StatusWaiting || st == sprites.StatusError {
 			attention++
 		}
 	}
 
 	right := ""
+	if d.showCost() {
+		_, today, month := d.costs(time.Now())
+		right = mutedStyle.Render(fmt.Sprintf("Today %s · Month %s", d.rates.Format(today), d.rates.Format(month)))
+	}
 	if attention > 0 {
-		right = badgeStyle.Render(fmt.Sprintf("[%d need attention]", attention))
+		if right != "" {
+			right += "  "
+		}
+		right += badgeStyle.Render(fmt.Sprintf("[%d need attention]", attention))
 	}
 
 	gap := d.width - lipgloss.Width(title) - lipgloss.Width(right)
@@ -226,16 +1354,34 @@ func (d Dashboard) renderSubheader() string {
 	if d.loading {
 		status = "Loading..."
 	}
-	return subheaderStyle.Render(status)
+	if !d.lastPoll.IsZero() {
+		status += " · Last poll: " + formatAgo(time.Since(d.lastPoll))
+	}
+	switch {
+	case d.search:
+		status += " · /" + d.filter + "▏"
+	case d.filter != "":
+		status += fmt.Sprintf(" · Filter: %s (%d of %d)", d.filter, len(d.visible()), len(d.sprites))
+	}
+	if len(d.marked) > 0 {
+		status += fmt.Sprintf(" · %d marked", len(d.marked))
+	}
+	if n := d.queuedTasks(); n > 0 {
+		status += fmt.Sprintf(" · %s queued", plural(n, "task"))
+	}
+	return subheaderStyle.Render(truncate(status, d.width))
 }
 
 func (d Dashboard) renderColumnHeaders() string {
-	showActivity := d.width >= 100
-	name := padRight("NAME", colName)
-	st := padRight("STATUS", colStatus)
-	up := padRight("UPTIME", colUptime)
+	showActivity := d.showActivity()
+	name := padRight("NAME", d.cols.Name)
+	st := padRight("STATUS", d.cols.Status)
+	up := padRight("UPTIME", d.cols.Uptime)
 
 	header := name + st + up
+	if d.showCostColumn() {
+		header += padRight("COST", colCost)
+	}
 	if showActivity {
 		header += "LAST ACTIVITY"
 	}
@@ -243,12 +1389,15 @@ func (d Dashboard) renderColumnHeaders() string {
 }
 
 func (d Dashboard) renderSeparator() string {
-	showActivity := d.width >= 100
-	name := padRight(strings.Repeat("─", colName-1), colName)
-	st := padRight(strings.Repeat("─", colStatus-1), colStatus)
-	up := padRight(strings.Repeat("─", colUptime-1), colUptime)
+	showActivity := d.showActivity()
+	name := padRight(strings.Repeat("─", d.cols.Name-1), d.cols.Name)
+	st := padRight(strings.Repeat("─", d.cols.Status-1), d.cols.Status)
+	up := padRight(strings.Repeat("─", d.cols.Uptime-1), d.cols.Uptime)
 
 	sep := name + st + up
+	if d.showCostColumn() {
+		sep += padRight(strings.Repeat("─", colCost-1), colCost)
+	}
 	if showActivity {
 		sep += strings.Repeat("─", 16)
 	}
@@ -261,47 +1410,27 @@ func (d Dashboard) renderSpriteList(height int) string {
 	}
 
 	if len(d.sprites) == 0 {
-		msg := "  No Sprites running.\n\n  Use 'sprite create <name>' to get started.\n"
+		msg := "  No Sprites running.\n\n  Press n or run 'slua new' to create one.\n"
 		return padLines(msg, height)
 	}
 
-	showActivity := d.width >= 100
+	list := d.visible()
+	if len(list) == 0 {
+		return padLines(fmt.Sprintf("  No Sprites match %q.\n\n  Press Esc to clear the filter.\n", d.filter), height)
+	}
+
+	rows, cursorRow := d.listRows(list)
 
 	// Calculate visible range (scroll if needed)
 	start := 0
-	if d.cursor >= height {
-		start = d.cursor - height + 1
-	}
-	end := start + height
-	if end > len(d.sprites) {
-		end = len(d.sprites)
+	if cursorRow >= height {
+		start = cursorRow - height + 1
 	}
+	end := min(start+height, len(rows))
 
 	var b strings.Builder
-	for i := start; i < end; i++ {
-		s := d.sprites[i]
-
-		// Cursor indicator
-		prefix := "  "
-		if i == d.cursor {
-			prefix = cursorStyle.Render("▸ ")
-		}
-
-		name := truncate(s.Name, colName-2)
-		name = padRight(name, colName-2) // -2 for prefix
-
-		label := statusLabel(s.Status)
-		styledStatus := statusStyle(s.Status).Render(padRight(label, colStatus))
-
-		uptime := padRight(s.FormatUptime(), colUptime)
-
-		line := prefix + name + styledStatus + uptime
-		if showActivity {
-			activity := activityText(s)
-			line += mutedStyle.Render(activity)
-		}
-
-		b.WriteString(line)
+	for _, row := range rows[start:end] {
+		b.WriteString(row)
 		b.WriteString("\n")
 	}
 
@@ -314,34 +1443,230 @@ func (d Dashboard) renderSpriteList(height int) string {
 	return b.String()
 }
 
+// listRows renders every row of list, with a header above each group when
+// the list is grouped, and returns the index of the cursor's row.
+// Organizations without Sprites still get a header, so one that cannot be
+// listed shows its error.
+func (d Dashboard) listRows(list []match) ([]string, int) {
+	showActivity := d.showActivity()
+	var costs map[string]float64
+	if d.showCostColumn() {
+		costs, _, _ = d.costs(time.Now())
+	}
+	if d.groupBy == groupNone {
+		rows := make([]string, len(list))
+		for i, m := range list {
+			rows[i] = d.renderSpriteRow(i, m, showActivity, costs)
+		}
+		return rows, d.cursor
+	}
+
+	var rows []string
+	cursorRow := 0
+	shown := make(map[string]bool)
+	// emptyOrgs adds the headers of organizations without rows listed
+	// before org, or all remaining ones when org is empty.
+	emptyOrgs := func(org string) {
+		if d.groupBy != groupOrg || d.filter != "" {
+			return
+		}
+		for _, o := range d.orgs {
+			if o == org {
+				return
+			}
+			if !shown[o] {
+				shown[o] = true
+				rows = append(rows, d.renderGroupHeader(o, 0, false, false))
+			}
+		}
+	}
+	for i, m := range list {
+		if i == 0 || list[i-1].group != m.group {
+			emptyOrgs(m.group)
+			shown[m.group] = true
+			if m.folded == 0 {
+				n := 1
+				for i+n < len(list) && list[i+n].group == m.group {
+					n++
+				}
+				rows = append(rows, d.renderGroupHeader(m.group, n, false, false))
+			}
+		}
+		if i == d.cursor {
+			cursorRow = len(rows)
+		}
+		if m.folded > 0 {
+			rows = append(rows, d.renderGroupHeader(m.group, m.folded, true, i == d.cursor))
+			continue
+		}
+		rows = append(rows, d.renderSpriteRow(i, m, showActivity, costs))
+	}
+	emptyOrgs("")
+	return rows, cursorRow
+}
+
+// renderSpriteRow renders the i-th row of the filtered list. costs holds
+// each Sprite's cost this month when the COST column is shown.
+func (d Dashboard) renderSpriteRow(i int, m match, showActivity bool, costs map[string]float64) string {
+	s := m.sprite
+
+	// Cursor and mark indicators
+	prefix := "  "
+	switch {
+	case i == d.cursor && d.marked[s.Name]:
+		prefix = cursorStyle.Render("▸●")
+	case i == d.cursor:
+		prefix = cursorStyle.Render("▸ ")
+	case d.marked[s.Name]:
+		prefix = cursorStyle.Render(" ●")
+	}
+
+	name := truncate(d.displayName(s), d.cols.Name-2)
+	name = padRight(name, d.cols.Name-2) // -2 for prefix
+	name = highlight(name, m.name, lipgloss.NewStyle())
+
+	status := d.displayStatus(s)
+	label := statusLabel(status)
+	hits := m.status
+	if isTransient(status) {
+		label = spinnerFrames[d.frame] + " " + label
+		hits = nil
+	}
+	styledStatus := highlight(padRight(label, d.cols.Status), hits, statusStyle(status))
+
+	uptime := padRight(s.FormatUptime(), d.cols.Uptime)
+
+	line := prefix + name + styledStatus + uptime
+	if costs != nil {
+		line += padRight(d.rates.Format(costs[s.Name]), colCost)
+	}
+	if showActivity {
+		activity := activityText(status, d.results[s.Name])
+		if t, ok := d.currentTask(s.Name); ok && status != sprites.StatusWaiting {
+			activity = fmt.Sprintf("task #%d: %s", t.ID, promptLine(t.Prompt))
+		}
+		if p, ok := d.creating[s.Name]; ok {
+			activity = p
+		}
+		line += mutedStyle.Render(activity)
+	}
+	return line
+}
+
 func (d Dashboard) renderNotificationBar() string {
+	if d.prompt != nil {
+		text := fmt.Sprintf("Run on %s: %s▏", plural(len(d.prompt.names), "Sprite"), d.prompt.input)
+		if d.prompt.reply {
+			text = fmt.Sprintf("Answer %s: %s▏", d.prompt.names[0], d.prompt.input)
+		}
+		return notificationBarStyle.Render("  " + truncate(text, d.width-4))
+	}
+	if d.tagger != nil {
+		text := fmt.Sprintf("Tags for %s: %s▏", d.tagger.sprite, d.tagger.input)
+		return notificationBarStyle.Render("  " + truncate(text, d.width-4))
+	}
 	if d.lastErr != "" {
 		return notificationBarStyle.Render("  " + truncate(d.lastErr, d.width-4))
 	}
-	return notificationBarStyle.Render("")
+	if d.notice != "" {
+		return notificationBarStyle.Render("  " + truncate(d.notice, d.width-4))
+	}
+
+	// Most recent transitions, newest first.
+	room := d.width - 4
+	var parts []string
+	for _, e := range d.bar.Recent(2) {
+		if room < 8 {
+			break
+		}
+		text := truncate(fmt.Sprintf("%s (%s)", e.Title(), formatAgo(time.Since(e.At))), room-2)
+		room -= lipgloss.Width(text) + 5 // "● " and " │ "
+		parts = append(parts, statusStyle(e.To).Render("●")+" "+notificationBarStyle.Render(text))
+	}
+	if len(parts) == 0 {
+		return notificationBarStyle.Render("")
+	}
+	return "  " + strings.Join(parts, mutedStyle.Render(" │ "))
 }
 
 func (d Dashboard) renderStatusBar() string {
-	return statusBarStyle.Render("  j/k:navigate  Enter:connect  r:refresh  q:quit")
+	hints := "j/k:navigate  Enter:connect  a:answer  p:prompt  space:mark  s:sessions  /:search  i:details  l:logs  t:tags  b:group  n:new  c:checkpoint  C:checkpoints  d:destroy  r:refresh  q:quit"
+	switch {
+	case d.search:
+		hints = "type to filter  ↑/↓:navigate  Enter:keep filter  Esc:clear"
+	case d.prompt != nil && d.prompt.reply:
+		hints = "type an answer  Enter:send  Esc:cancel"
+	case d.prompt != nil:
+		hints = "type a shell command  Enter:run  Esc:cancel"
+	case d.tagger != nil:
+		hints = "type tags separated by spaces  Enter:save  Esc:cancel"
+	case d.task != nil:
+		hints = "type a prompt  Enter:new line  Ctrl+S:send  Esc:cancel"
+	case d.confirm != nil:
+		keys := make([]string, len(d.confirm.options))
+		for i, o := range d.confirm.options {
+			keys[i] = o.key
+		}
+		hints = strings.Join(keys, "/") + ":choose  Esc:cancel"
+	case d.wizard != nil && d.wizard.stage == stageOrg:
+		hints = "j/k:choose org  Enter:next  Esc:cancel"
+	case d.wizard != nil && d.wizard.stage == stageTemplate:
+		hints = "j/k:choose template  Enter:create  Esc:cancel"
+	case d.wizard != nil:
+		hints = "type to edit  Enter:next  Esc:cancel"
+	case d.logs != nil && d.logs.search:
+		hints = "type to search  Enter:find  Esc:clear"
+	case d.logs != nil:
+		hints = "j/k:scroll  g/G:top/bottom  /:search  n/N:next/prev  space:pause  Tab:source  Esc:back"
+	case d.picker != nil:
+		hints = "j/k:navigate  Enter:attach  n:new console  x:kill  r:refresh  Esc:back"
+	case d.browser != nil:
+		hints = "j/k:navigate  Enter:restore  c:checkpoint  r:refresh  Esc:back"
+	case len(d.marked) > 0:
+		hints = "space:mark  V:range  *:all  c:checkpoint  d:destroy  R:restart Claude  e:exec  Esc:unmark"
+	}
+	return statusBarStyle.Render("  " + truncate(hints, d.width-2))
+}
+
+// showActivity reports whether the LAST ACTIVITY column fits. The detail
+// pane takes its place when open.
+func (d Dashboard) showActivity() bool {
+	return d.width >= 100 && !d.detail
 }
 
 // Helpers
 
+// padRight pads or cuts s to exactly width terminal cells.
 func padRight(s string, width int) string {
-	if len(s) >= width {
-		return s[:width]
+	w := lipgloss.Width(s)
+	if w >= width {
+		return cutWidth(s, width)
 	}
-	return s + strings.Repeat(" ", width-len(s))
+	return s + strings.Repeat(" ", width-w)
 }
 
+// truncate shortens s to at most maxLen cells, marking the cut with "…".
 func truncate(s string, maxLen int) string {
-	if len(s) <= maxLen {
+	if lipgloss.Width(s) <= maxLen {
 		return s
 	}
 	if maxLen <= 1 {
-		return s[:maxLen]
+		return cutWidth(s, maxLen)
 	}
-	return s[:maxLen-1] + "…"
+	return cutWidth(s, maxLen-1) + "…"
+}
+
+// cutWidth returns the longest prefix of s that fits in width cells.
+func cutWidth(s string, width int) string {
+	w := 0
+	for i, r := range s {
+		rw := lipgloss.Width(string(r))
+		if w+rw > width {
+			return s[:i]
+		}
+		w += rw
+	}
+	return s
 }
 
 func padLines(content string, height int) string {
@@ -353,15 +1678,58 @@ func padLines(content string, height int) string {
 	return content
 }
 
-func activityText(s sprites.Sprite) string {
-	switch s.Status {
+// formatAgo returns a short relative time like "3s ago" or "2m ago".
+func formatAgo(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds ago", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	}
+}
+
+// inBar reports whether a transition to status is shown in the
+// notification bar.
+func inBar(status string) bool {
+	switch status {
+	case sprites.StatusWaiting, sprites.StatusError, sprites.StatusFinished, sprites.StatusUnreachable:
+		return true
+	default:
+		return false
+	}
+}
+
+// isTransient reports whether status is a local in-flight operation that
+// is rendered with a spinner.
+func isTransient(status string) bool {
+	switch status {
+	case sprites.StatusDestroying, sprites.StatusCreating, sprites.StatusCheckpointing:
+		return true
+	default:
+		return false
+	}
+}
+
+func activityText(status string, r poller.Result) string {
+	if r.Stale() {
+		return "retrying"
+	}
+	switch status {
 	case sprites.StatusWorking:
 		return "active"
 	case sprites.StatusFinished:
 		return "completed"
 	case sprites.StatusWaiting:
+		if r.Detail != "" {
+			return "prompt: " + r.Detail
+		}
 		return "needs input"
 	case sprites.StatusError:
+		if r.Detail != "" {
+			return r.Detail
+		}
 		return "failed"
 	case sprites.StatusSleeping:
 		return "idle"
@@ -371,4 +1739,3 @@ func activityText(s sprites.Sprite) string {
 		return ""
 	}
 }
-
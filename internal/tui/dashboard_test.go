@@ -1,31 +1,111 @@
 package tui
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"os/exec"
+	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/JPM1118/slua/internal/cost"
+	"github.com/JPM1118/slua/internal/notify"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/queue"
 	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/templates"
 	tea "github.com/charmbracelet/bubbletea"
 )
 
 // mockSource implements sprites.SpriteSource for testing.
 type mockSource struct {
-	sprites []sprites.Sprite
-	err     error
+	sprites       []sprites.Sprite
+	err           error
+	checkpointErr error
+	destroyErr    error
+	createErr     error
+	checkpoints   []sprites.Checkpoint
+	sessions      []sprites.ExecSession
+	execStdout    string
+	execExit      map[string]int // exit code per Sprite, failing with "boom"
+	services      []sprites.Service
+	calls         []string // lifecycle calls like "checkpoint:name"
 }
 
 func (m *mockSource) List(_ context.Context) ([]sprites.Sprite, error) {
 	return m.sprites, m.err
 }
 
+func (m *mockSource) Create(_ context.Context, name, region string) error {
+	m.calls = append(m.calls, "create:"+name+":"+region)
+	if m.createErr != nil {
+		return m.createErr
+	}
+	m.sprites = append(m.sprites, sprites.Sprite{ID: "id-" + name, Name: name, Region: region, Status: sprites.StatusWorking})
+	return nil
+}
+
+func (m *mockSource) Checkpoint(_ context.Context, name, _ string) error {
+	m.calls = append(m.calls, "checkpoint:"+name)
+	return m.checkpointErr
+}
+
+func (m *mockSource) ListCheckpoints(_ context.Context, _ string) ([]sprites.Checkpoint, error) {
+	return m.checkpoints, nil
+}
+
+func (m *mockSource) RestoreCheckpoint(_ context.Context, name, id string) error {
+	m.calls = append(m.calls, "restore:"+name+":"+id)
+	return nil
+}
+
+func (m *mockSource) DeleteCheckpoint(_ context.Context, name, id string) error {
+	m.calls = append(m.calls, "delete:"+name+":"+id)
+	return nil
+}
+
+func (m *mockSource) Destroy(_ context.Context, name string) error {
+	m.calls = append(m.calls, "destroy:"+name)
+	return m.destroyErr
+}
+
+func (m *mockSource) Exec(_ context.Context, name string, command []string) (sprites.ExecResult, error) {
+	m.calls = append(m.calls, "exec:"+name+":"+command[len(command)-1])
+	if code := m.execExit[name]; code != 0 {
+		return sprites.ExecResult{Stderr: []byte("boom\n"), ExitCode: code}, nil
+	}
+	return sprites.ExecResult{Stdout: []byte(m.execStdout)}, nil
+}
+
+func (m *mockSource) ListSessions(_ context.Context, _ string) ([]sprites.ExecSession, error) {
+	return m.sessions, nil
+}
+
+func (m *mockSource) ListServices(_ context.Context, _ string) ([]sprites.Service, error) {
+	return m.services, nil
+}
+
+func (m *mockSource) ServiceLogs(_ context.Context, name, id string) ([]byte, error) {
+	return []byte("log of " + name + "/" + id + "\n"), nil
+}
+
 func (m *mockSource) ConsoleCmd(name string) *exec.Cmd {
 	return exec.Command("echo", name)
 }
 
+func (m *mockSource) AttachCmd(name, id string) *exec.Cmd {
+	return exec.Command("echo", name, id)
+}
+
+func (m *mockSource) KillSession(_ context.Context, name, id string) error {
+	m.calls = append(m.calls, "kill:"+name+":"+id)
+	return nil
+}
+
 // testDashboard creates a Dashboard with mock data already loaded.
 func testDashboard(src sprites.SpriteSource, width, height int) Dashboard {
 	d := NewDashboard(src)
@@ -49,6 +129,12 @@ func keyMsg(s string) tea.KeyMsg {
 	if s == "ctrl+c" {
 		return tea.KeyMsg{Type: tea.KeyCtrlC}
 	}
+	if s == "esc" {
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	}
+	if s == "backspace" {
+		return tea.KeyMsg{Type: tea.KeyBackspace}
+	}
 	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
 }
 
@@ -241,6 +327,17 @@ func TestView_ErrorInNotificationBar(t *testing.T) {
 	}
 }
 
+func TestView_StartupWarnings(t *testing.T) {
+	src := &mockSource{sprites: []sprites.Sprite{{Name: "api", Status: sprites.StatusWorking}}}
+	d := NewDashboard(src, WithWarnings("notifications.desktop: no desktop notifier found"))
+	d.width, d.height = 120, 30
+	d = runCmd(d, d.Init())
+
+	if !strings.Contains(d.View(), "Warning: notifications.desktop: no desktop notifier found") {
+		t.Errorf("View() should show the startup warning after the first list:\n%s", d.View())
+	}
+}
+
 func TestView_AttentionBadge(t *testing.T) {
 	src := &mockSource{
 		sprites: []sprites.Sprite{
@@ -332,3 +429,1399 @@ func TestUpdate_RefreshKey(t *testing.T) {
 		t.Errorf("after refresh completes: loading should be false")
 	}
 }
+
+func TestUpdate_PollCycleOverlaysStatus(t *testing.T) {
+	src := &mockSource{
+		sprites: []sprites.Sprite{
+			{Name: "busy", Status: sprites.StatusWorking},
+			{Name: "asking", Status: sprites.StatusWorking},
+			{Name: "napping", Status: sprites.StatusSleeping},
+		},
+	}
+	d := testDashboard(src, 110, 30)
+
+	cycle := poller.Cycle{
+		Sprites: src.sprites,
+		Results: map[string]poller.Result{
+			"busy":   {Name: "busy", Status: sprites.StatusWorking},
+			"asking": {Name: "asking", Status: sprites.StatusWaiting, Detail: "Allow? (y/n)"},
+		},
+		At: time.Now(),
+	}
+	updated, _ := d.Update(pollCycleMsg{cycle: cycle})
+	d = updated.(Dashboard)
+
+	if got := d.sprites[1].Status; got != sprites.StatusWaiting {
+		t.Errorf("asking status = %q, want %q", got, sprites.StatusWaiting)
+	}
+	if got := d.sprites[2].Status; got != sprites.StatusSleeping {
+		t.Errorf("napping status = %q, want %q", got, sprites.StatusSleeping)
+	}
+
+	view := d.View()
+	if !strings.Contains(view, "1 need attention") {
+		t.Errorf("View() should count the WAITING sprite in the attention badge")
+	}
+	if !strings.Contains(view, "prompt: Allow?") {
+		t.Errorf("View() should show the detected prompt as activity")
+	}
+	if !strings.Contains(view, "Last poll") {
+		t.Errorf("View() should show the last poll time")
+	}
+}
+
+func TestUpdate_ListKeepsDetectedStatus(t *testing.T) {
+	src := &mockSource{
+		sprites: []sprites.Sprite{{Name: "asking", Status: sprites.StatusWorking}},
+	}
+	d := testDashboard(src, 100, 30)
+
+	updated, _ := d.Update(pollCycleMsg{cycle: poller.Cycle{
+		Sprites: []sprites.Sprite{{Name: "asking", Status: sprites.StatusWorking}},
+		Results: map[string]poller.Result{"asking": {Name: "asking", Status: sprites.StatusWaiting}},
+	}})
+	d = updated.(Dashboard)
+
+	// A plain List refresh reports the coarse API status again.
+	updated, _ = d.Update(spritesLoadedMsg{sprites: []sprites.Sprite{{Name: "asking", Status: sprites.StatusWorking}}})
+	d = updated.(Dashboard)
+
+	if got := d.sprites[0].Status; got != sprites.StatusWaiting {
+		t.Errorf("status after list refresh = %q, want %q", got, sprites.StatusWaiting)
+	}
+}
+
+func TestUpdate_PollCycleErrorKeepsData(t *testing.T) {
+	src := &mockSource{sprites: []sprites.Sprite{{Name: "test", Status: sprites.StatusWorking}}}
+	d := testDashboard(src, 100, 30)
+
+	updated, _ := d.Update(pollCycleMsg{cycle: poller.Cycle{Err: fmt.Errorf("network down")}})
+	d = updated.(Dashboard)
+
+	if len(d.sprites) != 1 {
+		t.Fatalf("expected stale sprites to be kept, got %d", len(d.sprites))
+	}
+	if !strings.Contains(d.View(), "network down") {
+		t.Errorf("View() should show poll error in notification bar")
+	}
+}
+
+// runCmd executes cmd and feeds every resulting message back into d,
+// expanding batches. Spinner ticks are dropped so the loop terminates.
+func runCmd(d Dashboard, cmd tea.Cmd) Dashboard {
+	if cmd == nil {
+		return d
+	}
+	msg := cmd()
+	switch msg := msg.(type) {
+	case tea.BatchMsg:
+		for _, c := range msg {
+			d = runCmd(d, c)
+		}
+		return d
+	case spinnerTickMsg, logTickMsg, nil:
+		return d
+	}
+	updated, next := d.Update(msg)
+	return runCmd(updated.(Dashboard), next)
+}
+
+func TestUpdate_DestroyOpensDialogAndBlocksInput(t *testing.T) {
+	src := &mockSource{sprites: []sprites.Sprite{{Name: "first"}, {Name: "second"}}}
+	d := testDashboard(src, 100, 30)
+
+	updated, _ := d.Update(keyMsg("d"))
+	d = updated.(Dashboard)
+	if d.confirm == nil {
+		t.Fatal("d should open the confirmation dialog")
+	}
+	if view := d.View(); !strings.Contains(view, "Destroy first?") || !strings.Contains(view, "Checkpoint then destroy") {
+		t.Errorf("View() should render the destroy dialog, got:\n%s", view)
+	}
+
+	updated, _ = d.Update(keyMsg("j"))
+	d = updated.(Dashboard)
+	if d.cursor != 0 {
+		t.Errorf("navigation should be blocked while dialog is open")
+	}
+
+	updated, _ = d.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	d = updated.(Dashboard)
+	if d.confirm != nil {
+		t.Errorf("Esc should close the dialog")
+	}
+	if len(src.calls) != 0 {
+		t.Errorf("cancel should not call the source, got %v", src.calls)
+	}
+}
+
+func TestUpdate_DestroyWithoutCheckpoint(t *testing.T) {
+	src := &mockSource{sprites: []sprites.Sprite{{Name: "doomed", Status: sprites.StatusWorking}}}
+	d := testDashboard(src, 100, 30)
+
+	updated, _ := d.Update(keyMsg("d"))
+	d = updated.(Dashboard)
+	updated, cmd := d.Update(keyMsg("2"))
+	d = updated.(Dashboard)
+
+	if d.pending["doomed"] != sprites.StatusDestroying {
+		t.Fatalf("pending = %q, want %q", d.pending["doomed"], sprites.StatusDestroying)
+	}
+	if !strings.Contains(d.View(), "DESTROYING") {
+		t.Errorf("View() should show DESTROYING while in flight")
+	}
+
+	// The list still reports the Sprite, so it stays DESTROYING.
+	d = runCmd(d, cmd)
+	if len(src.calls) != 1 || src.calls[0] != "destroy:doomed" {
+		t.Errorf("calls = %v, want [destroy:doomed]", src.calls)
+	}
+	if d.pending["doomed"] != sprites.StatusDestroying {
+		t.Errorf("Sprite should stay DESTROYING until the list drops it")
+	}
+
+	src.sprites = nil
+	d = runCmd(d, d.loadSprites())
+	if _, ok := d.pending["doomed"]; ok {
+		t.Errorf("pending destroy should clear once the Sprite is gone")
+	}
+	if !strings.Contains(d.View(), "Destroyed doomed") {
+		t.Errorf("View() should confirm the destroy")
+	}
+}
+
+func TestUpdate_CheckpointThenDestroy(t *testing.T) {
+	src := &mockSource{sprites: []sprites.Sprite{{Name: "keep-me"}}}
+	d := testDashboard(src, 100, 30)
+
+	updated, _ := d.Update(keyMsg("d"))
+	d = updated.(Dashboard)
+	updated, cmd := d.Update(keyMsg("1"))
+	d = runCmd(updated.(Dashboard), cmd)
+
+	want := []string{"checkpoint:keep-me", "destroy:keep-me"}
+	if fmt.Sprint(src.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", src.calls, want)
+	}
+}
+
+func TestUpdate_CheckpointFailureBlocksDestroy(t *testing.T) {
+	src := &mockSource{
+		sprites:       []sprites.Sprite{{Name: "keep-me"}},
+		checkpointErr: fmt.Errorf("quota exceeded"),
+	}
+	d := testDashboard(src, 100, 30)
+
+	updated, _ := d.Update(keyMsg("d"))
+	d = updated.(Dashboard)
+	updated, cmd := d.Update(keyMsg("1"))
+	d = runCmd(updated.(Dashboard), cmd)
+
+	if len(src.calls) != 1 || src.calls[0] != "checkpoint:keep-me" {
+		t.Errorf("destroy must not run after a failed checkpoint, calls = %v", src.calls)
+	}
+	if _, ok := d.pending["keep-me"]; ok {
+		t.Errorf("pending state should clear after failure")
+	}
+	if !strings.Contains(d.View(), "quota exceeded") {
+		t.Errorf("View() should show the checkpoint failure")
+	}
+}
+
+func TestUpdate_CheckpointKey(t *testing.T) {
+	src := &mockSource{sprites: []sprites.Sprite{{Name: "snap", Status: sprites.StatusWorking}}}
+	d := testDashboard(src, 100, 30)
+
+	updated, cmd := d.Update(keyMsg("c"))
+	d = updated.(Dashboard)
+	if d.pending["snap"] != sprites.StatusCheckpointing {
+		t.Fatalf("pending = %q, want %q", d.pending["snap"], sprites.StatusCheckpointing)
+	}
+	if !strings.Contains(d.View(), "CHECKPOINTING") {
+		t.Errorf("View() should show CHECKPOINTING while in flight")
+	}
+
+	// A second request while in flight is ignored.
+	updated, _ = d.Update(keyMsg("c"))
+	d = updated.(Dashboard)
+	if !strings.Contains(d.notice, "already checkpointing") {
+		t.Errorf("notice = %q, want already checkpointing", d.notice)
+	}
+
+	d = runCmd(d, cmd)
+	if _, ok := d.pending["snap"]; ok {
+		t.Errorf("pending state should clear after checkpoint")
+	}
+	if len(src.calls) != 1 || src.calls[0] != "checkpoint:snap" {
+		t.Errorf("calls = %v, want one checkpoint", src.calls)
+	}
+	if !strings.Contains(d.View(), "Checkpointed snap as manual-") {
+		t.Errorf("View() should confirm the manual checkpoint name")
+	}
+}
+
+func TestUpdate_CheckpointBrowserRestore(t *testing.T) {
+	src := &mockSource{
+		sprites: []sprites.Sprite{{Name: "snap"}},
+		checkpoints: []sprites.Checkpoint{
+			{ID: "v2", Comment: "manual-20260205-120000", CreatedAt: time.Now().Add(-time.Hour)},
+			{ID: "v1", Comment: "manual-20260205-100000", CreatedAt: time.Now().Add(-3 * time.Hour)},
+		},
+	}
+	d := testDashboard(src, 100, 30)
+
+	updated, cmd := d.Update(keyMsg("C"))
+	d = runCmd(updated.(Dashboard), cmd)
+	if d.browser == nil {
+		t.Fatal("C should open the checkpoint browser")
+	}
+	view := d.View()
+	for _, want := range []string{"Checkpoints · snap", "manual-20260205-120000", "1h ago"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("browser view missing %q", want)
+		}
+	}
+
+	updated, _ = d.Update(keyMsg("j"))
+	d = updated.(Dashboard)
+	updated, _ = d.Update(keyMsg("enter"))
+	d = updated.(Dashboard)
+	if d.confirm == nil || d.confirm.ref != "v1" {
+		t.Fatalf("Enter should open a restore dialog for v1, got %+v", d.confirm)
+	}
+
+	updated, cmd = d.Update(keyMsg("y"))
+	d = runCmd(updated.(Dashboard), cmd)
+	if len(src.calls) != 1 || src.calls[0] != "restore:snap:v1" {
+		t.Errorf("calls = %v, want [restore:snap:v1]", src.calls)
+	}
+
+	updated, _ = d.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	d = updated.(Dashboard)
+	if d.browser != nil {
+		t.Errorf("Esc should close the browser")
+	}
+}
+
+func TestUpdate_AutoCheckpointOnFinish(t *testing.T) {
+	src := &mockSource{
+		sprites: []sprites.Sprite{{Name: "done", Status: sprites.StatusWorking}},
+		checkpoints: []sprites.Checkpoint{
+			{ID: "new", Comment: "auto-20260205-120000", CreatedAt: time.Now()},
+			{ID: "old", Comment: "auto-20260205-100000", CreatedAt: time.Now().Add(-time.Hour)},
+			{ID: "mine", Comment: "manual-20260205-090000", CreatedAt: time.Now().Add(-2 * time.Hour)},
+		},
+	}
+	policies := sprites.AutoCheckpointPolicies{
+		Default: sprites.AutoCheckpointPolicy{Enabled: true, Keep: 1, DedupWindow: time.Minute},
+	}
+	d := NewDashboard(src, WithAutoCheckpoint(policies))
+	d.width, d.height = 100, 30
+	d = runCmd(d, d.Init())
+
+	finished := poller.Cycle{
+		Sprites: src.sprites,
+		Results: map[string]poller.Result{
+			"done": {Name: "done", Status: sprites.StatusFinished, Previous: sprites.StatusWorking},
+		},
+	}
+	updated, cmd := d.Update(pollCycleMsg{cycle: finished})
+	d = updated.(Dashboard)
+	if d.pending["done"] != sprites.StatusCheckpointing {
+		t.Fatalf("WORKING→FINISHED should start an auto checkpoint")
+	}
+
+	d = runCmd(d, cmd)
+	want := []string{"checkpoint:done", "delete:done:old"}
+	if fmt.Sprint(src.calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", src.calls, want)
+	}
+	if !strings.Contains(d.notice, "Auto-checkpointed done as auto-") || !strings.Contains(d.notice, "pruned 1") {
+		t.Errorf("notice = %q", d.notice)
+	}
+
+	// A repeated transition inside the dedup window is skipped.
+	updated, _ = d.Update(pollCycleMsg{cycle: finished})
+	d = updated.(Dashboard)
+	if _, ok := d.pending["done"]; ok {
+		t.Errorf("auto checkpoint should be deduplicated")
+	}
+}
+
+func TestUpdate_AutoCheckpointDisabledPerSprite(t *testing.T) {
+	src := &mockSource{sprites: []sprites.Sprite{{Name: "scratch", Status: sprites.StatusWorking}}}
+	policies := sprites.DefaultAutoCheckpointPolicies()
+	policies.PerSprite = map[string]sprites.AutoCheckpointPolicy{"scratch": {Enabled: false}}
+	d := NewDashboard(src, WithAutoCheckpoint(policies))
+
+	updated, _ := d.Update(pollCycleMsg{cycle: poller.Cycle{
+		Sprites: src.sprites,
+		Results: map[string]poller.Result{
+			"scratch": {Name: "scratch", Status: sprites.StatusFinished, Previous: sprites.StatusWorking},
+		},
+	}})
+	d = updated.(Dashboard)
+	if _, ok := d.pending["scratch"]; ok {
+		t.Errorf("auto checkpoint should be disabled for scratch")
+	}
+}
+
+func TestFuzzyMatch(t *testing.T) {
+	tests := []struct {
+		query, text string
+		want        []int
+		ok          bool
+	}{
+		{"", "web-app", nil, true},
+		{"wa", "web-app", []int{0, 4}, true},
+		{"WEB", "web-app", []int{0, 1, 2}, true},
+		{"pw", "web-app", nil, false},
+		{"lhr", "LHR", []int{0, 1, 2}, true},
+	}
+	for _, tt := range tests {
+		got, ok := fuzzyMatch(tt.query, tt.text)
+		if ok != tt.ok || fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("fuzzyMatch(%q, %q) = %v, %v; want %v, %v", tt.query, tt.text, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func typeKeys(d Dashboard, keys ...string) Dashboard {
+	for _, k := range keys {
+		updated, _ := d.Update(keyMsg(k))
+		d = updated.(Dashboard)
+	}
+	return d
+}
+
+func TestUpdate_SearchFiltersList(t *testing.T) {
+	src := &mockSource{
+		sprites: []sprites.Sprite{
+			{Name: "web-app", Status: sprites.StatusWorking, Region: "ord"},
+			{Name: "api-dev", Status: sprites.StatusSleeping, Region: "lhr"},
+			{Name: "docs", Status: sprites.StatusWaiting, Region: "ord"},
+		},
+	}
+	d := testDashboard(src, 100, 30)
+
+	d = typeKeys(d, "/", "l", "h", "r")
+	if !d.search || d.filter != "lhr" {
+		t.Fatalf("search=%v filter=%q", d.search, d.filter)
+	}
+	view := d.View()
+	if !strings.Contains(view, "api-dev") || strings.Contains(view, "web-app") {
+		t.Errorf("region filter not applied:\n%s", view)
+	}
+	if !strings.Contains(view, "/lhr") {
+		t.Errorf("subheader should show the query while typing")
+	}
+
+	// Typed letters go to the filter, not to key bindings.
+	d = typeKeys(d, "backspace", "backspace", "backspace", "w", "a", "i", "t")
+	if s, _ := d.selected(); s.Name != "docs" {
+		t.Errorf("status filter selected %q, want docs", s.Name)
+	}
+
+	d = typeKeys(d, "enter")
+	if d.search || d.filter != "wait" {
+		t.Fatalf("Enter should keep filter: search=%v filter=%q", d.search, d.filter)
+	}
+	if view := d.View(); !strings.Contains(view, "Filter: wait (1 of 3)") {
+		t.Errorf("subheader missing kept filter:\n%s", view)
+	}
+
+	// The cursor acts on the filtered list.
+	updated, _ := d.Update(keyMsg("c"))
+	d = updated.(Dashboard)
+	if d.pending["docs"] != sprites.StatusCheckpointing {
+		t.Errorf("checkpoint should target the filtered selection, pending=%v", d.pending)
+	}
+
+	d = typeKeys(d, "/", "esc")
+	if d.search || d.filter != "" || len(d.visible()) != 3 {
+		t.Errorf("Esc should clear the filter: search=%v filter=%q", d.search, d.filter)
+	}
+	if s, _ := d.selected(); s.Name != "docs" {
+		t.Errorf("cursor should stay on docs after clearing, got %q", s.Name)
+	}
+}
+
+func TestUpdate_SearchClampsCursor(t *testing.T) {
+	src := &mockSource{
+		sprites: []sprites.Sprite{{Name: "alpha"}, {Name: "beta"}, {Name: "gamma"}},
+	}
+	d := testDashboard(src, 100, 30)
+	d = typeKeys(d, "G", "/", "a", "l")
+	if d.cursor != 0 {
+		t.Errorf("cursor = %d, want 0 after filtering to one Sprite", d.cursor)
+	}
+
+	d = typeKeys(d, "x")
+	if !strings.Contains(d.View(), `No Sprites match "alx"`) {
+		t.Errorf("expected no-match message")
+	}
+	if _, ok := d.selected(); ok {
+		t.Errorf("nothing should be selected when no Sprite matches")
+	}
+}
+
+func TestUpdate_TransitionsFillNotificationBar(t *testing.T) {
+	src := &mockSource{
+		sprites: []sprites.Sprite{
+			{Name: "asking", Status: sprites.StatusWorking},
+			{Name: "broken", Status: sprites.StatusWorking},
+		},
+	}
+	d := testDashboard(src, 120, 30)
+	at := time.Now()
+	d.results = map[string]poller.Result{
+		"asking": {Name: "asking", Status: sprites.StatusWorking},
+		"broken": {Name: "broken", Status: sprites.StatusWorking},
+	}
+
+	cycle := poller.Cycle{
+		Sprites: src.sprites,
+		Results: map[string]poller.Result{
+			"asking": {Name: "asking", Status: sprites.StatusWaiting, Previous: sprites.StatusWorking, CheckedAt: at},
+			"broken": {Name: "broken", Status: sprites.StatusError, Previous: sprites.StatusWorking, CheckedAt: at},
+		},
+		At: at,
+	}
+	updated, _ := d.Update(pollCycleMsg{cycle: cycle})
+	d = updated.(Dashboard)
+
+	view := d.View()
+	if !strings.Contains(view, "asking needs attention") || !strings.Contains(view, "broken errored") {
+		t.Errorf("notification bar missing transitions:\n%s", view)
+	}
+
+	// Connecting to a Sprite clears its notifications.
+	updated, _ = d.Update(keyMsg("enter"))
+	d = updated.(Dashboard)
+	if strings.Contains(d.View(), "asking needs attention") {
+		t.Errorf("connecting to asking should dismiss its notification")
+	}
+}
+
+// pushSink stands in for ntfy or a webhook, recording what it is sent.
+type pushSink struct{ sent []notify.Event }
+
+func (p *pushSink) Notify(_ context.Context, e notify.Event) error {
+	p.sent = append(p.sent, e)
+	return nil
+}
+
+func TestUpdate_ConsoleSuspendsNotifier(t *testing.T) {
+	src := &mockSource{sprites: []sprites.Sprite{{Name: "first"}, {Name: "other"}}}
+	var bell bytes.Buffer
+	push := &pushSink{}
+	n := notify.New(notify.Bell{W: &bell}, notify.NewOutbox(t.TempDir(), push))
+	d := NewDashboard(src, WithNotifier(n))
+	d.width, d.height = 120, 30
+	d = runCmd(d, d.loadSprites())
+
+	updated, _ := d.Update(keyMsg("enter"))
+	d = updated.(Dashboard)
+
+	// A transition observed while the console is open is held back from
+	// the terminal but still pushed.
+	n.Notify(context.Background(), notify.Event{Sprite: "other", To: sprites.StatusWaiting, At: time.Now()})
+	if bell.Len() != 0 || len(push.sent) != 1 {
+		t.Errorf("while suspended: %d bells, %d pushes; want 0 and 1", bell.Len(), len(push.sent))
+	}
+
+	updated, _ = d.Update(consoleFinishedMsg{})
+	d = updated.(Dashboard)
+	if !strings.Contains(d.View(), "other needs attention") {
+		t.Errorf("queued notification should appear in the bar on resume:\n%s", d.View())
+	}
+	if len(n.Resume()) != 0 {
+		t.Errorf("notifier queue should be drained")
+	}
+}
+
+func TestWithState_StartsFromSavedSprites(t *testing.T) {
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	err := store.Update(func(st *state.State) {
+		st.Reconcile([]sprites.Sprite{
+			{Name: "remembered", Status: sprites.StatusWaiting},
+			{Name: "destroyed", Status: sprites.StatusSleeping},
+		}, time.Now())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := &mockSource{sprites: []sprites.Sprite{
+		{Name: "remembered", Status: sprites.StatusWorking},
+		{Name: "new", Status: sprites.StatusWorking},
+	}}
+	d := NewDashboard(src, WithState(store))
+	d.width, d.height = 120, 30
+
+	view := d.View()
+	if strings.Contains(view, "Loading sprites") || !strings.Contains(view, "remembered") {
+		t.Errorf("saved Sprites should show before the first list:\n%s", view)
+	}
+
+	d = runCmd(d, d.loadSprites())
+	if len(d.sprites) != 2 || d.sprites[1].Name != "new" {
+		t.Errorf("sprites = %+v, want the listed Sprites", d.sprites)
+	}
+
+	// Connecting and transitions are recorded.
+	updated, cmd := d.Update(keyMsg("enter"))
+	d = updated.(Dashboard)
+	d = runCmd(d, cmd)
+	at := time.Now()
+	updated, cmd = d.Update(pollCycleMsg{cycle: poller.Cycle{
+		Sprites: src.sprites,
+		Results: map[string]poller.Result{
+			"new": {Name: "new", Status: sprites.StatusError, Previous: sprites.StatusWorking, CheckedAt: at},
+		},
+		At: at,
+	}})
+	d = runCmd(updated.(Dashboard), cmd)
+
+	st, err := store.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := st.Find("destroyed"); ok {
+		t.Errorf("Sprites gone from the list should be forgotten")
+	}
+	rem, _ := st.Find("remembered")
+	if rem.LastConnected.IsZero() {
+		t.Errorf("connecting to remembered should be recorded")
+	}
+	n, _ := st.Find("new")
+	if n.Status != sprites.StatusError || n.LastAttention.IsZero() {
+		t.Errorf("new = %+v, want ERROR with attention time", n)
+	}
+	if len(st.Events) != 2 || st.Events[1].Kind != state.EventTransition {
+		t.Errorf("events = %+v, want connect then transition", st.Events)
+	}
+}
+
+func TestUpdate_NewSpriteWizard(t *testing.T) {
+	src := &mockSource{sprites: []sprites.Sprite{{ID: "1", Name: "taken", Status: sprites.StatusWorking}}}
+	tmpls := templates.Sorted(map[string]templates.Template{
+		"node": {Description: "Node app", Steps: []templates.Step{{Name: "Install", Run: "npm ci"}}},
+	})
+	d := NewDashboard(src, WithTemplates(tmpls))
+	d.width, d.height = 120, 30
+	d = runCmd(d, d.loadSprites())
+
+	d = typeKeys(d, "n", "t", "a", "k", "e", "n", "enter")
+	if !strings.Contains(d.View(), "taken already exists") {
+		t.Fatalf("existing names should be rejected:\n%s", d.View())
+	}
+	d = typeKeys(d, "backspace", "backspace", "backspace", "backspace", "backspace", "backspace")
+	d = typeKeys(d, "w", "e", "b", "enter", "o", "r", "d", "enter")
+	if !strings.Contains(d.View(), "Node app") {
+		t.Fatalf("template stage should list templates:\n%s", d.View())
+	}
+
+	updated, cmd := d.Update(keyMsg("j"))
+	d = updated.(Dashboard)
+	updated, cmd = d.Update(keyMsg("enter"))
+	d = updated.(Dashboard)
+	if d.wizard != nil || d.displayStatus(d.sprites[1]) != sprites.StatusCreating {
+		t.Fatalf("web should show as CREATING right away, sprites = %+v", d.sprites)
+	}
+
+	d = runCmd(d, cmd)
+	want := []string{"create:web:ord", "exec:web:npm ci"}
+	if strings.Join(src.calls, ",") != strings.Join(want, ",") {
+		t.Errorf("calls = %v, want %v", src.calls, want)
+	}
+	if len(d.sprites) != 2 || d.sprites[1].ID == "" || d.pending["web"] != "" {
+		t.Errorf("sprites = %+v, want the listed web without a placeholder", d.sprites)
+	}
+	if !strings.Contains(d.View(), "Created web from node") {
+		t.Errorf("missing completion notice:\n%s", d.View())
+	}
+}
+
+func TestUpdate_NewSpriteCreateFails(t *testing.T) {
+	src := &mockSource{createErr: fmt.Errorf("quota exceeded")}
+	d := testDashboard(src, 120, 30)
+
+	// With only the blank template, Enter on the region creates.
+	d = typeKeys(d, "n", "x", "enter")
+	updated, cmd := d.Update(keyMsg("enter"))
+	d = runCmd(updated.(Dashboard), cmd)
+
+	if len(d.sprites) != 0 {
+		t.Errorf("placeholder should be removed, sprites = %+v", d.sprites)
+	}
+	if !strings.Contains(d.View(), "Create x failed: quota exceeded") {
+		t.Errorf("missing error:\n%s", d.View())
+	}
+}
+
+func TestUpdate_DetailPane(t *testing.T) {
+	defer func(delay time.Duration) { detailDelay = delay }(detailDelay)
+	detailDelay = 0
+
+	now := time.Now()
+	src := &mockSource{
+		sprites: []sprites.Sprite{
+			{ID: "spr-123", Name: "web", Status: sprites.StatusWorking, Region: "ord", CreatedAt: now.Add(-time.Hour)},
+			{ID: "spr-456", Name: "api", Status: sprites.StatusSleeping},
+		},
+		sessions:    []sprites.ExecSession{{ID: "7", Command: "claude --resume", CreatedAt: now}},
+		checkpoints: []sprites.Checkpoint{{ID: "v1", Comment: "manual-20260205-100000", CreatedAt: now}},
+		execStdout:  "● Running tests\n> \n",
+	}
+	d := testDashboard(src, 140, 30)
+
+	updated, cmd := d.Update(keyMsg("i"))
+	d = runCmd(updated.(Dashboard), cmd)
+	view := d.View()
+	for _, want := range []string{"spr-123", "ord", "● Running tests", "claude --resume", "manual-20260205-100000"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("detail pane missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "LAST ACTIVITY") {
+		t.Error("the pane should replace the activity column")
+	}
+
+	updated, cmd = d.Update(keyMsg("j"))
+	d = runCmd(updated.(Dashboard), cmd)
+	if view := d.View(); !strings.Contains(view, "Asleep") {
+		t.Errorf("sleeping Sprite should not be read:\n%s", view)
+	}
+
+	// Back on web the cached details are shown without fetching again.
+	updated, cmd = d.Update(keyMsg("k"))
+	d = runCmd(updated.(Dashboard), cmd)
+	var execs []string
+	for _, c := range src.calls {
+		if strings.HasPrefix(c, "exec:") {
+			execs = append(execs, c)
+		}
+	}
+	if len(execs) != 1 || !strings.HasPrefix(execs[0], "exec:web:") {
+		t.Errorf("exec calls = %q, want one for web", execs)
+	}
+
+	// A due fetch for a Sprite the cursor already left is dropped.
+	updated, cmd = d.Update(detailDueMsg{name: "api"})
+	if cmd != nil {
+		t.Error("stale detailDueMsg should not fetch")
+	}
+	d = updated.(Dashboard)
+
+	updated, _ = d.Update(keyMsg("i"))
+	if view := updated.(Dashboard).View(); !strings.Contains(view, "LAST ACTIVITY") {
+		t.Error("closing the pane should bring back the activity column")
+	}
+}
+
+func TestUpdate_LogViewer(t *testing.T) {
+	src := &mockSource{
+		sprites:    []sprites.Sprite{{ID: "spr-123", Name: "web", Status: sprites.StatusWorking}},
+		services:   []sprites.Service{{ID: "svc-1", Name: "dev-server"}},
+		execStdout: "\x1b[32m● Running tests\x1b[0m\nPASS ok\nFAIL flaky\n\n",
+	}
+	d := testDashboard(src, 120, 30)
+
+	updated, cmd := d.Update(keyMsg("l"))
+	d = runCmd(updated.(Dashboard), cmd)
+	view := d.View()
+	for _, want := range []string{"Logs · web · claude (1/2)", "\x1b[32m● Running tests", "FAIL flaky", "following"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("log viewer missing %q:\n%s", want, view)
+		}
+	}
+
+	for _, k := range []string{"/", "f", "a", "i", "l"} {
+		updated, _ = d.Update(keyMsg(k))
+		d = updated.(Dashboard)
+	}
+	updated, _ = d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	d = updated.(Dashboard)
+	if v := d.logs; v.search || len(v.matches) != 1 || v.matches[0] != 2 {
+		t.Errorf("search = %v, matches %v; want line 2", v.search, v.matches)
+	}
+	if view := d.View(); !strings.Contains(view, "/fail: 1 of 1") {
+		t.Errorf("footer should count matches:\n%s", view)
+	}
+
+	// While paused, reads are stopped and the content is kept.
+	updated, _ = d.Update(keyMsg(" "))
+	d = updated.(Dashboard)
+	if _, cmd := d.Update(logTickMsg{sprite: "web", gen: d.logs.gen}); cmd != nil {
+		t.Error("tick while paused should not read")
+	}
+	updated, _ = d.Update(logsLoadedMsg{sprite: "web", gen: d.logs.gen, lines: []string{"new"}})
+	d = updated.(Dashboard)
+	if !strings.Contains(d.View(), "PAUSED") || len(d.logs.lines) != 3 {
+		t.Errorf("paused viewer changed: %q", d.logs.lines)
+	}
+	updated, cmd = d.Update(keyMsg(" "))
+	d = runCmd(updated.(Dashboard), cmd)
+
+	// Tab moves to the service; a late read of the pane is dropped.
+	gen := d.logs.gen
+	updated, cmd = d.Update(tea.KeyMsg{Type: tea.KeyTab})
+	d = runCmd(updated.(Dashboard), cmd)
+	updated, _ = d.Update(logsLoadedMsg{sprite: "web", gen: gen, lines: []string{"stale"}})
+	d = updated.(Dashboard)
+	view = d.View()
+	if !strings.Contains(view, "dev-server (2/2)") || !strings.Contains(view, "log of web/svc-1") || strings.Contains(view, "stale") {
+		t.Errorf("service log not shown:\n%s", view)
+	}
+
+	updated, _ = d.Update(keyMsg("q"))
+	if updated.(Dashboard).logs != nil {
+		t.Error("q should close the log viewer")
+	}
+}
+
+func TestUpdate_LogViewerLeavesSleepingSpritesAlone(t *testing.T) {
+	src := &mockSource{sprites: []sprites.Sprite{{ID: "spr-123", Name: "web", Status: sprites.StatusSleeping}}}
+	d := testDashboard(src, 120, 30)
+
+	updated, cmd := d.Update(keyMsg("l"))
+	if cmd != nil {
+		t.Error("opening the logs of a sleeping Sprite should not read them")
+	}
+	d = updated.(Dashboard)
+	if view := d.View(); !strings.Contains(view, "Asleep") {
+		t.Errorf("log viewer should say the Sprite is asleep:\n%s", view)
+	}
+	// Neither does pausing and resuming.
+	for range 2 {
+		updated, cmd = d.Update(keyMsg(" "))
+		d = updated.(Dashboard)
+		if cmd != nil {
+			t.Error("resuming should not read a sleeping Sprite")
+		}
+	}
+	if len(src.calls) != 0 {
+		t.Errorf("calls = %q, want none", src.calls)
+	}
+}
+
+func TestUpdate_Sessions(t *testing.T) {
+	src := &mockSource{
+		sprites: []sprites.Sprite{{ID: "spr-123", Name: "web", Status: sprites.StatusWaiting}},
+		sessions: []sprites.ExecSession{
+			{ID: "3", Command: "bash"},
+			{ID: "7", Command: "claude --resume", TTY: true},
+		},
+	}
+	d := testDashboard(src, 120, 30)
+
+	// Without a preferred session Enter opens a new console right away.
+	updated, cmd := d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	d = updated.(Dashboard)
+	if d.checking != "" || cmd == nil {
+		t.Fatal("Enter without a preferred session should connect directly")
+	}
+
+	updated, cmd = d.Update(keyMsg("s"))
+	d = runCmd(updated.(Dashboard), cmd)
+	if view := d.View(); !strings.Contains(view, "Sessions · web") || !strings.Contains(view, "claude --resume") {
+		t.Fatalf("session picker not shown:\n%s", view)
+	}
+	updated, _ = d.Update(keyMsg("j"))
+	updated, _ = updated.(Dashboard).Update(tea.KeyMsg{Type: tea.KeyEnter})
+	d = updated.(Dashboard)
+	if d.picker != nil || d.preferred["web"] != "7" {
+		t.Fatalf("attaching should close the picker and remember session 7, got %v", d.preferred)
+	}
+
+	// Enter now checks session 7 is still running before attaching.
+	updated, cmd = d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	d = updated.(Dashboard)
+	if d.checking != "web" {
+		t.Fatal("Enter should look up the preferred session")
+	}
+	msg := cmd()
+	if m, ok := msg.(sessionCheckedMsg); !ok || !m.alive {
+		t.Fatalf("check = %#v, want session alive", msg)
+	}
+	updated, _ = d.Update(msg)
+	d = updated.(Dashboard)
+	if d.checking != "" || d.preferred["web"] != "7" {
+		t.Errorf("after attaching: checking %q, preferred %v", d.checking, d.preferred)
+	}
+
+	// Once it has ended Enter falls back to a new console and forgets it.
+	src.sessions = src.sessions[:1]
+	updated, cmd = d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.(Dashboard).Update(cmd())
+	d = updated.(Dashboard)
+	if _, ok := d.preferred["web"]; ok || !strings.Contains(d.notice, "has ended") {
+		t.Errorf("preferred %v, notice %q; want session forgotten", d.preferred, d.notice)
+	}
+
+	// x kills the selected session after confirming.
+	updated, cmd = d.Update(keyMsg("s"))
+	d = runCmd(updated.(Dashboard), cmd)
+	updated, _ = d.Update(keyMsg("x"))
+	updated, cmd = updated.(Dashboard).Update(keyMsg("y"))
+	d = runCmd(updated.(Dashboard), cmd)
+	if last := src.calls[len(src.calls)-1]; last != "kill:web:3" || !strings.Contains(d.notice, "Killed session 3") {
+		t.Errorf("last call %q, notice %q", last, d.notice)
+	}
+}
+
+func TestUpdate_KillingThePreferredSessionForgetsIt(t *testing.T) {
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	err := store.Update(func(st *state.State) {
+		st.Record(state.Event{Sprite: "web", Kind: state.EventConnect, Session: "7"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &mockSource{sprites: []sprites.Sprite{{Name: "web", Status: sprites.StatusWorking}}}
+	d := NewDashboard(src, WithState(store))
+	if d.preferred["web"] != "7" {
+		t.Fatalf("preferred = %v, want session 7 from the state file", d.preferred)
+	}
+
+	updated, cmd := d.Update(sessionKilledMsg{name: "web", id: "7"})
+	d = runCmd(updated.(Dashboard), cmd)
+	if _, ok := d.preferred["web"]; ok {
+		t.Errorf("preferred = %v, want session 7 forgotten", d.preferred)
+	}
+	st, _ := store.Load()
+	if got := st.Sessions(); len(got) != 0 {
+		t.Errorf("saved sessions = %v, the next run should not attach to the killed one", got)
+	}
+}
+
+func TestUpdate_BulkActions(t *testing.T) {
+	src := &mockSource{
+		sprites: []sprites.Sprite{
+			{ID: "1", Name: "a", Status: sprites.StatusWorking},
+			{ID: "2", Name: "b", Status: sprites.StatusWorking},
+			{ID: "3", Name: "c", Status: sprites.StatusWorking},
+			{ID: "4", Name: "d", Status: sprites.StatusWorking},
+		},
+		execExit: map[string]int{"c": 2},
+	}
+	d := testDashboard(src, 120, 30)
+	press := func(keys ...string) {
+		t.Helper()
+		for _, k := range keys {
+			updated, cmd := d.Update(keyMsg(k))
+			d = runCmd(updated.(Dashboard), cmd)
+		}
+	}
+
+	// space marks a and moves down; V marks from a to the cursor.
+	press(" ", "j", "V")
+	if got := d.targets(); strings.Join(got, ",") != "a,b,c" {
+		t.Fatalf("marked %v, want a,b,c", got)
+	}
+	if view := d.View(); !strings.Contains(view, "3 marked") {
+		t.Errorf("subheader should count marks:\n%s", view)
+	}
+	press("*")
+	if len(d.marked) != 4 {
+		t.Errorf("* marked %d, want all 4", len(d.marked))
+	}
+	press("*")
+	if len(d.marked) != 0 {
+		t.Errorf("* again should unmark all, got %v", d.marked)
+	}
+
+	// One confirmation for a checkpoint of every marked Sprite.
+	d.cursor = 0
+	press(" ", "j", " ", "c")
+	if d.confirm == nil || d.confirm.title != "Checkpoint 2 Sprites?" || !strings.Contains(d.confirm.body, "a, c") {
+		t.Fatalf("confirm = %+v", d.confirm)
+	}
+	src.calls = nil
+	press("y")
+	if strings.Join(src.calls, " ") != "checkpoint:a checkpoint:c" {
+		t.Errorf("calls = %q", src.calls)
+	}
+	if d.notice != "Checkpoint: done on 2 Sprites" || len(d.marked) != 0 {
+		t.Errorf("notice %q, marks %v", d.notice, d.marked)
+	}
+
+	// A failure on one Sprite is reported with its reason; the rest run.
+	d.cursor = 1
+	press(" ", " ", "e")
+	for _, r := range "git pull" {
+		updated, _ := d.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+		d = updated.(Dashboard)
+	}
+	updated, _ := d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	d = updated.(Dashboard)
+	if d.confirm == nil || d.confirm.title != "Run on 2 Sprites?" {
+		t.Fatalf("confirm = %+v", d.confirm)
+	}
+	press("y")
+	want := "Run git pull: failed on 1 of 2 — c: exit code 2: boom"
+	if d.lastErr != want {
+		t.Errorf("lastErr = %q, want %q", d.lastErr, want)
+	}
+
+	// Restarting starts Claude Code the way the Sprite's template does.
+	d.tmpls = append(d.tmpls, templates.Template{Name: "go", Claude: templates.Claude{Command: "claude --model opus", Dir: "/src/api"}})
+	d.madeFrom["a"] = "go"
+	d.cursor = 0
+	src.calls = nil
+	press("R", "y")
+	if len(src.calls) != 1 || !strings.Contains(src.calls[0], "-c '/src/api' 'claude --model opus --continue;") {
+		t.Errorf("calls = %q, want a restart from the go template", src.calls)
+	}
+}
+
+func TestView_GroupsSpritesByOrg(t *testing.T) {
+	personal := &mockSource{sprites: []sprites.Sprite{{Name: "api"}, {Name: "shared"}}}
+	work := &mockSource{sprites: []sprites.Sprite{{Name: "shared"}, {Name: "batch"}}}
+	src := sprites.NewMulti([]string{"personal", "work"}, map[string]sprites.SpriteSource{
+		"personal": personal,
+		"work":     work,
+	})
+	d := testDashboard(src, 100, 30)
+
+	view := d.View()
+	for _, want := range []string{"personal · 2 Sprites", "work · 2 Sprites", "personal/shared", "work/shared"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "personal/api") {
+		t.Errorf("names only one organization has should be shown bare:\n%s", view)
+	}
+	if strings.Index(view, "batch") < strings.Index(view, "work · 2 Sprites") {
+		t.Errorf("batch should be listed under work:\n%s", view)
+	}
+
+	// An unreachable organization keeps its last known Sprites.
+	work.err = fmt.Errorf("boom")
+	updated, cmd := d.Update(keyMsg("r"))
+	d = runCmd(updated.(Dashboard), cmd)
+	view = d.View()
+	if !strings.Contains(view, "work · unreachable: boom (last known)") {
+		t.Errorf("View() should show the work error:\n%s", view)
+	}
+	if !strings.Contains(view, "batch") || !strings.Contains(view, "personal · 2 Sprites") {
+		t.Errorf("View() should keep both groups:\n%s", view)
+	}
+	if d.lastErr != "" {
+		t.Errorf("lastErr = %q, want none while one org lists", d.lastErr)
+	}
+
+	// The cursor walks the groups in the order they are drawn.
+	var order []string
+	for _, m := range d.visible() {
+		order = append(order, m.sprite.Name)
+	}
+	if want := "personal/api personal/shared work/shared work/batch"; strings.Join(order, " ") != want {
+		t.Errorf("visible() = %v, want %s", order, want)
+	}
+
+	// The wizard asks which organization to create in; a name is only
+	// taken within one.
+	work.err = nil
+	d = typeKeys(d, "n", "b", "a", "t", "c", "h", "enter")
+	if !strings.Contains(d.View(), "▸ personal") {
+		t.Fatalf("the wizard should list the organizations:\n%s", d.View())
+	}
+	d = typeKeys(d, "j", "enter")
+	if !strings.Contains(d.View(), "batch already exists") {
+		t.Fatalf("batch is taken in work:\n%s", d.View())
+	}
+	d = typeKeys(d, "k", "enter")
+	updated, cmd = d.Update(keyMsg("enter"))
+	d = updated.(Dashboard)
+	if d.displayStatus(sprites.Sprite{Name: "personal/batch"}) != sprites.StatusCreating {
+		t.Errorf("pending = %v, want personal/batch CREATING", d.pending)
+	}
+	d = runCmd(d, cmd)
+	if len(personal.calls) != 1 || personal.calls[0] != "create:batch:" || len(work.calls) != 0 {
+		t.Errorf("calls = %q / %q, want batch created in personal", personal.calls, work.calls)
+	}
+	if !strings.Contains(d.View(), "Created personal/batch") {
+		t.Errorf("missing completion notice:\n%s", d.View())
+	}
+}
+
+func TestUpdate_TagsAndGroups(t *testing.T) {
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	src := &mockSource{sprites: []sprites.Sprite{
+		{Name: "web", Region: "ord"},
+		{Name: "api", Region: "ams"},
+		{Name: "docs", Region: "ord"},
+	}}
+	d := NewDashboard(src, WithState(store))
+	d.width, d.height = 120, 30
+	d = runCmd(d, d.loadSprites())
+
+	// t edits the selected Sprite's tags, saved to the state file.
+	for _, key := range []string{"t", "Frontend, urgent"} {
+		updated, _ := d.Update(keyMsg(key))
+		d = updated.(Dashboard)
+	}
+	updated, cmd := d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	d = runCmd(updated.(Dashboard), cmd)
+	if got := strings.Join(d.tags["web"], ","); got != "frontend,urgent" {
+		t.Fatalf("web tags = %q, want frontend,urgent", got)
+	}
+	st, _ := store.Load()
+	if got := st.Tags()["web"]; strings.Join(got, ",") != "frontend,urgent" {
+		t.Errorf("saved tags = %v", got)
+	}
+
+	// b groups by tag: web under each of its tags, the rest untagged last.
+	updated, _ = d.Update(keyMsg("b"))
+	d = updated.(Dashboard)
+	var rows []string
+	for _, m := range d.visible() {
+		rows = append(rows, m.group+":"+m.sprite.Name)
+	}
+	if want := "frontend:web urgent:web :api :docs"; strings.Join(rows, " ") != want {
+		t.Errorf("grouped by tag = %v, want %s", rows, want)
+	}
+	view := d.View()
+	for _, want := range []string{"frontend · 1 Sprite", "untagged · 2 Sprites"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q:\n%s", want, view)
+		}
+	}
+
+	// z collapses the group under the cursor into its header; Enter
+	// expands it again.
+	updated, _ = d.Update(keyMsg("b"))
+	d = updated.(Dashboard)
+	if d.groupBy != groupRegion {
+		t.Fatalf("groupBy = %q, want region", d.groupBy)
+	}
+	updated, _ = d.Update(keyMsg("z"))
+	d = updated.(Dashboard)
+	if _, ok := d.selected(); ok || len(d.visible()) != 2 {
+		t.Errorf("after z the cursor should be on ord's header, rows = %d", len(d.visible()))
+	}
+	if view := d.View(); !strings.Contains(view, "▹ ord · 2 Sprites") || strings.Contains(view, "docs") {
+		t.Errorf("ord should be collapsed:\n%s", view)
+	}
+	updated, _ = d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	d = updated.(Dashboard)
+	if s, ok := d.selected(); !ok || s.Name != "web" {
+		t.Errorf("Enter should expand ord with the cursor on web, got %q", s.Name)
+	}
+
+	// Tags are matched by the filter too.
+	d.setFilter("urgent")
+	if v := d.visible(); len(v) != 1 || v[0].sprite.Name != "web" {
+		t.Errorf("filter urgent = %v, want web", v)
+	}
+}
+
+func TestView_CostColumnAndTotals(t *testing.T) {
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	now := time.Now()
+	err := store.Update(func(st *state.State) {
+		st.Usage = []state.Usage{
+			{Day: now.Format(state.DayFormat), Sprite: "web", Awake: 10},
+			{Day: now.Format(state.DayFormat), Sprite: "api", Asleep: 10},
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := &mockSource{sprites: []sprites.Sprite{
+		{Name: "web", Status: sprites.StatusWorking},
+		{Name: "api", Status: sprites.StatusSleeping},
+	}}
+	d := NewDashboard(src, WithState(store), WithCost(cost.Rates{RunningHour: 0.5, Currency: "$"}))
+	d.width, d.height = 120, 30
+	d = runCmd(d, d.loadSprites())
+
+	view := d.View()
+	for _, want := range []string{"COST", "$5.0", "$0.00", "Today $5.0", "Month $5.0"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("View() missing %q:\n%s", want, view)
+		}
+	}
+
+	// Without rates nothing about cost is shown.
+	d.rates = cost.Rates{}
+	if view := d.View(); strings.Contains(view, "COST") || strings.Contains(view, "Month") {
+		t.Errorf("costs should be hidden without rates:\n%s", view)
+	}
+}
+
+func TestUpdate_AnswerWaitingPrompt(t *testing.T) {
+	replySettle = 0
+	src := &mockSource{
+		sprites:    []sprites.Sprite{{Name: "web", Status: sprites.StatusWorking}, {Name: "api", Status: sprites.StatusWorking}},
+		execStdout: "WAITING\nAllow Bash(rm -rf build)? (y/n)",
+	}
+	pl := poller.New(src)
+	pl.Workers = 1 // mockSource records calls without locking
+	d := NewDashboard(src, WithPoller(pl))
+	d.width, d.height = 120, 30
+	updated, _ := d.Update(pollCycleMsg{cycle: pl.PollOnce(context.Background())})
+	d = updated.(Dashboard)
+
+	updated, _ = d.Update(keyMsg("a"))
+	d = updated.(Dashboard)
+	if d.confirm == nil || !strings.Contains(d.View(), "Allow Bash(rm -rf build)? (y/n)") {
+		t.Fatalf("a should show the detected prompt:\n%s", d.View())
+	}
+
+	// Answering sends the keys and checks the Sprite again at once.
+	src.execStdout = "WORKING"
+	src.calls = nil
+	updated, cmd := d.Update(keyMsg("y"))
+	d = runCmd(updated.(Dashboard), cmd)
+	if len(src.calls) != 2 || src.calls[0] != "exec:web:y" {
+		t.Errorf("calls = %v, want the answer then one detection", src.calls)
+	}
+	if d.sprites[0].Status != sprites.StatusWorking || d.results["web"].Status != sprites.StatusWorking {
+		t.Errorf("web = %s, want WORKING after answering", d.sprites[0].Status)
+	}
+	if d.sprites[1].Status != sprites.StatusWaiting {
+		t.Errorf("api should be left waiting, got %s", d.sprites[1].Status)
+	}
+
+	// A typed answer goes through the prompt; only WAITING rows can be
+	// answered.
+	updated, _ = d.Update(keyMsg("a"))
+	d = updated.(Dashboard)
+	if d.confirm != nil || !strings.Contains(d.notice, "not waiting") {
+		t.Errorf("answering a WORKING Sprite should be refused, notice %q", d.notice)
+	}
+	updated, _ = d.Update(keyMsg("j"))
+	d = updated.(Dashboard)
+	for _, key := range []string{"a", "t", "use the cache"} {
+		updated, _ = d.Update(keyMsg(key))
+		d = updated.(Dashboard)
+	}
+	src.calls = nil
+	updated, cmd = d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	d = runCmd(updated.(Dashboard), cmd)
+	if len(src.calls) == 0 || src.calls[0] != "exec:api:use the cache" {
+		t.Errorf("calls = %v, want the typed answer", src.calls)
+	}
+	if !strings.Contains(d.notice, `Sent "use the cache" to api`) {
+		t.Errorf("notice = %q", d.notice)
+	}
+}
+
+func TestUpdate_SendPrompt(t *testing.T) {
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	src := &mockSource{sprites: []sprites.Sprite{{Name: "web", Status: sprites.StatusFinished}}, execStdout: "sent\n"}
+	d := NewDashboard(src, WithState(store))
+	d.width, d.height = 120, 30
+	d = runCmd(d, d.loadSprites())
+
+	// p opens a multi-line editor; Enter starts a line, Ctrl+S sends.
+	for _, key := range []string{"p", "fix the build"} {
+		updated, _ := d.Update(keyMsg(key))
+		d = updated.(Dashboard)
+	}
+	updated, _ := d.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	updated, _ = updated.(Dashboard).Update(keyMsg("then deploy"))
+	d = updated.(Dashboard)
+	if d.task == nil || !strings.Contains(d.View(), "New prompt for web") {
+		t.Fatalf("p should open the prompt editor:\n%s", d.View())
+	}
+	src.calls = nil
+	updated, cmd := d.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	d = runCmd(updated.(Dashboard), cmd)
+	if len(src.calls) == 0 || src.calls[0] != "exec:web:fix the build\nthen deploy" {
+		t.Errorf("calls = %q, want the prompt sent to web", src.calls)
+	}
+	if d.task != nil || d.notice != "Sent prompt to web" {
+		t.Errorf("notice = %q, want the prompt sent", d.notice)
+	}
+
+	// The prompt is remembered and shown in the detail pane.
+	st, _ := store.Load()
+	if got := st.Prompts()["web"]; len(got) != 1 || got[0].Detail != "fix the build\nthen deploy" {
+		t.Errorf("saved prompts = %+v", got)
+	}
+	d.detail = true
+	if view := d.View(); !strings.Contains(view, "PROMPTS") || !strings.Contains(view, "fix the build …") {
+		t.Errorf("detail pane should list the prompt:\n%s", view)
+	}
+
+	// An empty prompt is not sent.
+	updated, _ = d.Update(keyMsg("p"))
+	updated, cmd = updated.(Dashboard).Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if d = updated.(Dashboard); d.task == nil || cmd != nil {
+		t.Errorf("an empty prompt should keep the editor open")
+	}
+}
+
+// ranTask reports whether src ran prompt on name as a queued task.
+func ranTask(src *mockSource, name, prompt string) bool {
+	return slices.ContainsFunc(src.calls, func(c string) bool {
+		return strings.HasPrefix(c, "exec:"+name+":") && strings.Contains(c, "claude -p") && strings.Contains(c, prompt)
+	})
+}
+
+// claudeSource runs Claude Code on its Sprites as far as the default
+// detector can tell: a task command starts it and it runs until running
+// is cleared, or, if quick, is over before the next check.
+type claudeSource struct {
+	*mockSource
+	running map[string]bool
+	quick   bool
+}
+
+func (c *claudeSource) Exec(ctx context.Context, name string, command []string) (sprites.ExecResult, error) {
+	script := command[len(command)-1]
+	switch {
+	case strings.Contains(script, "claude -p"):
+		c.running[name] = !c.quick
+		return c.mockSource.Exec(ctx, name, command)
+	case strings.Contains(script, "pgrep"):
+		if c.running[name] {
+			return sprites.ExecResult{Stdout: []byte("WORKING\n")}, nil
+		}
+		return sprites.ExecResult{Stdout: []byte("FINISHED\n")}, nil
+	}
+	return c.mockSource.Exec(ctx, name, command)
+}
+
+func TestUpdate_TaskLifecycleWithDefaultDetector(t *testing.T) {
+	defer func(settle time.Duration) { replySettle = settle }(replySettle)
+	replySettle = 0
+
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	store.Update(func(st *state.State) {
+		st.AddTask(state.Task{Prompt: "fix the build"})
+		st.AddTask(state.Task{Prompt: "write docs"})
+	})
+	src := &claudeSource{
+		mockSource: &mockSource{sprites: []sprites.Sprite{{Name: "web", Status: sprites.StatusWorking}}, execStdout: "started\n"},
+		running:    make(map[string]bool),
+	}
+	// Cycles come from a poller with the default detector.
+	pl := poller.New(src)
+	d := NewDashboard(src, WithState(store), WithQueue(queue.Options{}))
+	d.width, d.height = 120, 30
+	poll := func() {
+		t.Helper()
+		updated, cmd := d.Update(pollCycleMsg{cycle: pl.PollOnce(context.Background())})
+		d = runCmd(updated.(Dashboard), cmd)
+	}
+	taskStatus := func(id int) string {
+		t.Helper()
+		st, _ := store.Load()
+		return st.FindTask(id).Status
+	}
+
+	// Idle web takes the first task, which starts Claude Code.
+	poll()
+	if got := taskStatus(1); got != state.TaskAssigned || !src.running["web"] {
+		t.Fatalf("task #1 = %s, want assigned to web and running", got)
+	}
+	poll()
+	poll()
+	if got := taskStatus(1); got != state.TaskWorking {
+		t.Errorf("task #1 = %s, want working", got)
+	}
+
+	// Claude Code exits when done, ending the task and freeing web for
+	// the next one.
+	src.running["web"] = false
+	poll()
+	if got := taskStatus(1); got != state.TaskDone {
+		t.Errorf("task #1 = %s, want done", got)
+	}
+	if got, _ := d.currentTask("web"); got.ID != 2 || !ranTask(src.mockSource, "web", "write docs") {
+		t.Errorf("web's task = %+v, want #2 running", got)
+	}
+}
+
+func TestUpdate_TaskEndsBetweenPolls(t *testing.T) {
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	store.Update(func(st *state.State) { st.AddTask(state.Task{Prompt: "fix the build"}) })
+	src := &claudeSource{
+		mockSource: &mockSource{sprites: []sprites.Sprite{{Name: "web", Status: sprites.StatusWorking}}, execStdout: "started\n"},
+		running:    make(map[string]bool),
+		quick:      true,
+	}
+	pl := poller.New(src)
+	d := NewDashboard(src, WithState(store), WithQueue(queue.Options{}))
+	d.width, d.height = 120, 30
+
+	// No check sees the run: web is FINISHED before and after it.
+	for range 2 {
+		updated, cmd := d.Update(pollCycleMsg{cycle: pl.PollOnce(context.Background())})
+		d = runCmd(updated.(Dashboard), cmd)
+	}
+	st, _ := store.Load()
+	if got := st.FindTask(1); !ranTask(src.mockSource, "web", "fix the build") || got.Status != state.TaskDone {
+		t.Errorf("task = %+v, want run on web and done", got)
+	}
+}
+
+func TestUpdate_DispatchesQueuedTasks(t *testing.T) {
+	store := state.NewStore(filepath.Join(t.TempDir(), "state.json"))
+	store.Update(func(st *state.State) {
+		st.AddTask(state.Task{Prompt: "fix the build"})
+		st.AddTask(state.Task{Prompt: "write docs", Tags: []string{"docs"}})
+		st.AddTask(state.Task{Prompt: "no room left"})
+	})
+	src := &mockSource{
+		sprites:    []sprites.Sprite{{Name: "web", Status: sprites.StatusFinished}, {Name: "api", Status: sprites.StatusWorking}},
+		execStdout: "started\n",
+	}
+	var madeFrom map[string]string
+	d := NewDashboard(src, WithState(store), WithQueue(queue.Options{MaxSprites: 3}),
+		WithOnSave(func(st *state.State) { madeFrom = st.Templates() }))
+	d.width, d.height = 120, 30
+	d = runCmd(d, d.loadSprites())
+
+	poll := func(status, previous string) {
+		t.Helper()
+		results := map[string]poller.Result{
+			"web": {Name: "web", Status: status, Previous: previous, CheckedAt: time.Now()},
+			"api": {Name: "api", Status: sprites.StatusWorking, CheckedAt: time.Now()},
+		}
+		updated, cmd := d.Update(pollCycleMsg{cycle: poller.Cycle{Sprites: src.sprites, Results: results, At: time.Now()}})
+		d = runCmd(updated.(Dashboard), cmd)
+	}
+
+	// The first task goes to idle web. No Sprite has the docs tag, so one
+	// is created for that task, which fills the fleet; the last task waits.
+	poll(sprites.StatusFinished, "")
+	if !slices.Contains(src.calls, "create:task-2:") || !ranTask(src, "web", "fix the build") || !ranTask(src, "task-2", "write docs") {
+		t.Errorf("calls = %q, want web to run task #1 and a new task-2 task #2", src.calls)
+	}
+	st, _ := store.Load()
+	if l := st.Local["task-2"]; strings.Join(l.Tags, ",") != "docs" || l.Template != templates.Blank {
+		t.Errorf("task-2 = %+v, want created from blank and tagged docs", l)
+	}
+	if madeFrom["task-2"] != templates.Blank {
+		t.Errorf("saved templates = %v, want task-2 passed on", madeFrom)
+	}
+	if q := st.Queued(); len(q) != 1 || q[0].Prompt != "no room left" {
+		t.Errorf("queued = %+v, want only the last task", q)
+	}
+	if view := d.View(); !strings.Contains(view, "task #1: fix the build") || !strings.Contains(view, "1 task queued") {
+		t.Errorf("the list should show web's task and the queue:\n%s", view)
+	}
+
+	// The task follows web's Claude Code until it finishes, freeing web
+	// for the task that was waiting.
+	poll(sprites.StatusWorking, sprites.StatusFinished)
+	st, _ = store.Load()
+	if got := st.FindTask(1).Status; got != state.TaskWorking {
+		t.Errorf("task #1 = %s, want working", got)
+	}
+	poll(sprites.StatusFinished, sprites.StatusWorking)
+	st, _ = store.Load()
+	if got := st.FindTask(1).Status; got != state.TaskDone {
+		t.Errorf("task #1 = %s, want done", got)
+	}
+	if got, _ := d.currentTask("web"); got.ID != 3 || d.queuedTasks() != 0 {
+		t.Errorf("web's task = %+v, want #3 taken from the queue", got)
+	}
+}
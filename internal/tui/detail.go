@@ -0,0 +1,312 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/sprites"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Detail pane tuning.
+const (
+	// detailTTL is how long fetched details are shown before the next
+	// selection of the Sprite fetches them again.
+	detailTTL = 30 * time.Second
+	// detailPaneMin is the narrowest pane shown beside the list. Below it
+	// the pane replaces the list.
+	detailPaneMin = 36
+	// paneLines is how much of Claude Code's tmux pane is shown.
+	paneLines = 8
+	// detailCheckpoints is how many recent checkpoints are shown.
+	detailCheckpoints = 3
+)
+
+// detailDelay is how long the cursor must rest on a Sprite before its
+// details are fetched, so scrolling through the list fetches nothing.
+var detailDelay = 300 * time.Millisecond
+
+// paneCommand prints the last non-blank lines of the tmux pane, like the
+// detection script reads.
+var paneCommand = []string{"sh", "-c",
+	fmt.Sprintf("tmux capture-pane -p 2>/dev/null | grep -v '^[[:space:]]*$' | tail -n %d", paneLines)}
+
+type detailDueMsg struct {
+	name string
+}
+
+type detailLoadedMsg struct {
+	name   string
+	detail spriteDetail
+}
+
+// spriteDetail is what the detail pane fetches for one Sprite. Each part
+// fails on its own so one slow endpoint does not hide the others.
+type spriteDetail struct {
+	loading     bool
+	fetchedAt   time.Time
+	pane        []string
+	paneErr     string
+	asleep      bool // pane not read, to avoid waking the Sprite
+	sessions    []sprites.ExecSession
+	sessionsErr string
+	checkpoints []sprites.Checkpoint
+	ckptErr     string
+}
+
+// fresh reports whether the details are loading or recent enough to show
+// without fetching again.
+func (s *spriteDetail) fresh() bool {
+	return s != nil && (s.loading || time.Since(s.fetchedAt) < detailTTL)
+}
+
+// scheduleDetail fetches the selected Sprite's details after detailDelay,
+// unless the pane is closed or they are already fresh.
+func (d Dashboard) scheduleDetail() tea.Cmd {
+	if !d.detail {
+		return nil
+	}
+	s, ok := d.selected()
+	if !ok || d.details[s.Name].fresh() {
+		return nil
+	}
+	if _, creating := d.creating[s.Name]; creating {
+		return nil
+	}
+	name := s.Name
+	return tea.Tick(detailDelay, func(time.Time) tea.Msg {
+		return detailDueMsg{name: name}
+	})
+}
+
+// invalidateDetail drops name's cached details, fetching them again if the
+// pane shows name.
+func (d *Dashboard) invalidateDetail(name string) tea.Cmd {
+	delete(d.details, name)
+	if s, ok := d.selected(); ok && s.Name == name {
+		return d.scheduleDetail()
+	}
+	return nil
+}
+
+// loadDetail starts fetching s's details if the cursor is still on it.
+func (d *Dashboard) loadDetail(name string) tea.Cmd {
+	s, ok := d.selected()
+	if !d.detail || !ok || s.Name != name || d.details[name].fresh() {
+		return nil
+	}
+	d.details[name] = &spriteDetail{loading: true}
+
+	src, awake := d.cli, poller.Pollable(s)
+	return func() tea.Msg {
+		ctx := context.Background()
+		det := spriteDetail{asleep: !awake}
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			sessions, err := src.ListSessions(ctx, name)
+			det.sessions = sessions
+			if err != nil {
+				det.sessionsErr = err.Error()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			cps, err := src.ListCheckpoints(ctx, name)
+			det.checkpoints = cps
+			if err != nil {
+				det.ckptErr = err.Error()
+			}
+		}()
+		if awake {
+			ctx, cancel := context.WithTimeout(ctx, poller.DefaultExecTimeout)
+			res, err := src.Exec(ctx, name, paneCommand)
+			cancel()
+			if err != nil {
+				det.paneErr = err.Error()
+			} else if out := strings.TrimRight(string(res.Stdout), "\n"); out != "" {
+				det.pane = strings.Split(out, "\n")
+			}
+		}
+		wg.Wait()
+		det.fetchedAt = time.Now()
+		return detailLoadedMsg{name: name, detail: det}
+	}
+}
+
+// renderDetail renders the selected Sprite's details into a width x height
+// region.
+func (d Dashboard) renderDetail(width, height int) string {
+	s, ok := d.selected()
+	if !ok {
+		return padLines("", height)
+	}
+	det := d.details[s.Name]
+	inner := width - 2
+
+	var lines []string
+	add := func(line string) {
+		lines = append(lines, line)
+	}
+	field := func(label, value string) {
+		add(mutedStyle.Render(padRight(label, 9)) + truncate(value, inner-9))
+	}
+	section := func(title string) {
+		add("")
+		add(columnHeaderStyle.Render(title))
+	}
+	muted := func(text string) {
+		add(mutedStyle.Render(truncate(text, inner)))
+	}
+
+	add(headerStyle.Render(truncate(s.Name, inner)))
+	field("ID", Dash(s.ID))
+	field("Region", Dash(s.Region))
+	if s.Org != "" {
+		field("Org", s.Org)
+	}
+	if tags := d.tags[s.Name]; len(tags) > 0 {
+		field("Tags", strings.Join(tags, ", "))
+	}
+	if d.showCost() {
+		costs, _, _ := d.costs(time.Now())
+		field("Cost", d.rates.Format(costs[s.Name])+" this month")
+	}
+	field("Created", formatCheckpointTime(s.CreatedAt))
+
+	status := d.displayStatus(s)
+	r, checked := d.results[s.Name]
+	st := statusStyle(status).Render(statusLabel(status))
+	if checked {
+		st += mutedStyle.Render(" · checked " + formatAgo(time.Since(r.CheckedAt)))
+	}
+	add(mutedStyle.Render(padRight("Status", 9)) + st)
+	if a := activityText(status, r); checked && a != "" {
+		field("", a)
+	}
+	if r.Stale() {
+		field("", "last check: "+r.Err.Error())
+	}
+
+	if t, ok := d.currentTask(s.Name); ok {
+		field("Task", fmt.Sprintf("#%d %s · %s", t.ID, t.Status, promptLine(t.Prompt)))
+	}
+
+	if prompts := d.prompts[s.Name]; len(prompts) > 0 {
+		section("PROMPTS")
+		for i, e := range prompts {
+			if i == detailPrompts {
+				muted(fmt.Sprintf("+%d more", len(prompts)-i))
+				break
+			}
+			age := " " + mutedStyle.Render(formatAgo(time.Since(e.At)))
+			add(truncate(promptLine(e.Detail), inner-lipgloss.Width(age)) + age)
+		}
+	}
+
+	section("CLAUDE CODE")
+	switch {
+	case det == nil || det.loading && det.fetchedAt.IsZero():
+		muted("Loading…")
+	case det.asleep:
+		muted("Asleep. Press Enter to wake and connect.")
+	case det.paneErr != "":
+		muted(det.paneErr)
+	case len(det.pane) == 0:
+		muted("No output.")
+	default:
+		for _, l := range det.pane {
+			add(truncate(l, inner))
+		}
+	}
+
+	if det != nil && !det.fetchedAt.IsZero() {
+		section(fmt.Sprintf("SESSIONS (%d)", len(det.sessions)))
+		switch {
+		case det.sessionsErr != "":
+			muted(det.sessionsErr)
+		case len(det.sessions) == 0:
+			muted("None.")
+		}
+		for _, es := range det.sessions {
+			age := ""
+			if !es.CreatedAt.IsZero() {
+				age = " " + mutedStyle.Render(formatAgo(time.Since(es.CreatedAt)))
+			}
+			if es.ID == d.preferred[s.Name] {
+				age = " ★" + age
+			}
+			add(truncate(es.ID+"  "+es.Command, inner-lipgloss.Width(age)) + age)
+		}
+
+		section("CHECKPOINTS")
+		switch {
+		case det.ckptErr != "":
+			muted(det.ckptErr)
+		case len(det.checkpoints) == 0:
+			muted("None.")
+		}
+		for i, cp := range det.checkpoints {
+			if i == detailCheckpoints {
+				muted(fmt.Sprintf("+%d more (C to browse)", len(det.checkpoints)-i))
+				break
+			}
+			age := " " + mutedStyle.Render(formatAgo(time.Since(cp.CreatedAt)))
+			add(truncate(cp.Label(), inner-lipgloss.Width(age)) + age)
+		}
+	}
+
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for len(lines) < height {
+		lines = append(lines, "")
+	}
+	border := mutedStyle.Render("│ ")
+	var b strings.Builder
+	for _, l := range lines {
+		b.WriteString(border + l + "\n")
+	}
+	return b.String()
+}
+
+// renderListWithDetail renders the Sprite list with the detail pane on its
+// right, or the pane alone when the terminal is too narrow for both.
+func (d Dashboard) renderListWithDetail(height int) string {
+	listWidth := d.cols.Name + d.cols.Status + d.cols.Uptime
+	paneWidth := d.width - listWidth
+	if paneWidth < detailPaneMin {
+		return d.renderDetail(d.width, height)
+	}
+	list := strings.TrimSuffix(d.renderSpriteList(height), "\n")
+	pane := strings.TrimSuffix(d.renderDetail(paneWidth, height), "\n")
+	listLines := strings.Split(list, "\n")
+	paneRows := strings.Split(pane, "\n")
+
+	var b strings.Builder
+	for i := range height {
+		var l, p string
+		if i < len(listLines) {
+			l = listLines[i]
+		}
+		if i < len(paneRows) {
+			p = paneRows[i]
+		}
+		b.WriteString(padRight(l, listWidth) + p + "\n")
+	}
+	return b.String()
+}
+
+// Dash returns s, or an em dash when it is empty, for blank cells.
+func Dash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
@@ -0,0 +1,169 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// Ways to group the Sprite list, cycled with b.
+const (
+	groupNone   = ""
+	groupOrg    = "org"
+	groupTag    = "tag"
+	groupRegion = "region"
+	groupStatus = "status"
+)
+
+// groupModes returns the groupings b cycles through. Organizations are
+// offered only when there are several.
+func (d Dashboard) groupModes() []string {
+	if len(d.orgs) > 1 {
+		return []string{groupOrg, groupTag, groupRegion, groupStatus, groupNone}
+	}
+	return []string{groupNone, groupTag, groupRegion, groupStatus}
+}
+
+// groupKeys returns the groups s is listed under: one per tag when
+// grouping by tag, otherwise exactly one. An empty key collects the
+// Sprites without a tag or region.
+func (d Dashboard) groupKeys(s sprites.Sprite) []string {
+	switch d.groupBy {
+	case groupOrg:
+		return []string{d.orgOf(s)}
+	case groupTag:
+		if tags := d.tags[s.Name]; len(tags) > 0 {
+			return tags
+		}
+	case groupRegion:
+		return []string{s.Region}
+	case groupStatus:
+		return []string{s.Status}
+	}
+	return []string{""}
+}
+
+// group orders list into groups, keeping the list order within each, and
+// replaces the Sprites of each collapsed group with its header row.
+// Organizations keep their configured order; other groups are sorted by
+// name with the empty group last.
+func (d Dashboard) group(list []match) []match {
+	groups := make(map[string][]match)
+	var keys []string
+	for _, m := range list {
+		for _, k := range d.groupKeys(m.sprite) {
+			if _, ok := groups[k]; !ok {
+				keys = append(keys, k)
+			}
+			m.group = k
+			groups[k] = append(groups[k], m)
+		}
+	}
+	slices.SortStableFunc(keys, func(a, b string) int {
+		if d.groupBy == groupOrg {
+			return slices.Index(d.orgs, a) - slices.Index(d.orgs, b)
+		}
+		if (a == "") != (b == "") {
+			if a == "" {
+				return 1
+			}
+			return -1
+		}
+		return strings.Compare(a, b)
+	})
+
+	out := make([]match, 0, len(list))
+	for _, k := range keys {
+		if d.collapsed[k] {
+			out = append(out, match{group: k, folded: len(groups[k])})
+			continue
+		}
+		out = append(out, groups[k]...)
+	}
+	return out
+}
+
+// groupLabel names a group in its header.
+func (d Dashboard) groupLabel(key string) string {
+	if key != "" {
+		if d.groupBy == groupStatus {
+			return statusLabel(key)
+		}
+		return key
+	}
+	switch d.groupBy {
+	case groupTag:
+		return "untagged"
+	case groupRegion:
+		return "no region"
+	}
+	return "unknown"
+}
+
+// renderGroupHeader renders the line above a group of n Sprites, or in
+// place of them when the group is collapsed. An organization that could
+// not be listed shows why; its rows are the Sprites it last listed.
+func (d Dashboard) renderGroupHeader(key string, n int, collapsed, cursor bool) string {
+	prefix := "  "
+	if cursor {
+		prefix = cursorStyle.Render("▸ ")
+	}
+	fold := "▾ "
+	if collapsed {
+		fold = "▹ "
+	}
+	if err := d.orgErrs[key]; err != nil && d.groupBy == groupOrg {
+		text := fmt.Sprintf("%s · unreachable: %s", key, err.Error())
+		if n > 0 {
+			text += " (last known)"
+		}
+		return prefix + statusStyle(sprites.StatusError).Render(truncate(fold+text, d.width-3))
+	}
+	text := fmt.Sprintf("%s · %s", d.groupLabel(key), plural(n, "Sprite"))
+	return prefix + columnHeaderStyle.Render(truncate(fold+text, d.width-3))
+}
+
+// cycleGroup switches to the next grouping, expanding every group and
+// keeping the cursor on the same Sprite.
+func (d *Dashboard) cycleGroup() {
+	current, _ := d.selected()
+	modes := d.groupModes()
+	d.groupBy = modes[(slices.Index(modes, d.groupBy)+1)%len(modes)]
+	clear(d.collapsed)
+	d.cursor = 0
+	for i, m := range d.visible() {
+		if m.folded == 0 && m.sprite.Name == current.Name {
+			d.cursor = i
+			break
+		}
+	}
+	if d.groupBy == groupNone {
+		d.notice = "Not grouped"
+	} else {
+		d.notice = "Grouped by " + d.groupBy
+	}
+}
+
+// toggleGroup collapses the group under the cursor, or expands it when the
+// cursor is on its header.
+func (d *Dashboard) toggleGroup() {
+	list := d.visible()
+	if d.groupBy == groupNone || len(list) == 0 {
+		return
+	}
+	key := list[d.cursor].group
+	if list[d.cursor].folded > 0 {
+		// The group's first Sprite takes the header's place.
+		delete(d.collapsed, key)
+		return
+	}
+	d.collapsed[key] = true
+	for i, m := range d.visible() {
+		if m.folded > 0 && m.group == key {
+			d.cursor = i
+			break
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/logs"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/sprites"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// logInterval is how often the log viewer reads again while following.
+var logInterval = logs.DefaultInterval
+
+type logsLoadedMsg struct {
+	sprite string
+	gen    int
+	lines  []string
+	err    error
+}
+
+type logTickMsg struct {
+	sprite string
+	gen    int
+}
+
+type servicesLoadedMsg struct {
+	sprite   string
+	services []sprites.Service
+}
+
+// logViewer is the sub-view streaming one Sprite's Claude Code pane or
+// service log into a scrollable viewport.
+type logViewer struct {
+	sprite  string
+	targets []logs.Target // the pane, then each service
+	current int
+	lines   []string
+	offset  int  // first line shown
+	follow  bool // keep the newest line in view as lines arrive
+	paused  bool
+	loading bool
+	asleep  bool // not read, to avoid waking the Sprite
+	err     string
+	// gen identifies the current read loop. Switching target or resuming
+	// starts a new loop, and messages from older ones are dropped.
+	gen int
+
+	search  bool // typing a search query
+	query   string
+	matches []int // line indexes matching query
+	match   int   // index into matches
+}
+
+func newLogViewer(name string) *logViewer {
+	return &logViewer{
+		sprite:  name,
+		targets: []logs.Target{{Sprite: name}},
+		follow:  true,
+		loading: true,
+	}
+}
+
+func (v *logViewer) target() logs.Target {
+	return v.targets[v.current]
+}
+
+// setLines replaces the content, keeping the view on the newest lines when
+// following.
+func (v *logViewer) setLines(lines []string, err error, rows int) {
+	v.loading = false
+	if err != nil {
+		v.err = err.Error()
+		return
+	}
+	v.err = ""
+	v.lines = lines
+	v.findMatches()
+	if v.follow {
+		v.offset = v.maxOffset(rows)
+	}
+	v.offset = min(v.offset, v.maxOffset(rows))
+}
+
+// setServices offers services as further targets after the pane.
+func (v *logViewer) setServices(services []sprites.Service) {
+	v.targets = v.targets[:1]
+	for _, s := range services {
+		v.targets = append(v.targets, logs.Target{Sprite: v.sprite, Service: s.ID, Label: s.Label()})
+	}
+}
+
+func (v *logViewer) maxOffset(rows int) int {
+	return max(0, len(v.lines)-rows)
+}
+
+// scroll moves the view by n lines. Reaching the bottom resumes following.
+func (v *logViewer) scroll(n, rows int) {
+	v.offset = max(0, min(v.offset+n, v.maxOffset(rows)))
+	v.follow = v.offset == v.maxOffset(rows)
+}
+
+// findMatches recomputes which lines contain the query, ignoring case and
+// colors.
+func (v *logViewer) findMatches() {
+	v.matches = v.matches[:0]
+	if v.query == "" {
+		return
+	}
+	q := strings.ToLower(v.query)
+	for i, l := range v.lines {
+		if strings.Contains(strings.ToLower(ansi.Strip(l)), q) {
+			v.matches = append(v.matches, i)
+		}
+	}
+	v.match = max(0, min(v.match, len(v.matches)-1))
+}
+
+// firstMatch selects the first match at or below the top of the view.
+func (v *logViewer) firstMatch(rows int) {
+	v.match = 0
+	for i, line := range v.matches {
+		if line >= v.offset {
+			v.match = i
+			break
+		}
+	}
+	v.reveal(rows)
+}
+
+// jump moves to the next (dir 1) or previous (dir -1) match.
+func (v *logViewer) jump(dir, rows int) {
+	if len(v.matches) == 0 {
+		return
+	}
+	v.match = (v.match + dir + len(v.matches)) % len(v.matches)
+	v.reveal(rows)
+}
+
+// reveal scrolls the selected match into view.
+func (v *logViewer) reveal(rows int) {
+	if len(v.matches) == 0 {
+		return
+	}
+	line := v.matches[v.match]
+	if line < v.offset || line >= v.offset+rows {
+		v.offset = max(0, min(line-rows/2, v.maxOffset(rows)))
+	}
+	v.follow = v.offset == v.maxOffset(rows)
+}
+
+// View renders the viewer into a width x height region.
+func (v *logViewer) View(width, height int) string {
+	var s strings.Builder
+	title := "  Logs · " + v.sprite + " · " + v.target().String()
+	if len(v.targets) > 1 {
+		title += fmt.Sprintf(" (%d/%d)", v.current+1, len(v.targets))
+	}
+	s.WriteString(headerStyle.Render(title))
+	switch {
+	case v.paused:
+		s.WriteString("  " + badgeStyle.Render("PAUSED"))
+	case v.follow:
+		s.WriteString("  " + mutedStyle.Render("following"))
+	}
+	s.WriteString("\n")
+
+	rows := logRows(height)
+	switch {
+	case v.asleep:
+		s.WriteString(mutedStyle.Render("  Asleep. Press Enter to wake and connect.") + "\n")
+	case v.loading && len(v.lines) == 0:
+		s.WriteString("  Loading…\n")
+	case v.err != "" && len(v.lines) == 0:
+		s.WriteString("  " + truncate(v.err, width-4) + "\n")
+	case len(v.lines) == 0:
+		s.WriteString(mutedStyle.Render("  No output yet.") + "\n")
+	}
+
+	current := -1
+	if len(v.matches) > 0 {
+		current = v.matches[v.match]
+	}
+	hits := make(map[int]bool, len(v.matches))
+	for _, m := range v.matches {
+		hits[m] = true
+	}
+	end := min(v.offset+rows, len(v.lines))
+	for i := v.offset; i < end; i++ {
+		gutter := "  "
+		switch {
+		case i == current:
+			gutter = matchStyle.Render("▶ ")
+		case hits[i]:
+			gutter = matchStyle.Render("▌ ")
+		}
+		// Reset after each line so an unterminated color does not bleed
+		// into the next.
+		s.WriteString(gutter + ansi.Truncate(v.lines[i], width-2, "…") + "\x1b[0m\n")
+	}
+
+	content := padLines(s.String(), height-1)
+	return content + v.footer(width, rows) + "\n"
+}
+
+// footer shows the search prompt, the last error or the scroll position.
+func (v *logViewer) footer(width, rows int) string {
+	var text string
+	switch {
+	case v.search:
+		text = "/" + v.query + "▏"
+	case v.err != "" && len(v.lines) > 0:
+		return statusStyle(sprites.StatusError).Render("  " + truncate(v.err, width-4))
+	case v.query != "":
+		if len(v.matches) == 0 {
+			text = fmt.Sprintf("/%s: no matches", v.query)
+		} else {
+			text = fmt.Sprintf("/%s: %d of %d", v.query, v.match+1, len(v.matches))
+		}
+	}
+	if len(v.lines) > 0 {
+		pos := fmt.Sprintf("lines %d-%d of %d", v.offset+1, min(v.offset+rows, len(v.lines)), len(v.lines))
+		if text != "" {
+			text += " · "
+		}
+		text += pos
+	}
+	return mutedStyle.Render("  " + truncate(text, width-4))
+}
+
+// logRows is how many log lines fit in a viewer of height, less the title
+// and footer.
+func logRows(height int) int {
+	return max(1, height-2)
+}
+
+// openLogs opens the log viewer on s and starts reading, unless s is
+// asleep: reading would wake it, and bill it.
+func (d Dashboard) openLogs(s sprites.Sprite) (tea.Model, tea.Cmd) {
+	name := s.Name
+	d.logs = newLogViewer(name)
+	if !poller.Pollable(s) {
+		d.logs.asleep, d.logs.loading = true, false
+		return d, nil
+	}
+	src := d.cli
+	services := func() tea.Msg {
+		list, _ := src.ListServices(context.Background(), name)
+		return servicesLoadedMsg{sprite: name, services: list}
+	}
+	return d, tea.Batch(d.readLogs(), services)
+}
+
+// readLogs fetches the viewer's current target.
+func (d Dashboard) readLogs() tea.Cmd {
+	v := d.logs
+	src, target, gen := d.cli, v.target(), v.gen
+	return func() tea.Msg {
+		lines, err := logs.Fetch(context.Background(), src, target, logs.DefaultLines)
+		return logsLoadedMsg{sprite: target.Sprite, gen: gen, lines: lines, err: err}
+	}
+}
+
+// restartLogs starts a new read loop, abandoning the one in flight.
+func (d Dashboard) restartLogs() tea.Cmd {
+	if d.logs.asleep {
+		return nil
+	}
+	d.logs.gen++
+	d.logs.loading = true
+	return d.readLogs()
+}
+
+// logsRows is the viewport height for the current terminal size.
+func (d Dashboard) logsRows() int {
+	return logRows(d.height - headerLines - footerLines)
+}
+
+// handleLogsKey handles input while the log viewer is open.
+func (d Dashboard) handleLogsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	v := d.logs
+	rows := d.logsRows()
+
+	if v.search {
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return d, tea.Quit
+		case tea.KeyEnter:
+			v.search = false
+			v.firstMatch(rows)
+		case tea.KeyEsc:
+			v.search = false
+			v.query = ""
+			v.findMatches()
+		case tea.KeyBackspace:
+			if r := []rune(v.query); len(r) > 0 {
+				v.query = string(r[:len(r)-1])
+				v.findMatches()
+			}
+		case tea.KeyRunes, tea.KeySpace:
+			v.query += string(msg.Runes)
+			v.findMatches()
+		}
+		return d, nil
+	}
+
+	switch msg.String() {
+	case "ctrl+c":
+		return d, tea.Quit
+	case "esc", "q":
+		if v.query != "" && msg.String() == "esc" {
+			v.query = ""
+			v.findMatches()
+			return d, nil
+		}
+		d.logs = nil
+		return d, nil
+	case "j", "down":
+		v.scroll(1, rows)
+	case "k", "up":
+		v.scroll(-1, rows)
+	case "ctrl+d", "pgdown":
+		v.scroll(rows/2, rows)
+	case "ctrl+u", "pgup":
+		v.scroll(-rows/2, rows)
+	case "g", "home":
+		v.scroll(-len(v.lines), rows)
+	case "G", "end":
+		v.scroll(len(v.lines), rows)
+	case "/":
+		v.search = true
+		v.query = ""
+		v.findMatches()
+	case "n":
+		v.jump(1, rows)
+	case "N":
+		v.jump(-1, rows)
+	case " ", "p":
+		v.paused = !v.paused
+		if !v.paused {
+			return d, d.restartLogs()
+		}
+	case "tab":
+		if len(v.targets) > 1 {
+			v.current = (v.current + 1) % len(v.targets)
+			v.lines, v.offset, v.follow = nil, 0, true
+			return d, d.restartLogs()
+		}
+	}
+	return d, nil
+}
+
+// updateLogs handles the log viewer's background messages.
+func (d Dashboard) updateLogs(msg tea.Msg) (tea.Model, tea.Cmd) {
+	v := d.logs
+	switch msg := msg.(type) {
+	case logsLoadedMsg:
+		if v == nil || v.sprite != msg.sprite || v.gen != msg.gen {
+			return d, nil
+		}
+		if !v.paused {
+			v.setLines(msg.lines, msg.err, d.logsRows())
+		}
+		sprite, gen := msg.sprite, msg.gen
+		return d, tea.Tick(logInterval, func(time.Time) tea.Msg {
+			return logTickMsg{sprite: sprite, gen: gen}
+		})
+
+	case logTickMsg:
+		if v == nil || v.sprite != msg.sprite || v.gen != msg.gen || v.paused {
+			return d, nil
+		}
+		return d, d.readLogs()
+
+	case servicesLoadedMsg:
+		if v != nil && v.sprite == msg.sprite {
+			v.setServices(msg.services)
+		}
+	}
+	return d, nil
+}
@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/JPM1118/slua/internal/sprites"
+)
+
+// orgSource is implemented by sources spanning several organizations,
+// such as sprites.Multi, which list Sprites as "org/name". The dashboard
+// then groups the list by organization and offers a choice of where to
+// create Sprites.
+type orgSource interface {
+	Orgs() []string
+	OrgErrors() map[string]error
+	In(org string) sprites.SpriteSource
+}
+
+// spriteKey returns the name the dashboard, poller and state file know
+// name in org by: "org/name" when the source spans organizations, as
+// sprites.Multi lists it. An empty org is the first, the default.
+func (d Dashboard) spriteKey(org, name string) string {
+	if len(d.orgs) == 0 {
+		return name
+	}
+	if org == "" {
+		org = d.orgs[0]
+	}
+	return org + "/" + name
+}
+
+// displayName returns the name s is shown by: without its organization,
+// unless another organization has a Sprite of the same name.
+func (d Dashboard) displayName(s sprites.Sprite) string {
+	org := d.orgOf(s)
+	bare, ok := strings.CutPrefix(s.Name, org+"/")
+	if !ok || org == "" || d.shared[bare] {
+		return s.Name
+	}
+	return bare
+}
+
+// sharedNames returns the names that several organizations in list have.
+func (d Dashboard) sharedNames(list []sprites.Sprite) map[string]bool {
+	orgs := make(map[string]string)
+	shared := make(map[string]bool)
+	for _, s := range list {
+		org := d.orgOf(s)
+		bare := strings.TrimPrefix(s.Name, org+"/")
+		if first, ok := orgs[bare]; ok && first != org {
+			shared[bare] = true
+		}
+		orgs[bare] = org
+	}
+	return shared
+}
+
+// orgOf returns the organization s belongs to. Rows for Sprites still
+// being created have none yet, so it is read from their name.
+func (d Dashboard) orgOf(s sprites.Sprite) string {
+	if s.Org != "" {
+		return s.Org
+	}
+	if org, _, ok := strings.Cut(s.Name, "/"); ok && slices.Contains(d.orgs, org) {
+		return org
+	}
+	if len(d.orgs) > 0 {
+		return d.orgs[0]
+	}
+	return ""
+}
@@ -0,0 +1,201 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/queue"
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/templates"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tasksDispatchedMsg reports the tasks assigned by one dispatch, with
+// every task as it then stood.
+type tasksDispatchedMsg struct {
+	plan  []queue.Assignment
+	tasks []state.Task
+	err   error
+}
+
+// WithQueue dispatches tasks from `slua queue` to idle Sprites after each
+// poll, creating Sprites as opts allow. It needs WithState and WithPoller.
+func WithQueue(opts queue.Options) Option {
+	return func(d *Dashboard) {
+		d.queue = &opts
+	}
+}
+
+// dispatch assigns queued tasks to idle Sprites in the background, unless
+// a dispatch is already under way. Tasks assigned for too long fail first.
+func (d *Dashboard) dispatch() tea.Cmd {
+	if d.queue == nil || d.store == nil || d.assigning {
+		return nil
+	}
+	var idle []string
+	for _, s := range d.listed() {
+		if _, busy := d.pending[s.Name]; !busy && queue.Idle(d.displayStatus(s)) {
+			idle = append(idle, s.Name)
+		}
+	}
+	room := max(d.queue.MaxSprites-len(d.sprites), 0)
+	d.assigning = true
+
+	// Sprites created for tasks go to the default organization and must
+	// not take the name of one that exists or is being created.
+	key := d.spriteKey
+	names := make(map[string]bool)
+	for _, s := range d.sprites {
+		names[s.Name] = true
+	}
+	for name := range d.creating {
+		names[name] = true
+	}
+	taken := func(name string) bool { return names[key("", name)] }
+
+	store := d.store
+	return func() tea.Msg {
+		// Most polls find nothing queued or expired; only those that do
+		// write.
+		now := time.Now()
+		st, err := store.Load()
+		if err != nil || (len(st.Queued()) == 0 && !st.ExpireTasks(now)) {
+			return tasksDispatchedMsg{tasks: st.Tasks, err: err}
+		}
+		var msg tasksDispatchedMsg
+		msg.err = store.Update(func(st *state.State) {
+			msg.plan = nil
+			st.ExpireTasks(now)
+			for _, a := range queue.Plan(st.Queued(), queue.Candidates(st, idle), room, taken) {
+				sprite := a.Sprite
+				if a.Create {
+					sprite = key("", sprite)
+				}
+				if st.AssignTask(a.Task.ID, sprite, now) {
+					msg.plan = append(msg.plan, a)
+				}
+			}
+			msg.tasks = slices.Clone(st.Tasks)
+		})
+		return msg
+	}
+}
+
+// updateDispatch sends each assigned task's prompt, or creates the Sprite
+// that will run it.
+func (d Dashboard) updateDispatch(msg tasksDispatchedMsg) (tea.Model, tea.Cmd) {
+	d.assigning = false
+	if msg.err != nil {
+		d.lastErr = fmt.Sprintf("Dispatching tasks failed: %s", msg.err.Error())
+		return d, nil
+	}
+	d.tasks = msg.tasks
+	if len(msg.plan) == 0 {
+		return d, nil
+	}
+
+	d.lastErr = ""
+	d.notice = fmt.Sprintf("Dispatched %s", plural(len(msg.plan), "task"))
+	var cmds []tea.Cmd
+	for _, a := range msg.plan {
+		if !a.Create {
+			cmds = append(cmds, d.sendPrompt(a.Sprite, a.Task.Prompt, a.Task.ID))
+			continue
+		}
+		name := a.Task.Template
+		if name == "" {
+			name = d.queue.Template
+		}
+		if name == "" {
+			name = templates.Blank
+		}
+		i := slices.IndexFunc(d.tmpls, func(t templates.Template) bool { return t.Name == name })
+		if i < 0 {
+			cmds = append(cmds, d.failTask(a.Task.ID, fmt.Sprintf("unknown template %q", name)))
+			continue
+		}
+		d.forTask[d.spriteKey("", a.Sprite)] = a.Task
+		m, cmd := d.startCreate("", a.Sprite, "", d.tmpls[i])
+		d = m.(Dashboard)
+		cmds = append(cmds, cmd)
+	}
+	return d, tea.Batch(cmds...)
+}
+
+// createdForTask starts the task name was created for, if any, now that
+// creating it is over. It returns events to save with the create event:
+// the task's tags for the new Sprite. A Sprite that failed to set up fails
+// its task.
+func (d *Dashboard) createdForTask(name string, err error) ([]state.Event, tea.Cmd) {
+	t, ok := d.forTask[name]
+	if !ok {
+		return nil, nil
+	}
+	delete(d.forTask, name)
+	if err != nil {
+		return nil, d.failTask(t.ID, "creating the Sprite failed: "+err.Error())
+	}
+	var events []state.Event
+	if len(t.Tags) > 0 {
+		events = append(events, state.Event{At: time.Now(), Sprite: name, Kind: state.EventTag, Tags: t.Tags})
+		d.tags[name] = t.Tags
+	}
+	return events, d.sendPrompt(name, t.Prompt, t.ID)
+}
+
+// settleTasks ends the tasks whose run results show to be over though no
+// transition said so: a run that starts and ends between two checks goes
+// from FINISHED to FINISHED, and the first check after a Sprite wakes is
+// not a transition.
+func (d *Dashboard) settleTasks(results map[string]poller.Result) tea.Cmd {
+	var over []poller.Result
+	for _, t := range d.tasks {
+		r, ok := results[t.Sprite]
+		if !ok || !t.Active() || t.StartedAt.IsZero() || r.Err != nil || r.CheckedAt.Before(t.StartedAt) {
+			continue
+		}
+		if r.Status == sprites.StatusFinished || r.Status == sprites.StatusError {
+			over = append(over, r)
+		}
+	}
+	if len(over) == 0 {
+		return nil
+	}
+	return d.updateState(func(st *state.State) {
+		for _, r := range over {
+			st.SettleTask(r.Name, r.Status, r.Detail, r.CheckedAt)
+		}
+	})
+}
+
+// failTask ends task id with reason in the state file.
+func (d *Dashboard) failTask(id int, reason string) tea.Cmd {
+	d.lastErr = fmt.Sprintf("Task #%d failed: %s", id, reason)
+	return d.updateState(func(st *state.State) {
+		st.FailTask(id, reason, time.Now())
+	})
+}
+
+// currentTask returns the task name is running, if any.
+func (d Dashboard) currentTask(name string) (state.Task, bool) {
+	for _, t := range d.tasks {
+		if t.Active() && t.Sprite == name {
+			return t, true
+		}
+	}
+	return state.Task{}, false
+}
+
+// queuedTasks counts the tasks waiting for a Sprite.
+func (d Dashboard) queuedTasks() int {
+	n := 0
+	for _, t := range d.tasks {
+		if t.Status == state.TaskQueued {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,132 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/JPM1118/slua/internal/fleet"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/templates"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// replySettle is how long to wait after answering before checking the
+// Sprite again, so Claude Code has taken the answer off the screen.
+var replySettle = 500 * time.Millisecond
+
+// replySentMsg reports an answer typed into a Sprite's Claude Code pane,
+// with the state detected right after when there is a poller.
+type replySentMsg struct {
+	name   string
+	answer string
+	err    error
+	result *poller.Result
+}
+
+func newReplyDialog(name, prompt string) *confirmDialog {
+	if prompt == "" {
+		prompt = "(no prompt text detected)"
+	}
+	return &confirmDialog{
+		title:  fmt.Sprintf("Answer %s", name),
+		body:   truncate(prompt, 60),
+		target: name,
+		options: []confirmOption{
+			{key: "y", label: "Send y", action: actionReplyYes},
+			{key: "n", label: "Send n", action: actionReplyNo},
+			{key: "t", label: "Type an answer", action: actionReplyText},
+		},
+	}
+}
+
+// openReply offers to answer the selected Sprite's prompt.
+func (d Dashboard) openReply() (tea.Model, tea.Cmd) {
+	s, ok := d.selected()
+	if !ok {
+		return d, nil
+	}
+	if d.displayStatus(s) != sprites.StatusWaiting {
+		d.notice = fmt.Sprintf("%s is not waiting for an answer", s.Name)
+		return d, nil
+	}
+	d.confirm = newReplyDialog(s.Name, d.results[s.Name].Detail)
+	return d, nil
+}
+
+// startReply types answer into name's Claude Code pane and checks the
+// Sprite again once it has had a moment to react.
+func (d Dashboard) startReply(name, answer string) (tea.Model, tea.Cmd) {
+	d.bar.Dismiss(name)
+	d.lastErr = ""
+	d.notice = fmt.Sprintf("Answering %s…", name)
+	src, pl := d.cli, d.poller
+	_, target := d.agent(name)
+	return d, func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), fleet.DefaultTimeout)
+		defer cancel()
+		res, err := src.Exec(ctx, name, poller.ReplyCommand(target, answer))
+		if err == nil && res.ExitCode != 0 {
+			err = fmt.Errorf("exit code %d", res.ExitCode)
+			if line := templates.LastLine(res.Stderr); line != "" {
+				err = errors.New(line)
+			}
+		}
+		msg := replySentMsg{name: name, answer: answer, err: err}
+		if err != nil || pl == nil {
+			return msg
+		}
+		time.Sleep(replySettle)
+		r := pl.Poll(ctx, name)
+		msg.result = &r
+		return msg
+	}
+}
+
+// updateReply handles the result of an answer.
+func (d Dashboard) updateReply(msg replySentMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		d.lastErr = fmt.Sprintf("Answer to %s failed: %s", msg.name, msg.err.Error())
+		d.notice = ""
+		return d, nil
+	}
+	d.notice = fmt.Sprintf("Sent %q to %s", msg.answer, msg.name)
+	if msg.result == nil {
+		return d, d.refresh()
+	}
+	return d, d.applyResult(*msg.result)
+}
+
+// applyResult merges one Sprite's detection made outside a poll cycle and
+// reacts to its transition, saving events before it.
+func (d *Dashboard) applyResult(r poller.Result, events ...state.Event) tea.Cmd {
+	// The cycle's map is shared with the poller's observers.
+	results := maps.Clone(d.results)
+	if results == nil {
+		results = make(map[string]poller.Result)
+	}
+	results[r.Name] = r
+	d.results = results
+	for i := range d.sprites {
+		if d.sprites[i].Name == r.Name {
+			d.sprites[i].Status = r.Status
+		}
+	}
+	settle := d.settleTasks(map[string]poller.Result{r.Name: r})
+	t, ok := r.Transition()
+	if !ok {
+		return tea.Batch(d.saveState(events...), settle)
+	}
+	return tea.Batch(d.handleTransition(t), settle, d.saveState(append(events, state.Event{
+		At:     t.At,
+		Sprite: t.Name,
+		Kind:   state.EventTransition,
+		From:   t.From,
+		To:     t.To,
+		Detail: r.Detail,
+	})...))
+}
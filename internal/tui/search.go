@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// match is a Sprite that passes the current filter, with the rune
+// positions in its name and status that matched the query. When the list
+// is grouped, a collapsed group is a single row with folded set instead.
+type match struct {
+	sprite sprites.Sprite
+	name   []int
+	status []int
+	group  string // group the row is listed under, when grouping
+	folded int    // Sprites hidden under this collapsed group's header
+}
+
+// fuzzyMatch reports whether every rune of query appears in text in order,
+// ignoring case, and returns the positions of the matched runes in text.
+func fuzzyMatch(query, text string) ([]int, bool) {
+	q := []rune(strings.ToLower(query))
+	if len(q) == 0 {
+		return nil, true
+	}
+
+	var pos []int
+	i := 0
+	for j, r := range []rune(text) {
+		if unicode.ToLower(r) == q[i] {
+			pos = append(pos, j)
+			i++
+			if i == len(q) {
+				return pos, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// filterSprites returns the Sprites matching query. Each whitespace
+// separated term must fuzzy-match the name as shown by name, region,
+// organization, detected status or one of the Sprite's tags; the list
+// keeps its original order. Transient states are not matched so a Sprite
+// does not drop out of the filter while it is being checkpointed.
+func filterSprites(list []sprites.Sprite, query string, tags map[string][]string, name func(sprites.Sprite) string) []match {
+	terms := strings.Fields(query)
+	out := make([]match, 0, len(list))
+
+next:
+	for _, s := range list {
+		m := match{sprite: s}
+		for _, term := range terms {
+			if pos, ok := fuzzyMatch(term, name(s)); ok {
+				m.name = append(m.name, pos...)
+				continue
+			}
+			if pos, ok := fuzzyMatch(term, s.Status); ok {
+				m.status = append(m.status, pos...)
+				continue
+			}
+			if _, ok := fuzzyMatch(term, s.Region); ok {
+				continue
+			}
+			if _, ok := fuzzyMatch(term, s.Org); ok {
+				continue
+			}
+			if slices.ContainsFunc(tags[s.Name], func(tag string) bool {
+				_, ok := fuzzyMatch(term, tag)
+				return ok
+			}) {
+				continue
+			}
+			continue next
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// highlight renders s with base, drawing the runes at positions pos in the
+// match style.
+func highlight(s string, pos []int, base lipgloss.Style) string {
+	if len(pos) == 0 {
+		return base.Render(s)
+	}
+	hit := make(map[int]bool, len(pos))
+	for _, p := range pos {
+		hit[p] = true
+	}
+	matched := matchStyle.Inherit(base)
+
+	var b strings.Builder
+	var run []rune
+	inMatch := false
+	flush := func() {
+		if len(run) == 0 {
+			return
+		}
+		if inMatch {
+			b.WriteString(matched.Render(string(run)))
+		} else {
+			b.WriteString(base.Render(string(run)))
+		}
+		run = run[:0]
+	}
+	for i, r := range []rune(s) {
+		if hit[i] != inMatch {
+			flush()
+			inMatch = hit[i]
+		}
+		run = append(run, r)
+	}
+	flush()
+	return b.String()
+}
@@ -0,0 +1,258 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/state"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const colSessionID = 10
+
+type sessionsLoadedMsg struct {
+	name     string
+	sessions []sprites.ExecSession
+	err      error
+}
+
+type sessionKilledMsg struct {
+	name string
+	id   string
+	err  error
+}
+
+// sessionCheckedMsg reports whether a Sprite's preferred session is still
+// running, before Enter attaches to it.
+type sessionCheckedMsg struct {
+	name  string
+	id    string
+	alive bool
+}
+
+// sessionPicker is the sub-view listing one Sprite's exec sessions to
+// attach to or kill.
+type sessionPicker struct {
+	sprite    string
+	preferred string // session Enter on the list attaches to
+	sessions  []sprites.ExecSession
+	cursor    int
+	loading   bool
+	err       string
+}
+
+func newSessionPicker(name, preferred string) *sessionPicker {
+	return &sessionPicker{sprite: name, preferred: preferred, loading: true}
+}
+
+// selected returns the session under the cursor.
+func (p *sessionPicker) selected() (sprites.ExecSession, bool) {
+	if len(p.sessions) == 0 {
+		return sprites.ExecSession{}, false
+	}
+	return p.sessions[p.cursor], true
+}
+
+func (p *sessionPicker) setSessions(sessions []sprites.ExecSession, err error) {
+	p.loading = false
+	if err != nil {
+		p.err = err.Error()
+		return
+	}
+	p.err = ""
+	p.sessions = sessions
+	if p.cursor >= len(sessions) {
+		p.cursor = max(0, len(sessions)-1)
+	}
+}
+
+// View renders the picker into a width x height region.
+func (p *sessionPicker) View(width, height int) string {
+	var s strings.Builder
+	s.WriteString(headerStyle.Render("  Sessions · " + p.sprite))
+	s.WriteString("\n\n")
+	s.WriteString(columnHeaderStyle.Render("  " + padRight("ID", colSessionID) + padRight("STARTED", 12) + "COMMAND"))
+	s.WriteString("\n")
+	rows := height - 3
+
+	switch {
+	case p.loading:
+		return padLines(s.String()+"  Loading sessions...\n", height)
+	case p.err != "":
+		return padLines(s.String()+"  "+truncate(p.err, width-4)+"\n", height)
+	case len(p.sessions) == 0:
+		return padLines(s.String()+"  No sessions running. Press n for a new console.\n", height)
+	}
+
+	start := 0
+	if p.cursor >= rows {
+		start = p.cursor - rows + 1
+	}
+	end := min(start+rows, len(p.sessions))
+
+	for i := start; i < end; i++ {
+		es := p.sessions[i]
+		prefix := "  "
+		if i == p.cursor {
+			prefix = cursorStyle.Render("▸ ")
+		}
+		started := "—"
+		if !es.CreatedAt.IsZero() {
+			started = formatAgo(time.Since(es.CreatedAt))
+		}
+		command := es.Command
+		if es.ID == p.preferred {
+			command += mutedStyle.Render("  ★ Enter attaches")
+		}
+		line := padRight(truncate(es.ID, colSessionID-2), colSessionID) + mutedStyle.Render(padRight(started, 12))
+		s.WriteString(prefix + line + truncate(command, width-colSessionID-16) + "\n")
+	}
+	return padLines(s.String(), height)
+}
+
+// loadSessions lists name's exec sessions in the background.
+func (d Dashboard) loadSessions(name string) tea.Cmd {
+	src := d.cli
+	return func() tea.Msg {
+		sessions, err := src.ListSessions(context.Background(), name)
+		return sessionsLoadedMsg{name: name, sessions: sessions, err: err}
+	}
+}
+
+// checkSession looks up whether session id is still running on name.
+// When the list cannot be read the session is assumed alive and attaching
+// reports the problem.
+func (d Dashboard) checkSession(name, id string) tea.Cmd {
+	src := d.cli
+	return func() tea.Msg {
+		sessions, err := src.ListSessions(context.Background(), name)
+		_, found := sprites.FindSession(sessions, id)
+		return sessionCheckedMsg{name: name, id: id, alive: found || err != nil}
+	}
+}
+
+// startKillSession ends session id on name in the background.
+func (d Dashboard) startKillSession(name, id string) (tea.Model, tea.Cmd) {
+	src := d.cli
+	return d, func() tea.Msg {
+		err := src.KillSession(context.Background(), name, id)
+		return sessionKilledMsg{name: name, id: id, err: err}
+	}
+}
+
+// connect hands the terminal to name: session id when set, otherwise a
+// new console. The choice is remembered as the Sprite's preferred session.
+func (d Dashboard) connect(name, id string) (tea.Model, tea.Cmd) {
+	d.bar.Dismiss(name)
+	if d.notifier != nil {
+		d.notifier.Suspend()
+	}
+	if id != "" {
+		d.preferred[name] = id
+	} else {
+		delete(d.preferred, name)
+	}
+	connected := state.Event{At: time.Now(), Sprite: name, Kind: state.EventConnect, User: state.CurrentUser(), Session: id}
+	c := d.cli.ConsoleCmd(name)
+	if id != "" {
+		c = d.cli.AttachCmd(name, id)
+	}
+	return d, tea.Batch(d.saveState(connected), tea.ExecProcess(c, func(err error) tea.Msg {
+		return consoleFinishedMsg{err: err}
+	}))
+}
+
+// handleSessionsKey handles input while the session picker is open.
+func (d Dashboard) handleSessionsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	p := d.picker
+	switch msg.String() {
+	case "ctrl+c":
+		return d, tea.Quit
+
+	case "esc", "q":
+		d.picker = nil
+		return d, nil
+
+	case "j", "down":
+		if p.cursor < len(p.sessions)-1 {
+			p.cursor++
+		}
+		return d, nil
+
+	case "k", "up":
+		if p.cursor > 0 {
+			p.cursor--
+		}
+		return d, nil
+
+	case "r":
+		p.loading = true
+		return d, d.loadSessions(p.sprite)
+
+	case "enter":
+		es, ok := p.selected()
+		if !ok {
+			return d, nil
+		}
+		d.picker = nil
+		return d.connect(p.sprite, es.ID)
+
+	case "n":
+		d.picker = nil
+		return d.connect(p.sprite, "")
+
+	case "x":
+		if es, ok := p.selected(); ok {
+			d.confirm = newKillSessionDialog(p.sprite, es)
+		}
+		return d, nil
+	}
+	return d, nil
+}
+
+// updateSessions handles the session picker's and preferred session's
+// background messages.
+func (d Dashboard) updateSessions(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case sessionsLoadedMsg:
+		if d.picker != nil && d.picker.sprite == msg.name {
+			d.picker.setSessions(msg.sessions, msg.err)
+		}
+
+	case sessionKilledMsg:
+		if msg.err != nil {
+			d.lastErr = fmt.Sprintf("Kill session %s on %s failed: %s", msg.id, msg.name, msg.err.Error())
+			return d, nil
+		}
+		d.notice = fmt.Sprintf("Killed session %s on %s", msg.id, msg.name)
+		if d.preferred[msg.name] == msg.id {
+			delete(d.preferred, msg.name)
+		}
+		// The state file forgets it too, so the next run does not try to
+		// attach to it.
+		save := d.saveState(state.Event{At: time.Now(), Sprite: msg.name, Kind: state.EventKill, User: state.CurrentUser(), Session: msg.id})
+		if d.picker != nil && d.picker.sprite == msg.name {
+			if d.picker.preferred == msg.id {
+				d.picker.preferred = ""
+			}
+			d.picker.loading = true
+			return d, tea.Batch(save, d.loadSessions(msg.name), d.invalidateDetail(msg.name))
+		}
+		return d, tea.Batch(save, d.invalidateDetail(msg.name))
+
+	case sessionCheckedMsg:
+		if d.checking != msg.name || d.preferred[msg.name] != msg.id {
+			return d, nil
+		}
+		d.checking = ""
+		if msg.alive {
+			return d.connect(msg.name, msg.id)
+		}
+		d.notice = fmt.Sprintf("Session %s on %s has ended; opened a new console", msg.id, msg.name)
+		return d.connect(msg.name, "")
+	}
+	return d, nil
+}
@@ -5,57 +5,133 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
+// Palette holds the dashboard colors as lipgloss color strings: an ANSI
+// index such as "3" or a hex value such as "#ffaa00".
+type Palette struct {
+	Working     string
+	Finished    string
+	Waiting     string
+	Error       string
+	Sleeping    string
+	Unreachable string
+	Header      string
+	Muted       string
+	Cursor      string
+}
+
+// DefaultPalette is the palette used unless SetPalette is called.
+var DefaultPalette = Palette{
+	Working:     "3",  // yellow
+	Finished:    "2",  // green
+	Waiting:     "1",  // red
+	Error:       "1",  // red
+	Sleeping:    "8",  // dim gray
+	Unreachable: "8",  // dim gray
+	Header:      "12", // bright blue
+	Muted:       "8",  // dim
+	Cursor:      "6",  // cyan
+}
+
+// Styles, built from the active palette by SetPalette.
 var (
-	// Colors
-	colorWorking     = lipgloss.Color("3")  // yellow
-	colorFinished    = lipgloss.Color("2")  // green
-	colorWaiting     = lipgloss.Color("1")  // red
-	colorError       = lipgloss.Color("1")  // red
-	colorSleeping    = lipgloss.Color("8")  // dim gray
-	colorUnreachable = lipgloss.Color("8")  // dim gray
-	colorHeader      = lipgloss.Color("12") // bright blue
-	colorMuted       = lipgloss.Color("8")  // dim
-	colorCursor      = lipgloss.Color("6")  // cyan
-
-	// Styles
+	headerStyle          lipgloss.Style
+	subheaderStyle       lipgloss.Style
+	cursorStyle          lipgloss.Style
+	columnHeaderStyle    lipgloss.Style
+	statusBarStyle       lipgloss.Style
+	notificationBarStyle lipgloss.Style
+	badgeStyle           lipgloss.Style
+	mutedStyle           lipgloss.Style
+	matchStyle           lipgloss.Style
+	dialogStyle          lipgloss.Style
+	dialogTitleStyle     lipgloss.Style
+
+	// Pre-allocated status styles
+	statusStyleWorking     lipgloss.Style
+	statusStyleFinished    lipgloss.Style
+	statusStyleWaiting     lipgloss.Style
+	statusStyleError       lipgloss.Style
+	statusStyleSleeping    lipgloss.Style
+	statusStyleUnreachable lipgloss.Style
+	statusStyleTransient   lipgloss.Style
+	statusStyleDefault     lipgloss.Style
+)
+
+func init() {
+	SetPalette(DefaultPalette)
+}
+
+// SetPalette rebuilds every style from p. Empty fields fall back to
+// DefaultPalette. Call it before the program starts.
+func SetPalette(p Palette) {
+	pick := func(v, def string) lipgloss.Color {
+		if v == "" {
+			return lipgloss.Color(def)
+		}
+		return lipgloss.Color(v)
+	}
+	def := DefaultPalette
+	colorWorking := pick(p.Working, def.Working)
+	colorFinished := pick(p.Finished, def.Finished)
+	colorWaiting := pick(p.Waiting, def.Waiting)
+	colorError := pick(p.Error, def.Error)
+	colorSleeping := pick(p.Sleeping, def.Sleeping)
+	colorUnreachable := pick(p.Unreachable, def.Unreachable)
+	colorHeader := pick(p.Header, def.Header)
+	colorMuted := pick(p.Muted, def.Muted)
+	colorCursor := pick(p.Cursor, def.Cursor)
+
 	headerStyle = lipgloss.NewStyle().
-			Bold(true).
-			Foreground(colorHeader)
+		Bold(true).
+		Foreground(colorHeader)
 
 	subheaderStyle = lipgloss.NewStyle().
-			Foreground(colorMuted)
+		Foreground(colorMuted)
 
 	cursorStyle = lipgloss.NewStyle().
-			Foreground(colorCursor).
-			Bold(true)
+		Foreground(colorCursor).
+		Bold(true)
 
 	columnHeaderStyle = lipgloss.NewStyle().
-				Foreground(colorMuted).
-				Underline(true)
+		Foreground(colorMuted).
+		Underline(true)
 
 	statusBarStyle = lipgloss.NewStyle().
-			Foreground(colorMuted)
+		Foreground(colorMuted)
 
 	notificationBarStyle = lipgloss.NewStyle().
-				Foreground(colorMuted).
-				Italic(true)
+		Foreground(colorMuted).
+		Italic(true)
 
 	badgeStyle = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("1")).
-			Bold(true)
+		Foreground(colorError).
+		Bold(true)
 
 	mutedStyle = lipgloss.NewStyle().
-			Foreground(colorMuted)
+		Foreground(colorMuted)
 
-	// Pre-allocated status styles
-	statusStyleWorking     = lipgloss.NewStyle().Foreground(colorWorking)
-	statusStyleFinished    = lipgloss.NewStyle().Foreground(colorFinished)
-	statusStyleWaiting     = lipgloss.NewStyle().Foreground(colorWaiting).Bold(true)
-	statusStyleError       = lipgloss.NewStyle().Foreground(colorError).Bold(true)
-	statusStyleSleeping    = lipgloss.NewStyle().Foreground(colorSleeping)
+	matchStyle = lipgloss.NewStyle().
+		Foreground(colorCursor).
+		Bold(true).
+		Underline(true)
+
+	dialogStyle = lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(colorHeader).
+		Padding(1, 3)
+
+	dialogTitleStyle = lipgloss.NewStyle().
+		Bold(true)
+
+	statusStyleWorking = lipgloss.NewStyle().Foreground(colorWorking)
+	statusStyleFinished = lipgloss.NewStyle().Foreground(colorFinished)
+	statusStyleWaiting = lipgloss.NewStyle().Foreground(colorWaiting).Bold(true)
+	statusStyleError = lipgloss.NewStyle().Foreground(colorError).Bold(true)
+	statusStyleSleeping = lipgloss.NewStyle().Foreground(colorSleeping)
 	statusStyleUnreachable = lipgloss.NewStyle().Foreground(colorUnreachable)
-	statusStyleDefault     = lipgloss.NewStyle().Foreground(colorMuted)
-)
+	statusStyleTransient = lipgloss.NewStyle().Foreground(colorCursor)
+	statusStyleDefault = lipgloss.NewStyle().Foreground(colorMuted)
+}
 
 // statusStyle returns the appropriate style for a Sprite status.
 func statusStyle(status string) lipgloss.Style {
@@ -72,6 +148,8 @@ func statusStyle(status string) lipgloss.Style {
 		return statusStyleSleeping
 	case sprites.StatusUnreachable:
 		return statusStyleUnreachable
+	case sprites.StatusDestroying, sprites.StatusCreating, sprites.StatusCheckpointing:
+		return statusStyleTransient
 	default:
 		return statusStyleDefault
 	}
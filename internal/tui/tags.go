@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/state"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tagEditor is the input for one Sprite's tags, separated by spaces or
+// commas.
+type tagEditor struct {
+	sprite string
+	input  string
+}
+
+func newTagEditor(name string, tags []string) *tagEditor {
+	return &tagEditor{sprite: name, input: strings.Join(tags, " ")}
+}
+
+// handleTagKey handles input while editing tags.
+func (d Dashboard) handleTagKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	e := d.tagger
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return d, tea.Quit
+	case tea.KeyEsc:
+		d.tagger = nil
+	case tea.KeyEnter:
+		d.tagger = nil
+		return d, d.setTags(e.sprite, state.ParseTags(e.input))
+	case tea.KeyBackspace:
+		if r := []rune(e.input); len(r) > 0 {
+			e.input = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		e.input += string(msg.Runes)
+	}
+	return d, nil
+}
+
+// setTags replaces name's tags and saves them to the state file.
+func (d *Dashboard) setTags(name string, tags []string) tea.Cmd {
+	if len(tags) == 0 {
+		delete(d.tags, name)
+		d.notice = "Cleared tags on " + name
+	} else {
+		d.tags[name] = tags
+		d.notice = fmt.Sprintf("Tagged %s: %s", name, strings.Join(tags, ", "))
+	}
+	d.clampCursor()
+	return d.saveState(state.Event{At: time.Now(), Sprite: name, Kind: state.EventTag, User: state.CurrentUser(), Tags: tags})
+}
@@ -0,0 +1,166 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/JPM1118/slua/internal/fleet"
+	"github.com/JPM1118/slua/internal/poller"
+	"github.com/JPM1118/slua/internal/state"
+	"github.com/JPM1118/slua/internal/templates"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// taskEditorWidth is the widest the prompt editor grows.
+const taskEditorWidth = 72
+
+// detailPrompts is how many recent prompts the detail pane shows.
+const detailPrompts = 3
+
+// taskEditor is the multi-line input for a new prompt to one Sprite's
+// Claude Code.
+type taskEditor struct {
+	sprite string
+	input  string
+}
+
+// taskSentMsg reports a prompt given to a Sprite's Claude Code, with the
+// state detected right after when there is a poller.
+type taskSentMsg struct {
+	name    string
+	text    string
+	task    int       // queued task the prompt is for, zero if typed
+	at      time.Time // when it was delivered
+	started bool      // Claude Code was not running and was started with text
+	err     error
+	result  *poller.Result
+}
+
+// handleTaskKey handles input while writing a prompt. Enter starts a new
+// line; Ctrl+S sends.
+func (d Dashboard) handleTaskKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	e := d.task
+	switch msg.Type {
+	case tea.KeyCtrlC:
+		return d, tea.Quit
+	case tea.KeyEsc:
+		d.task = nil
+	case tea.KeyCtrlS:
+		if strings.TrimSpace(e.input) == "" {
+			return d, nil
+		}
+		d.task = nil
+		return d.startTask(e.sprite, strings.Trim(e.input, "\n"))
+	case tea.KeyEnter:
+		e.input += "\n"
+	case tea.KeyBackspace:
+		if r := []rune(e.input); len(r) > 0 {
+			e.input = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		e.input += strings.ReplaceAll(string(msg.Runes), "\r", "\n")
+	}
+	return d, nil
+}
+
+// startTask gives name's Claude Code the prompt text.
+func (d Dashboard) startTask(name, text string) (tea.Model, tea.Cmd) {
+	d.lastErr = ""
+	d.notice = fmt.Sprintf("Sending prompt to %s…", name)
+	return d, d.sendPrompt(name, text, 0)
+}
+
+// sendPrompt gives name's Claude Code the prompt text, starting it if it
+// is not running, and checks the Sprite again once it has had a moment to
+// react. A queued task gets a run of its own that exits when done, so the
+// task ends.
+func (d Dashboard) sendPrompt(name, text string, task int) tea.Cmd {
+	src, pl := d.cli, d.poller
+	process, target := d.agent(name)
+	command := d.templateOf(name).PromptCommand(process, target, text)
+	if task != 0 {
+		command = d.templateOf(name).TaskCommand(text)
+	}
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), fleet.DefaultTimeout)
+		defer cancel()
+		res, err := src.Exec(ctx, name, command)
+		if err == nil && res.ExitCode != 0 {
+			err = fmt.Errorf("exit code %d", res.ExitCode)
+			if line := templates.LastLine(res.Stderr); line != "" {
+				err = errors.New(line)
+			}
+		}
+		msg := taskSentMsg{name: name, text: text, task: task, at: time.Now(), started: templates.PromptStarted(res.Stdout), err: err}
+		if err != nil || pl == nil {
+			return msg
+		}
+		time.Sleep(replySettle)
+		r := pl.Poll(ctx, name)
+		msg.result = &r
+		return msg
+	}
+}
+
+// updateTask records a prompt that was sent and shows its outcome. A
+// queued task whose prompt could not be delivered fails.
+func (d Dashboard) updateTask(msg taskSentMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		if msg.task != 0 {
+			return d, d.failTask(msg.task, fmt.Sprintf("sending the prompt to %s failed: %s", msg.name, msg.err.Error()))
+		}
+		d.lastErr = fmt.Sprintf("Prompt to %s failed: %s", msg.name, msg.err.Error())
+		d.notice = ""
+		return d, nil
+	}
+	switch {
+	case msg.task != 0:
+		d.notice = fmt.Sprintf("Started task #%d on %s", msg.task, msg.name)
+	case msg.started:
+		d.notice = fmt.Sprintf("Started Claude Code on %s with the prompt", msg.name)
+	default:
+		d.notice = fmt.Sprintf("Sent prompt to %s", msg.name)
+	}
+	sent := state.Event{At: msg.at, Sprite: msg.name, Kind: state.EventPrompt, Detail: msg.text, User: state.CurrentUser(), Task: msg.task}
+	d.prompts[msg.name] = append([]state.Event{sent}, d.prompts[msg.name]...)
+	next := tea.Batch(d.refresh(), d.saveState(sent))
+	if msg.result != nil {
+		// Saved together so the task starts before the transition counts.
+		next = d.applyResult(*msg.result, sent)
+	}
+	return d, tea.Batch(next, d.invalidateDetail(msg.name))
+}
+
+// View renders the editor as a dialog in a width x height region, showing
+// the end of the prompt when it is too long to fit.
+func (e *taskEditor) View(width, height int) string {
+	inner := min(taskEditorWidth, width-10)
+	text := lipgloss.NewStyle().Width(inner).Render(e.input + cursorStyle.Render("▏"))
+	lines := strings.Split(text, "\n")
+	if room := max(height-10, 1); len(lines) > room {
+		lines = lines[len(lines)-room:]
+	}
+
+	var b strings.Builder
+	b.WriteString(dialogTitleStyle.Render("New prompt for " + e.sprite))
+	b.WriteString("\n\n")
+	b.WriteString(strings.Join(lines, "\n"))
+	b.WriteString("\n\n")
+	b.WriteString(mutedStyle.Render("Enter for a new line · Ctrl+S to send · Esc to cancel"))
+
+	box := dialogStyle.Render(b.String())
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// promptLine summarizes a prompt on one line.
+func promptLine(text string) string {
+	line, _, more := strings.Cut(strings.TrimSpace(text), "\n")
+	if more {
+		line += " …"
+	}
+	return line
+}
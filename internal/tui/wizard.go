@@ -0,0 +1,204 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/JPM1118/slua/internal/sprites"
+	"github.com/JPM1118/slua/internal/templates"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// wizardStage is the field the new-Sprite wizard is asking for.
+type wizardStage int
+
+const (
+	stageName wizardStage = iota
+	stageOrg
+	stageRegion
+	stageTemplate
+)
+
+// spriteWizard is the modal behind the n key. It asks for a name, an
+// organization when there are several, a region and a template, one at a
+// time.
+type spriteWizard struct {
+	stage     wizardStage
+	name      string
+	orgs      []string // organizations to choose from, if several
+	org       int      // index into orgs
+	region    string
+	templates []templates.Template
+	cursor    int
+	taken     map[string]bool // names already in use
+	keyOf     func(org, name string) string
+	err       string
+}
+
+// newSpriteWizard returns a wizard offering ts and orgs. keyOf gives the
+// name a Sprite in an organization is listed by, to check against
+// existing.
+func newSpriteWizard(ts []templates.Template, existing []sprites.Sprite, orgs []string, keyOf func(org, name string) string) *spriteWizard {
+	taken := make(map[string]bool, len(existing))
+	for _, s := range existing {
+		taken[s.Name] = true
+	}
+	if len(orgs) < 2 {
+		orgs = nil
+	}
+	return &spriteWizard{templates: ts, orgs: orgs, taken: taken, keyOf: keyOf}
+}
+
+// template returns the chosen template.
+func (w *spriteWizard) template() templates.Template {
+	return w.templates[w.cursor]
+}
+
+// organization returns the chosen organization, or "" for the default.
+func (w *spriteWizard) organization() string {
+	if len(w.orgs) == 0 {
+		return ""
+	}
+	return w.orgs[w.org]
+}
+
+// key handles input other than Esc and ctrl+c, and reports whether the
+// wizard is complete.
+func (w *spriteWizard) key(msg tea.KeyMsg) bool {
+	if w.stage == stageOrg {
+		switch msg.String() {
+		case "j", "down":
+			w.org = min(w.org+1, len(w.orgs)-1)
+		case "k", "up":
+			w.org = max(w.org-1, 0)
+		case "enter":
+			return w.advance()
+		}
+		return false
+	}
+	if w.stage == stageTemplate {
+		switch msg.String() {
+		case "j", "down":
+			if w.cursor < len(w.templates)-1 {
+				w.cursor++
+			}
+		case "k", "up":
+			if w.cursor > 0 {
+				w.cursor--
+			}
+		case "enter":
+			return true
+		}
+		return false
+	}
+
+	field := &w.name
+	if w.stage == stageRegion {
+		field = &w.region
+	}
+	switch msg.Type {
+	case tea.KeyEnter:
+		return w.advance()
+	case tea.KeyBackspace:
+		if r := []rune(*field); len(r) > 0 {
+			*field = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes:
+		*field += string(msg.Runes)
+	}
+	w.err = ""
+	return false
+}
+
+// advance validates the current field and moves to the next stage. It
+// reports whether the wizard is complete.
+func (w *spriteWizard) advance() bool {
+	switch w.stage {
+	case stageName:
+		if err := sprites.ValidateName(w.name); err != nil {
+			w.err = err.Error()
+			return false
+		}
+		if len(w.orgs) > 0 {
+			w.stage = stageOrg
+			break
+		}
+		fallthrough
+	case stageOrg:
+		if w.taken[w.keyOf(w.organization(), w.name)] {
+			w.err = w.name + " already exists"
+			return false
+		}
+		w.stage = stageRegion
+	case stageRegion:
+		w.stage = stageTemplate
+		// A single template needs no choice.
+		return len(w.templates) == 1
+	}
+	w.err = ""
+	return false
+}
+
+// View renders the wizard centered in a width x height region.
+func (w *spriteWizard) View(width, height int) string {
+	var b strings.Builder
+	b.WriteString(dialogTitleStyle.Render("New Sprite"))
+	b.WriteString("\n\n")
+
+	field := func(label, value string, stage wizardStage, placeholder string) {
+		b.WriteString(padRight(label, 10))
+		switch {
+		case w.stage == stage:
+			b.WriteString(cursorStyle.Render(value + "▏"))
+			if value == "" && placeholder != "" {
+				b.WriteString(mutedStyle.Render(placeholder))
+			}
+		case w.stage > stage && value == "":
+			b.WriteString(mutedStyle.Render(placeholder))
+		default:
+			b.WriteString(value)
+		}
+		b.WriteString("\n")
+	}
+	field("Name", w.name, stageName, "")
+	switch {
+	case len(w.orgs) == 0:
+	case w.stage == stageOrg:
+		b.WriteString("Org\n")
+		for i, org := range w.orgs {
+			prefix := "  "
+			if i == w.org {
+				prefix = cursorStyle.Render("▸ ")
+			}
+			b.WriteString(prefix + org + "\n")
+		}
+	default:
+		field("Org", w.organization(), stageOrg, "")
+	}
+	field("Region", w.region, stageRegion, "default")
+
+	b.WriteString("Template\n")
+	if w.stage == stageTemplate {
+		for i, t := range w.templates {
+			prefix := "  "
+			if i == w.cursor {
+				prefix = cursorStyle.Render("▸ ")
+			}
+			line := prefix + t.Name
+			if t.Description != "" {
+				line += "  " + mutedStyle.Render(t.Description)
+			}
+			b.WriteString(line + "\n")
+		}
+	}
+
+	b.WriteString("\n")
+	if w.err != "" {
+		b.WriteString(statusStyle(sprites.StatusError).Render(w.err))
+	} else {
+		b.WriteString(mutedStyle.Render("Enter to continue · Esc to cancel"))
+	}
+
+	box := dialogStyle.Render(b.String())
+	return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, box)
+}